@@ -0,0 +1,39 @@
+package db
+
+// ApproximateSize returns the approximate size in bytes of the keys and values in the range
+// [start, end) of a reader. It uses ApproximateSizeDB.ApproximateSize if the reader implements it,
+// and otherwise computes the exact size by iterating over the range.
+func ApproximateSize(r Reader, start, end []byte) (int64, error) {
+	if adb, ok := r.(ApproximateSizeDB); ok {
+		return adb.ApproximateSize(start, end)
+	}
+	itr, err := r.Iterator(start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
+	var size int64
+	for ; itr.Valid(); itr.Next() {
+		size += int64(len(itr.Key()) + len(itr.Value()))
+	}
+	return size, itr.Error()
+}
+
+// ApproximateCount returns the approximate number of keys in the range [start, end) of a reader. It
+// uses ApproximateSizeDB.ApproximateCount if the reader implements it, and otherwise counts the keys
+// exactly by iterating over the range.
+func ApproximateCount(r Reader, start, end []byte) (int64, error) {
+	if adb, ok := r.(ApproximateSizeDB); ok {
+		return adb.ApproximateCount(start, end)
+	}
+	itr, err := KeysIterator(r, start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
+	var count int64
+	for ; itr.Valid(); itr.Next() {
+		count++
+	}
+	return count, itr.Error()
+}
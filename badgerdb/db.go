@@ -3,11 +3,15 @@ package badgerdb
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/dgraph-io/badger/v2"
+	"github.com/dgraph-io/badger/v2/y"
 	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/estimate"
 )
 
 // NewDB creates a Badger key-value store backed to the
@@ -26,60 +30,121 @@ func NewDB(dbName, dir string) (*BadgerDB, error) {
 	return NewDBWithOptions(opts)
 }
 
+// NewReadOnlyDB opens an existing Badger key-value store in the directory dir without modifying
+// it. All writes fail with ErrReadOnly.
+func NewReadOnlyDB(dbName, dir string) (*BadgerDB, error) {
+	opts := badger.DefaultOptions(filepath.Join(dir, dbName))
+	opts.ReadOnly = true
+	opts.Logger = nil
+	return NewDBWithOptions(opts)
+}
+
 // NewDBWithOptions creates a BadgerDB key value store
 // gives the flexibility of initializing a database with the
-// respective options.
+// respective options. If opts.ReadOnly is set, all writes fail with ErrReadOnly.
 func NewDBWithOptions(opts badger.Options) (*BadgerDB, error) {
 	db, err := badger.Open(opts)
 	if err != nil {
 		return nil, err
 	}
-	return &BadgerDB{db: db}, nil
+	return &BadgerDB{db: db, opts: opts}, nil
 }
 
+// BadgerDB is a Badger backend. Keys are always ordered by their bytes, since Badger does not
+// support custom comparers.
 type BadgerDB struct {
-	db *badger.DB
+	db   *badger.DB
+	opts badger.Options
 }
 
-var _ tmdb.DB = (*BadgerDB)(nil)
+var (
+	_ tmdb.TransactionDB     = (*BadgerDB)(nil)
+	_ tmdb.CompactionDB      = (*BadgerDB)(nil)
+	_ tmdb.CheckpointDB      = (*BadgerDB)(nil)
+	_ tmdb.MultiGetDB        = (*BadgerDB)(nil)
+	_ tmdb.TTLDB             = (*BadgerDB)(nil)
+	_ tmdb.KeysIteratorDB    = (*BadgerDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*BadgerDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*BadgerDB)(nil)
+)
 
 func (b *BadgerDB) Get(key []byte) ([]byte, error) {
 	if len(key) == 0 {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	if b.db.IsClosed() {
+		return nil, tmdb.ErrClosed
+	}
 	var val []byte
-	err := b.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(key)
-		if err == badger.ErrKeyNotFound {
-			return nil
-		} else if err != nil {
-			return err
-		}
-		val, err = item.ValueCopy(nil)
-		if err == nil && val == nil {
-			val = []byte{}
-		}
+	err := b.db.View(func(txn *badger.Txn) (err error) {
+		val, err = get(txn, key)
 		return err
 	})
 	return val, err
 }
 
+func get(txn *badger.Txn, key []byte) ([]byte, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err == nil && val == nil {
+		val = []byte{}
+	}
+	return val, err
+}
+
+// GetMany implements MultiGetDB, reading the keys in a single read-only transaction.
+func (b *BadgerDB) GetMany(keys [][]byte) ([][]byte, error) {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, tmdb.ErrKeyEmpty
+		}
+	}
+	if b.db.IsClosed() {
+		return nil, tmdb.ErrClosed
+	}
+	values := make([][]byte, len(keys))
+	err := b.db.View(func(txn *badger.Txn) (err error) {
+		for i, key := range keys {
+			if values[i], err = get(txn, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 func (b *BadgerDB) Has(key []byte) (bool, error) {
 	if len(key) == 0 {
 		return false, tmdb.ErrKeyEmpty
 	}
+	if b.db.IsClosed() {
+		return false, tmdb.ErrClosed
+	}
 	var found bool
-	err := b.db.View(func(txn *badger.Txn) error {
-		_, err := txn.Get(key)
-		if err != nil && err != badger.ErrKeyNotFound {
-			return err
-		}
-		found = (err != badger.ErrKeyNotFound)
-		return nil
+	err := b.db.View(func(txn *badger.Txn) (err error) {
+		found, err = has(txn, key)
+		return err
 	})
 	return found, err
 }
 
+func has(txn *badger.Txn, key []byte) (bool, error) {
+	_, err := txn.Get(key)
+	if err != nil && err != badger.ErrKeyNotFound {
+		return false, err
+	}
+	return err != badger.ErrKeyNotFound, nil
+}
+
 func (b *BadgerDB) Set(key, value []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
@@ -87,11 +152,40 @@ func (b *BadgerDB) Set(key, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return tmdb.ErrReadOnly
+	}
 	return b.db.Update(func(txn *badger.Txn) error {
 		return txn.Set(key, value)
 	})
 }
 
+// SetWithTTL implements TTLDB, using Badger's native TTLs. Badger stores expiry times with a
+// granularity of one second.
+func (b *BadgerDB) SetWithTTL(key, value []byte, ttl time.Duration) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("invalid TTL %v", ttl)
+	}
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return tmdb.ErrReadOnly
+	}
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(key, value).WithTTL(ttl))
+	})
+}
+
 func withSync(db *badger.DB, err error) error {
 	if err != nil {
 		return err
@@ -107,6 +201,12 @@ func (b *BadgerDB) Delete(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return tmdb.ErrReadOnly
+	}
 	return b.db.Update(func(txn *badger.Txn) error {
 		return txn.Delete(key)
 	})
@@ -116,35 +216,197 @@ func (b *BadgerDB) DeleteSync(key []byte) error {
 	return withSync(b.db, b.Delete(key))
 }
 
+func (b *BadgerDB) DeleteRange(start, end []byte) error {
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return tmdb.ErrReadOnly
+	}
+	batch := b.NewBatch()
+	defer batch.Close()
+	if err := batch.DeleteRange(start, end); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Update implements DB, using a read-write transaction which is retried on conflicts.
+func (b *BadgerDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return tmdb.ErrReadOnly
+	}
+	for {
+		err := b.db.Update(func(txn *badger.Txn) error {
+			value, err := get(txn, key)
+			if err != nil {
+				return err
+			}
+			value, del, err := fn(value)
+			switch {
+			case err != nil:
+				return err
+			case del:
+				return txn.Delete(key)
+			case value == nil:
+				return tmdb.ErrValueNil
+			default:
+				return txn.Set(key, value)
+			}
+		})
+		if err != badger.ErrConflict {
+			return err
+		}
+	}
+}
+
+// CompareAndSwap implements DB, using a read-write transaction which is retried on conflicts.
+func (b *BadgerDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	if b.db.IsClosed() {
+		return false, tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return false, tmdb.ErrReadOnly
+	}
+	for {
+		swapped := false
+		err := b.db.Update(func(txn *badger.Txn) error {
+			current, err := get(txn, key)
+			if err != nil {
+				return err
+			}
+			if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+				return nil
+			}
+			swapped = true
+			if value == nil {
+				return txn.Delete(key)
+			}
+			return txn.Set(key, value)
+		})
+		if err != badger.ErrConflict {
+			return swapped && err == nil, err
+		}
+	}
+}
+
+// valueLogGCDiscardRatio is the fraction of a value log file which must be discardable for Compact
+// to rewrite it. This is Badger's recommended ratio.
+const valueLogGCDiscardRatio = 0.5
+
+// Compact implements CompactionDB. Badger compacts its LSM tree in the background, so this runs
+// value log garbage collection instead, rewriting value log files until none have enough stale
+// values to be worth rewriting. The key range is ignored, since the value log is not ordered.
+func (b *BadgerDB) Compact(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return tmdb.ErrReadOnly
+	}
+	for {
+		err := b.db.RunValueLogGC(valueLogGCDiscardRatio)
+		if err == badger.ErrNoRewrite {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// checkpointMaxPendingWrites is the maximum number of pending writes while loading a checkpoint.
+const checkpointMaxPendingWrites = 256
+
+// Checkpoint implements CheckpointDB. The database is streamed with Badger's Backup into a new
+// database with the same options, which avoids writing the backup to disk first, except that it
+// is writable even if the database was opened read-only. The database directory in dir has the
+// same name as the database's directory.
+func (b *BadgerDB) Checkpoint(dir string) error {
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	path := filepath.Join(dir, filepath.Base(b.opts.Dir))
+	if tmdb.FileExists(path) {
+		return fmt.Errorf("checkpoint directory %v already exists", path)
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	checkpoint, err := badger.Open(b.opts.WithDir(path).WithValueDir(path).WithReadOnly(false))
+	if err != nil {
+		return err
+	}
+
+	r, w := io.Pipe()
+	backupErr := make(chan error, 1)
+	go func() {
+		_, err := b.db.Backup(w, 0)
+		w.CloseWithError(err)
+		backupErr <- err
+	}()
+	err = checkpoint.Load(r, checkpointMaxPendingWrites)
+	r.CloseWithError(err) // unblocks the backup if loading failed
+	if berr := <-backupErr; err == nil {
+		err = berr
+	}
+	if cerr := checkpoint.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
 func (b *BadgerDB) Close() error {
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
 	return b.db.Close()
 }
 
-func (b *BadgerDB) Print() error {
-	return nil
+// Dump implements DB.
+func (b *BadgerDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, b, opts)
 }
 
 func (b *BadgerDB) iteratorOpts(start, end []byte, opts badger.IteratorOptions) (*badgerDBIterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	if b.db.IsClosed() {
+		return nil, tmdb.ErrClosed
+	}
 	txn := b.db.NewTransaction(false)
-	iter := txn.NewIterator(opts)
-	iter.Rewind()
-	iter.Seek(start)
-	if opts.Reverse && iter.Valid() && bytes.Equal(iter.Item().Key(), start) {
-		// If we're going in reverse, our starting point was "end",
-		// which is exclusive.
-		iter.Next()
-	}
-	return &badgerDBIterator{
-		reverse: opts.Reverse,
-		start:   start,
-		end:     end,
+	iter := newBadgerDBIterator(txn, start, end, opts)
+	iter.discardTxn = true
+	return iter, nil
+}
+
+func newBadgerDBIterator(txn *badger.Txn, start, end []byte, opts badger.IteratorOptions) *badgerDBIterator {
+	iter := &badgerDBIterator{
+		reverse:  opts.Reverse,
+		keysOnly: !opts.PrefetchValues,
+		start:    start,
+		end:      end,
 
 		txn:  txn,
-		iter: iter,
-	}, nil
+		iter: txn.NewIterator(opts),
+	}
+	iter.iter.Rewind()
+	// If we're going in reverse, our starting point was "end",
+	// which is exclusive.
+	iter.seek(start, opts.Reverse)
+	return iter
 }
 
 func (b *BadgerDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
@@ -158,26 +420,278 @@ func (b *BadgerDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	return b.iteratorOpts(end, start, opts)
 }
 
-func (b *BadgerDB) Stats() map[string]string {
-	return nil
+// KeysIterator implements KeysIteratorDB, without prefetching values from the value log.
+func (b *BadgerDB) KeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	return b.iteratorOpts(start, end, opts)
+}
+
+// ReverseKeysIterator implements KeysIteratorDB, without prefetching values from the value log.
+func (b *BadgerDB) ReverseKeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Reverse = true
+	return b.iteratorOpts(end, start, opts)
+}
+
+// IteratorWithOptions implements IteratorOptionsDB. Badger does not support controlling cache
+// fills or read-ahead per iterator, so these options are ignored.
+func (b *BadgerDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	bopts := badger.DefaultIteratorOptions
+	bopts.Reverse = opts.Reverse
+	bopts.PrefetchValues = !opts.KeysOnly
+	if opts.PrefetchSize > 0 {
+		bopts.PrefetchSize = opts.PrefetchSize
+	}
+	if opts.Reverse {
+		return b.iteratorOpts(end, start, bopts)
+	}
+	return b.iteratorOpts(start, end, bopts)
+}
+
+func (b *BadgerDB) NewSnapshot() (tmdb.Snapshot, error) {
+	if b.db.IsClosed() {
+		return nil, tmdb.ErrClosed
+	}
+	return &badgerDBSnapshot{txn: b.db.NewTransaction(false)}, nil
+}
+
+// NewTransaction implements TransactionDB. It fails with ErrReadOnly for read-only databases.
+func (b *BadgerDB) NewTransaction() (tmdb.Transaction, error) {
+	if b.db.IsClosed() {
+		return nil, tmdb.ErrClosed
+	}
+	if b.opts.ReadOnly {
+		return nil, tmdb.ErrReadOnly
+	}
+	return &badgerDBTransaction{txn: b.db.NewTransaction(true)}, nil
+}
+
+// Stats implements DB. Badger only updates its on-disk sizes periodically, and does not count
+// keys without reading all tables, so KeyCount is not provided.
+func (b *BadgerDB) Stats() (*tmdb.Stats, error) {
+	if b.db.IsClosed() {
+		return nil, tmdb.ErrClosed
+	}
+	lsm, vlog := b.db.Size()
+	stats := &tmdb.Stats{
+		Backend:  "badgerdb",
+		DiskSize: lsm + vlog,
+		Properties: map[string]string{
+			"badger.lsm.size":  fmt.Sprintf("%d", lsm),
+			"badger.vlog.size": fmt.Sprintf("%d", vlog),
+			"badger.tables":    fmt.Sprintf("%d", len(b.db.Tables(false))),
+		},
+	}
+	if metrics := b.db.BlockCacheMetrics(); metrics != nil {
+		stats.CacheSize = int64(metrics.CostAdded() - metrics.CostEvicted())
+		stats.Properties["badger.blockcache.hits"] = fmt.Sprintf("%d", metrics.Hits())
+		stats.Properties["badger.blockcache.misses"] = fmt.Sprintf("%d", metrics.Misses())
+	}
+	return stats, nil
+}
+
+// ApproximateSize implements ApproximateSizeDB, using the estimated size of the LSM tree tables
+// overlapping the range. Writes which are still in memtables, and values which are stored in the
+// value log, are not included.
+func (b *BadgerDB) ApproximateSize(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, tmdb.ErrKeyEmpty
+	}
+	if b.db.IsClosed() {
+		return 0, tmdb.ErrClosed
+	}
+	var size int64
+	for _, table := range b.db.Tables(false) {
+		// Table bounds are internal keys, suffixed with versions.
+		left, right := y.ParseKey(table.Left), y.ParseKey(table.Right)
+		if (end == nil || bytes.Compare(left, end) < 0) && (start == nil || bytes.Compare(right, start) >= 0) {
+			size += int64(table.EstimatedSz)
+		}
+	}
+	return size, nil
+}
+
+// ApproximateCount implements ApproximateSizeDB, extrapolating from a sample of the keys in the
+// range and their approximate size. Sizes are measured by whole tables, so ranges within a single
+// table with more keys than sampled are underestimated.
+func (b *BadgerDB) ApproximateCount(start, end []byte) (int64, error) {
+	size, err := b.ApproximateSize(start, end)
+	if err != nil {
+		return 0, err
+	}
+	itr, err := b.KeysIterator(start, end)
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
+	return estimate.Count(itr, size, b.ApproximateSize)
 }
 
 func (b *BadgerDB) NewBatch() tmdb.Batch {
 	wb := &badgerDBBatch{
 		db:         b.db,
+		readOnly:   b.opts.ReadOnly,
 		wb:         b.db.NewWriteBatch(),
+		keys:       make(map[string]struct{}),
 		firstFlush: make(chan struct{}, 1),
 	}
 	wb.firstFlush <- struct{}{}
 	return wb
 }
 
+// badgerDBSnapshot is a read-only transaction, which sees a consistent view of the database as of
+// the time it was started.
+type badgerDBSnapshot struct {
+	txn *badger.Txn
+}
+
+var _ tmdb.Snapshot = (*badgerDBSnapshot)(nil)
+
+func (s *badgerDBSnapshot) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	return get(s.txn, key)
+}
+
+func (s *badgerDBSnapshot) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	return has(s.txn, key)
+}
+
+func (s *badgerDBSnapshot) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	return newBadgerDBIterator(s.txn, start, end, badger.DefaultIteratorOptions), nil
+}
+
+func (s *badgerDBSnapshot) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	return newBadgerDBIterator(s.txn, end, start, opts), nil
+}
+
+func (s *badgerDBSnapshot) Close() error {
+	s.txn.Discard()
+	return nil
+}
+
+// badgerDBTransaction is a native read-write Badger transaction. Badger detects conflicts for
+// keys read by Get and Has, and for keys returned by iterators, but not for keys inserted into a
+// range that was iterated over. Badger only allows one open iterator at a time in a read-write
+// transaction.
+type badgerDBTransaction struct {
+	txn    *badger.Txn
+	closed bool
+}
+
+var _ tmdb.Transaction = (*badgerDBTransaction)(nil)
+
+func (t *badgerDBTransaction) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	if t.closed {
+		return nil, tmdb.ErrTransactionClosed
+	}
+	return get(t.txn, key)
+}
+
+func (t *badgerDBTransaction) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	if t.closed {
+		return false, tmdb.ErrTransactionClosed
+	}
+	return has(t.txn, key)
+}
+
+func (t *badgerDBTransaction) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	if t.closed {
+		return nil, tmdb.ErrTransactionClosed
+	}
+	return newBadgerDBIterator(t.txn, start, end, badger.DefaultIteratorOptions), nil
+}
+
+func (t *badgerDBTransaction) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	if t.closed {
+		return nil, tmdb.ErrTransactionClosed
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = true
+	return newBadgerDBIterator(t.txn, end, start, opts), nil
+}
+
+func (t *badgerDBTransaction) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	if t.closed {
+		return tmdb.ErrTransactionClosed
+	}
+	return t.txn.Set(key, value)
+}
+
+func (t *badgerDBTransaction) Delete(key []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if t.closed {
+		return tmdb.ErrTransactionClosed
+	}
+	return t.txn.Delete(key)
+}
+
+func (t *badgerDBTransaction) Commit() error {
+	if t.closed {
+		return tmdb.ErrTransactionClosed
+	}
+	t.closed = true
+	err := t.txn.Commit()
+	if err == badger.ErrConflict {
+		return tmdb.ErrConflict
+	}
+	return err
+}
+
+func (t *badgerDBTransaction) Close() error {
+	t.closed = true
+	t.txn.Discard()
+	return nil
+}
+
 var _ tmdb.Batch = (*badgerDBBatch)(nil)
 
 type badgerDBBatch struct {
 	db *badger.DB
 	wb *badger.WriteBatch
 
+	// readOnly is true for batches of read-only databases, whose writes fail with ErrReadOnly
+	// since a badger.WriteBatch of a read-only database can not contain any writes.
+	readOnly bool
+
+	// keys contains the keys set in the batch, which are needed by DeleteRange since the contents
+	// of a badger.WriteBatch can't be inspected.
+	keys map[string]struct{}
+
 	// Calling db.Flush twice panics, so we must keep track of whether we've
 	// flushed already on our own. If Write can receive from the firstFlush
 	// channel, then it's the first and only Flush call we should do.
@@ -194,17 +708,77 @@ func (b *badgerDBBatch) Set(key, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
-	return b.wb.Set(key, value)
+	if b.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	if err := b.wb.Set(key, value); err != nil {
+		return err
+	}
+	b.keys[string(key)] = struct{}{}
+	return nil
 }
 
 func (b *badgerDBBatch) Delete(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
-	return b.wb.Delete(key)
+	if b.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	if err := b.wb.Delete(key); err != nil {
+		return err
+	}
+	delete(b.keys, string(key))
+	return nil
+}
+
+// DeleteRange deletes each key in the range individually, since Badger does not support range
+// deletes.
+func (b *badgerDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	// The batch has been written or closed if firstFlush has been drained.
+	if len(b.firstFlush) == 0 {
+		return tmdb.ErrBatchClosed
+	}
+	if b.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	// Keys set earlier in the batch are not in the database yet, so we must delete these as well.
+	for key := range b.keys {
+		if tmdb.IsKeyInDomain([]byte(key), start, end) {
+			if err := b.wb.Delete([]byte(key)); err != nil {
+				return err
+			}
+			delete(b.keys, key)
+		}
+	}
+
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	return b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		iter := newBadgerDBIterator(txn, start, end, opts)
+		defer iter.iter.Close()
+		for ; iter.Valid(); iter.Next() {
+			if err := b.wb.Delete(iter.Key()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (b *badgerDBBatch) Write() error {
+	if b.db.IsClosed() {
+		return tmdb.ErrClosed
+	}
+	if b.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	select {
 	case <-b.firstFlush:
 		return b.wb.Flush()
@@ -226,19 +800,27 @@ func (b *badgerDBBatch) Close() error {
 	return nil
 }
 
+var _ tmdb.SeekableIterator = (*badgerDBIterator)(nil)
+
 type badgerDBIterator struct {
 	reverse    bool
+	keysOnly   bool // values are not read, and Value returns nil
 	start, end []byte
 
 	txn  *badger.Txn
 	iter *badger.Iterator
 
+	// Iterators over a snapshot share its transaction, which must then be left open on Close.
+	discardTxn bool
+
 	lastErr error
 }
 
 func (i *badgerDBIterator) Close() error {
 	i.iter.Close()
-	i.txn.Discard()
+	if i.discardTxn {
+		i.txn.Discard()
+	}
 	return nil
 }
 
@@ -252,6 +834,28 @@ func (i *badgerDBIterator) Next() {
 	i.iter.Next()
 }
 
+// Seek moves the iterator to the given key, see tmdb.SeekableIterator. For reverse iterators,
+// start is the exclusive upper bound of the domain.
+func (i *badgerDBIterator) Seek(key []byte) {
+	switch {
+	case i.reverse && i.start != nil && bytes.Compare(key, i.start) >= 0:
+		i.seek(i.start, true)
+	case !i.reverse && i.start != nil && bytes.Compare(key, i.start) < 0:
+		i.seek(i.start, false)
+	default:
+		i.seek(key, false)
+	}
+}
+
+// seek moves the underlying iterator to the given key, or the next key in iteration order if it
+// does not exist, skipping the key itself if exclusive.
+func (i *badgerDBIterator) seek(key []byte, exclusive bool) {
+	i.iter.Seek(key)
+	if exclusive && i.iter.Valid() && bytes.Equal(i.iter.Item().Key(), key) {
+		i.iter.Next()
+	}
+}
+
 func (i *badgerDBIterator) Valid() bool {
 	if !i.iter.Valid() {
 		return false
@@ -279,6 +883,9 @@ func (i *badgerDBIterator) Value() []byte {
 	if !i.Valid() {
 		panic("iterator is invalid")
 	}
+	if i.keysOnly {
+		return nil
+	}
 	val, err := i.iter.Item().ValueCopy(nil)
 	if err != nil {
 		i.lastErr = err
@@ -10,8 +10,11 @@ type opType int
 const (
 	opTypeSet opType = iota + 1
 	opTypeDelete
+	opTypeDeleteRange
 )
 
+// operation is a batch operation. For opTypeDeleteRange, key and value are the start and end of
+// the range.
 type operation struct {
 	opType
 	key   []byte
@@ -60,11 +63,31 @@ func (b *boltDBBatch) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange implements Batch.
+func (b *boltDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.ops == nil {
+		return tmdb.ErrBatchClosed
+	}
+	b.ops = append(b.ops, operation{opTypeDeleteRange, start, end})
+	return nil
+}
+
 // Write implements Batch.
 func (b *boltDBBatch) Write() error {
 	if b.ops == nil {
 		return tmdb.ErrBatchClosed
 	}
+	b.db.mtx.RLock()
+	defer b.db.mtx.RUnlock()
+	if b.db.closed {
+		return tmdb.ErrClosed
+	}
+	if b.db.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := b.db.db.Batch(func(tx *bbolt.Tx) error {
 		bkt := tx.Bucket(bucket)
 		for _, op := range b.ops {
@@ -77,6 +100,10 @@ func (b *boltDBBatch) Write() error {
 				if err := bkt.Delete(op.key); err != nil {
 					return err
 				}
+			case opTypeDeleteRange:
+				if err := deleteRange(bkt, op.key, op.value); err != nil {
+					return err
+				}
 			}
 		}
 		return nil
@@ -1,10 +1,12 @@
 package boltdb
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	tmdb "github.com/tendermint/tm-db"
 	"go.etcd.io/bbolt"
@@ -19,40 +21,77 @@ var bucket = []byte("tm")
 //
 // A single bucket ([]byte("tm")) is used per a database instance. This could
 // lead to performance issues when/if there will be lots of keys.
+//
+// Keys are always ordered by their bytes, since bolt does not support custom comparers.
 type BoltDB struct {
-	db *bbolt.DB
+	// mtx is held for reading while using db, and for writing while Compact replaces it or Close
+	// closes it.
+	mtx    sync.RWMutex
+	db     *bbolt.DB
+	path   string
+	opts   *bbolt.Options
+	closed bool
+
+	// readOnly is true if the database was opened with bbolt.Options.ReadOnly, in which case all
+	// writes fail with ErrReadOnly.
+	readOnly bool
 }
 
-var _ tmdb.DB = (*BoltDB)(nil)
+var (
+	_ tmdb.CompactionDB      = (*BoltDB)(nil)
+	_ tmdb.CheckpointDB      = (*BoltDB)(nil)
+	_ tmdb.MultiGetDB        = (*BoltDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*BoltDB)(nil)
+)
 
 // NewDB returns a BoltDB with default options.
 func NewDB(name, dir string) (tmdb.DB, error) {
 	return NewDBWithOpts(name, dir, bbolt.DefaultOptions)
 }
 
-// NewDBWithOpts allows you to supply *bbolt.Options. ReadOnly: true is not
-// supported because NewDBWithOpts creates a global bucket.
+// NewReadOnlyDB opens an existing BoltDB without modifying it. All writes fail with ErrReadOnly.
+func NewReadOnlyDB(name, dir string) (tmdb.DB, error) {
+	opts := *bbolt.DefaultOptions
+	opts.ReadOnly = true
+	return NewDBWithOpts(name, dir, &opts)
+}
+
+// NewDBWithOpts allows you to supply *bbolt.Options. With ReadOnly: true, the
+// database must already exist, since the global bucket can not be created, and
+// all writes fail with ErrReadOnly.
 func NewDBWithOpts(name string, dir string, opts *bbolt.Options) (tmdb.DB, error) {
+	dbPath := filepath.Join(dir, name+".db")
 	if opts.ReadOnly {
-		return nil, errors.New("ReadOnly: true is not supported")
+		// bolt creates missing database files, even when read-only
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, err
+		}
 	}
-
-	dbPath := filepath.Join(dir, name+".db")
 	db, err := bbolt.Open(dbPath, os.ModePerm, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// create a global bucket
-	err = db.Update(func(tx *bbolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(bucket)
-		return err
-	})
+	if opts.ReadOnly {
+		err = db.View(func(tx *bbolt.Tx) error {
+			if tx.Bucket(bucket) == nil {
+				return fmt.Errorf("bucket %q not found in %v", bucket, dbPath)
+			}
+			return nil
+		})
+	} else {
+		// create a global bucket
+		err = db.Update(func(tx *bbolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			return err
+		})
+	}
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return &BoltDB{db: db}, nil
+	return &BoltDB{db: db, path: dbPath, opts: opts, readOnly: opts.ReadOnly}, nil
 }
 
 // Get implements DB.
@@ -60,6 +99,11 @@ func (bdb *BoltDB) Get(key []byte) (value []byte, err error) {
 	if len(key) == 0 {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return nil, tmdb.ErrClosed
+	}
 	err = bdb.db.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		if v := b.Get(key); v != nil {
@@ -73,6 +117,34 @@ func (bdb *BoltDB) Get(key []byte) (value []byte, err error) {
 	return
 }
 
+// GetMany implements MultiGetDB, reading the keys in a single read-only transaction.
+func (bdb *BoltDB) GetMany(keys [][]byte) ([][]byte, error) {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, tmdb.ErrKeyEmpty
+		}
+	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return nil, tmdb.ErrClosed
+	}
+	values := make([][]byte, len(keys))
+	err := bdb.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for i, key := range keys {
+			if v := b.Get(key); v != nil {
+				values[i] = append([]byte{}, v...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 // Has implements DB.
 func (bdb *BoltDB) Has(key []byte) (bool, error) {
 	bytes, err := bdb.Get(key)
@@ -90,6 +162,14 @@ func (bdb *BoltDB) Set(key, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return tmdb.ErrClosed
+	}
+	if bdb.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := bdb.db.Update(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(bucket)
 		return b.Put(key, value)
@@ -110,6 +190,14 @@ func (bdb *BoltDB) Delete(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return tmdb.ErrClosed
+	}
+	if bdb.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := bdb.db.Update(func(tx *bbolt.Tx) error {
 		return tx.Bucket(bucket).Delete(key)
 	})
@@ -124,47 +212,194 @@ func (bdb *BoltDB) DeleteSync(key []byte) error {
 	return bdb.Delete(key)
 }
 
+// DeleteRange implements DB.
+func (bdb *BoltDB) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return tmdb.ErrClosed
+	}
+	if bdb.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	return bdb.db.Update(func(tx *bbolt.Tx) error {
+		return deleteRange(tx.Bucket(bucket), start, end)
+	})
+}
+
+// deleteRange deletes a range of keys from a bucket.
+func deleteRange(bkt *bbolt.Bucket, start, end []byte) error {
+	// Deleting keys while iterating with a cursor skips keys, so we collect copies of them first.
+	var keys [][]byte
+	c := bkt.Cursor()
+	k, _ := c.First()
+	if start != nil {
+		k, _ = c.Seek(start)
+	}
+	for ; k != nil && (end == nil || bytes.Compare(k, end) < 0); k, _ = c.Next() {
+		keys = append(keys, append([]byte{}, k...))
+	}
+	for _, key := range keys {
+		if err := bkt.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update implements DB, using a write transaction.
+func (bdb *BoltDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return tmdb.ErrClosed
+	}
+	if bdb.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	return bdb.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		var value []byte
+		if v := b.Get(key); v != nil {
+			value = append([]byte{}, v...)
+		}
+		value, del, err := fn(value)
+		switch {
+		case err != nil:
+			return err
+		case del:
+			return b.Delete(key)
+		case value == nil:
+			return tmdb.ErrValueNil
+		default:
+			return b.Put(key, value)
+		}
+	})
+}
+
+// CompareAndSwap implements DB, using a write transaction.
+func (bdb *BoltDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return false, tmdb.ErrClosed
+	}
+	if bdb.readOnly {
+		return false, tmdb.ErrReadOnly
+	}
+	swapped := false
+	err := bdb.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		current := b.Get(key)
+		if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+			return nil
+		}
+		swapped = true
+		if value == nil {
+			return b.Delete(key)
+		}
+		return b.Put(key, value)
+	})
+	if err != nil {
+		return false, err
+	}
+	return swapped, nil
+}
+
 // Close implements DB.
 func (bdb *BoltDB) Close() error {
+	bdb.mtx.Lock()
+	defer bdb.mtx.Unlock()
+	if bdb.closed {
+		return tmdb.ErrClosed
+	}
+	bdb.closed = true
 	return bdb.db.Close()
 }
 
-// Print implements DB.
-func (bdb *BoltDB) Print() error {
-	stats := bdb.db.Stats()
-	fmt.Printf("%v\n", stats)
+// ApproximateSize implements ApproximateSizeDB. The size is exactly that of the keys and values
+// in the range, computed by traversing it with a cursor.
+func (bdb *BoltDB) ApproximateSize(start, end []byte) (int64, error) {
+	_, size, err := bdb.scanRange(start, end)
+	return size, err
+}
 
-	err := bdb.db.View(func(tx *bbolt.Tx) error {
-		if err := tx.Bucket(bucket).ForEach(func(k, v []byte) error {
-			fmt.Printf("[%X]:\t[%X]\n", k, v)
-			return nil
-		}); err != nil {
-			return err
+// ApproximateCount implements ApproximateSizeDB. The count is exact, computed by traversing the
+// range with a cursor.
+func (bdb *BoltDB) ApproximateCount(start, end []byte) (int64, error) {
+	count, _, err := bdb.scanRange(start, end)
+	return count, err
+}
+
+// scanRange returns the number of keys in a range, and the size of the keys and values.
+func (bdb *BoltDB) scanRange(start, end []byte) (count int64, size int64, err error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, 0, tmdb.ErrKeyEmpty
+	}
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return 0, 0, tmdb.ErrClosed
+	}
+	err = bdb.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		k, v := c.First()
+		if start != nil {
+			k, v = c.Seek(start)
+		}
+		for ; k != nil && (end == nil || bytes.Compare(k, end) < 0); k, v = c.Next() {
+			count++
+			size += int64(len(k) + len(v))
 		}
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	return nil
+	return count, size, err
 }
 
-// Stats implements DB.
-func (bdb *BoltDB) Stats() map[string]string {
-	stats := bdb.db.Stats()
-	m := make(map[string]string)
+// Dump implements DB.
+func (bdb *BoltDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, bdb, opts)
+}
 
-	// Freelist stats
-	m["FreePageN"] = fmt.Sprintf("%v", stats.FreePageN)
-	m["PendingPageN"] = fmt.Sprintf("%v", stats.PendingPageN)
-	m["FreeAlloc"] = fmt.Sprintf("%v", stats.FreeAlloc)
-	m["FreelistInuse"] = fmt.Sprintf("%v", stats.FreelistInuse)
+// Stats implements DB. The key count is computed by traversing the bucket's pages.
+func (bdb *BoltDB) Stats() (*tmdb.Stats, error) {
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return nil, tmdb.ErrClosed
+	}
+	stats := &tmdb.Stats{Backend: "boltdb"}
+	err := bdb.db.View(func(tx *bbolt.Tx) error {
+		stats.KeyCount = int64(tx.Bucket(bucket).Stats().KeyN)
+		stats.DiskSize = tx.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// Transaction stats
-	m["TxN"] = fmt.Sprintf("%v", stats.TxN)
-	m["OpenTxN"] = fmt.Sprintf("%v", stats.OpenTxN)
+	dbStats := bdb.db.Stats()
+	stats.Properties = map[string]string{
+		// Freelist stats
+		"FreePageN":     fmt.Sprintf("%v", dbStats.FreePageN),
+		"PendingPageN":  fmt.Sprintf("%v", dbStats.PendingPageN),
+		"FreeAlloc":     fmt.Sprintf("%v", dbStats.FreeAlloc),
+		"FreelistInuse": fmt.Sprintf("%v", dbStats.FreelistInuse),
 
-	return m
+		// Transaction stats
+		"TxN":     fmt.Sprintf("%v", dbStats.TxN),
+		"OpenTxN": fmt.Sprintf("%v", dbStats.OpenTxN),
+	}
+	return stats, nil
 }
 
 // NewBatch implements DB.
@@ -178,11 +413,13 @@ func (bdb *BoltDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
-	tx, err := bdb.db.Begin(false)
+	tx, err := bdb.begin()
 	if err != nil {
 		return nil, err
 	}
-	return newBoltDBIterator(tx, start, end, false), nil
+	itr := newBoltDBIterator(tx, start, end, false)
+	itr.rollbackTx = true
+	return itr, nil
 }
 
 // WARNING: Any concurrent writes or reads will block until the iterator is
@@ -191,9 +428,165 @@ func (bdb *BoltDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
-	tx, err := bdb.db.Begin(false)
+	tx, err := bdb.begin()
+	if err != nil {
+		return nil, err
+	}
+	itr := newBoltDBIterator(tx, start, end, true)
+	itr.rollbackTx = true
+	return itr, nil
+}
+
+// NewSnapshot implements DB. The snapshot holds a read-only transaction open until it is closed,
+// which prevents BoltDB from reclaiming pages freed by later writes.
+//
+// WARNING: Writes which need to grow the database file will block until all snapshots are closed,
+// so a goroutine must not write to the database while holding a snapshot.
+func (bdb *BoltDB) NewSnapshot() (tmdb.Snapshot, error) {
+	tx, err := bdb.begin()
 	if err != nil {
 		return nil, err
 	}
-	return newBoltDBIterator(tx, start, end, true), nil
+	return newBoltDBSnapshot(tx), nil
+}
+
+// begin begins a read-only transaction.
+func (bdb *BoltDB) begin() (*bbolt.Tx, error) {
+	bdb.mtx.RLock()
+	defer bdb.mtx.RUnlock()
+	if bdb.closed {
+		return nil, tmdb.ErrClosed
+	}
+	return bdb.db.Begin(false)
+}
+
+// Checkpoint implements CheckpointDB, writing the database file from a read-only transaction.
+//
+// WARNING: As with snapshots, writes which need to grow the database file will block until the
+// checkpoint has been written.
+func (bdb *BoltDB) Checkpoint(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, filepath.Base(bdb.path))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	bdb.mtx.RLock()
+	if bdb.closed {
+		err = tmdb.ErrClosed
+	} else {
+		err = bdb.db.View(func(tx *bbolt.Tx) error {
+			_, err := tx.WriteTo(f)
+			return err
+		})
+	}
+	bdb.mtx.RUnlock()
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// compactTxSize is the approximate number of bytes copied per write transaction by Compact.
+const compactTxSize = 16 << 20
+
+// Compact implements CompactionDB. BoltDB never shrinks its database file, so this copies all keys
+// to a new file, replaces the database file with it, and reopens the database. The key range is
+// ignored. If the database cannot be reopened, it is left closed, and later calls fail with
+// ErrClosed.
+//
+// WARNING: All other operations block until compaction has finished, and compaction blocks until
+// all iterators and snapshots are closed, so a goroutine must not compact the database while
+// holding one.
+func (bdb *BoltDB) Compact(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	bdb.mtx.Lock()
+	defer bdb.mtx.Unlock()
+	if bdb.closed {
+		return tmdb.ErrClosed
+	}
+	if bdb.readOnly {
+		return tmdb.ErrReadOnly
+	}
+
+	tmpPath := bdb.path + ".compact"
+	if err := compactTo(bdb.db, tmpPath, bdb.opts); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := bdb.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	renameErr := os.Rename(tmpPath, bdb.path)
+	if renameErr != nil {
+		os.Remove(tmpPath)
+	}
+	// The original database is reopened if the compacted one could not replace it.
+	db, err := bbolt.Open(bdb.path, os.ModePerm, bdb.opts)
+	if err != nil {
+		bdb.closed = true
+		return fmt.Errorf("failed to reopen database after compaction, database is closed: %w", err)
+	}
+	bdb.db = db
+	return renameErr
+}
+
+// compactTo copies the bucket of a database to a new database file at the given path.
+func compactTo(src *bbolt.DB, path string, opts *bbolt.Options) error {
+	dst, err := bbolt.Open(path, os.ModePerm, opts)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	// Individual transactions need not be synced, since the file is synced before it is used.
+	dst.NoSync = true
+
+	err = src.View(func(srcTx *bbolt.Tx) error {
+		dstTx, err := dst.Begin(true)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = dstTx.Rollback() }() // no-op after commit
+		bkt, err := dstTx.CreateBucket(bucket)
+		if err != nil {
+			return err
+		}
+		size := 0
+		err = srcTx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			if size >= compactTxSize {
+				if err := dstTx.Commit(); err != nil {
+					return err
+				}
+				if dstTx, err = dst.Begin(true); err != nil {
+					return err
+				}
+				bkt = dstTx.Bucket(bucket)
+				size = 0
+			}
+			// Keys are inserted in order, so pages can be filled completely.
+			bkt.FillPercent = 1.0
+			size += len(k) + len(v)
+			return bkt.Put(k, v)
+		})
+		if err != nil {
+			return err
+		}
+		return dstTx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+	return dst.Sync()
 }
@@ -3,6 +3,7 @@ package boltdb
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -19,6 +20,43 @@ func TestBoltDBNewBoltDB(t *testing.T) {
 	db.Close()
 }
 
+func TestBoltDBCompact(t *testing.T) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	defer dbtest.CleanupDBDir(dir, name)
+
+	db, err := NewDB(name, dir)
+	require.NoError(t, err)
+	defer db.Close()
+
+	value := make([]byte, 1024)
+	batch := db.NewBatch()
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, batch.Set([]byte(fmt.Sprintf("key%04d", i)), value))
+	}
+	require.NoError(t, batch.Write())
+	require.NoError(t, db.DeleteRange([]byte("key0001"), nil))
+
+	path := filepath.Join(dir, name+".db")
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, db.(*BoltDB).Compact(nil, nil))
+
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Less(t, after.Size(), before.Size())
+
+	// the database should remain usable
+	v, err := db.Get([]byte("key0000"))
+	require.NoError(t, err)
+	require.Equal(t, value, v)
+	require.NoError(t, db.Set([]byte("key0001"), value))
+	has, err := db.Has([]byte("key0001"))
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
 func BenchmarkBoltDBRandomReadsWrites(b *testing.B) {
 	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
 	db, err := NewDB(name, "")
@@ -11,6 +11,8 @@ import (
 // start / end keys (nil & nil will result in doing full scan).
 type boltDBIterator struct {
 	tx *bbolt.Tx
+	// Iterators over a snapshot share its transaction, which must then be left open on Close.
+	rollbackTx bool
 
 	itr   *bbolt.Cursor
 	start []byte
@@ -23,40 +25,51 @@ type boltDBIterator struct {
 	isReverse bool
 }
 
-var _ tmdb.Iterator = (*boltDBIterator)(nil)
+var _ tmdb.SeekableIterator = (*boltDBIterator)(nil)
 
 // newBoltDBIterator creates a new boltDBIterator.
 func newBoltDBIterator(tx *bbolt.Tx, start, end []byte, isReverse bool) *boltDBIterator {
-	itr := tx.Bucket(bucket).Cursor()
-
-	var ck, cv []byte
+	itr := &boltDBIterator{
+		tx:        tx,
+		itr:       tx.Bucket(bucket).Cursor(),
+		start:     start,
+		end:       end,
+		isReverse: isReverse,
+		isInvalid: false,
+	}
 	if isReverse {
-		switch {
-		case end == nil:
-			ck, cv = itr.Last()
-		default:
-			_, _ = itr.Seek(end) // after key
-			ck, cv = itr.Prev()  // return to end key
-		}
+		itr.seekReverse(end, false)
 	} else {
-		switch {
-		case start == nil:
-			ck, cv = itr.First()
-		default:
-			ck, cv = itr.Seek(start)
-		}
+		itr.seekForward(start)
+	}
+	return itr
+}
+
+// seekForward moves the cursor to the first key at or after the given key, or to the first key
+// if nil.
+func (itr *boltDBIterator) seekForward(key []byte) {
+	if key == nil {
+		itr.currentKey, itr.currentValue = itr.itr.First()
+	} else {
+		itr.currentKey, itr.currentValue = itr.itr.Seek(key)
 	}
+}
 
-	return &boltDBIterator{
-		tx:           tx,
-		itr:          itr,
-		start:        start,
-		end:          end,
-		currentKey:   ck,
-		currentValue: cv,
-		isReverse:    isReverse,
-		isInvalid:    false,
+// seekReverse moves the cursor to the last key before the given key (or at it, if inclusive), or
+// to the last key if nil.
+func (itr *boltDBIterator) seekReverse(key []byte, inclusive bool) {
+	if key == nil {
+		itr.currentKey, itr.currentValue = itr.itr.Last()
+		return
 	}
+	ck, cv := itr.itr.Seek(key) // key or after key
+	switch {
+	case ck == nil:
+		ck, cv = itr.itr.Last()
+	case !inclusive || !bytes.Equal(ck, key):
+		ck, cv = itr.itr.Prev()
+	}
+	itr.currentKey, itr.currentValue = ck, cv
 }
 
 // Domain implements Iterator.
@@ -107,6 +120,21 @@ func (itr *boltDBIterator) Next() {
 	}
 }
 
+// Seek implements SeekableIterator.
+func (itr *boltDBIterator) Seek(key []byte) {
+	itr.isInvalid = false
+	switch {
+	case itr.isReverse && itr.end != nil && bytes.Compare(key, itr.end) >= 0:
+		itr.seekReverse(itr.end, false)
+	case itr.isReverse:
+		itr.seekReverse(key, true)
+	case itr.start != nil && bytes.Compare(key, itr.start) < 0:
+		itr.seekForward(itr.start)
+	default:
+		itr.seekForward(key)
+	}
+}
+
 // Key implements Iterator.
 func (itr *boltDBIterator) Key() []byte {
 	itr.assertIsValid()
@@ -130,6 +158,9 @@ func (itr *boltDBIterator) Error() error {
 
 // Close implements Iterator.
 func (itr *boltDBIterator) Close() error {
+	if !itr.rollbackTx {
+		return nil
+	}
 	return itr.tx.Rollback()
 }
 
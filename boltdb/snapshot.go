@@ -0,0 +1,78 @@
+package boltdb
+
+import (
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+	"go.etcd.io/bbolt"
+)
+
+// boltDBSnapshot is a read-only BoltDB transaction, which sees a consistent view of the database
+// as of the time it was started. BoltDB transactions are not concurrency-safe, so access to it is
+// serialized.
+type boltDBSnapshot struct {
+	mtx sync.Mutex
+	tx  *bbolt.Tx
+}
+
+var _ tmdb.Snapshot = (*boltDBSnapshot)(nil)
+
+func newBoltDBSnapshot(tx *bbolt.Tx) *boltDBSnapshot {
+	return &boltDBSnapshot{
+		tx: tx,
+	}
+}
+
+// Get implements Snapshot.
+func (s *boltDBSnapshot) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var value []byte
+	if v := s.tx.Bucket(bucket).Get(key); v != nil {
+		value = append([]byte{}, v...)
+	}
+	return value, nil
+}
+
+// Has implements Snapshot.
+func (s *boltDBSnapshot) Has(key []byte) (bool, error) {
+	bytes, err := s.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return bytes != nil, nil
+}
+
+// Iterator implements Snapshot.
+func (s *boltDBSnapshot) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return newBoltDBIterator(s.tx, start, end, false), nil
+}
+
+// ReverseIterator implements Snapshot.
+func (s *boltDBSnapshot) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return newBoltDBIterator(s.tx, start, end, true), nil
+}
+
+// Close implements Snapshot.
+func (s *boltDBSnapshot) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.tx.Rollback()
+}
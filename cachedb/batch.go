@@ -0,0 +1,91 @@
+package cachedb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// keyRange is a range of keys deleted by a batch.
+type keyRange struct {
+	start, end []byte
+}
+
+// cacheDBBatch records the keys written to a batch, to invalidate them once it is written.
+type cacheDBBatch struct {
+	db     *CacheDB
+	source tmdb.Batch
+	keys   [][]byte
+	ranges []keyRange
+}
+
+var _ tmdb.Batch = (*cacheDBBatch)(nil)
+
+func newCacheDBBatch(db *CacheDB, source tmdb.Batch) *cacheDBBatch {
+	return &cacheDBBatch{db: db, source: source}
+}
+
+// Set implements Batch.
+func (b *cacheDBBatch) Set(key, value []byte) error {
+	if err := b.source.Set(key, value); err != nil {
+		return err
+	}
+	b.keys = append(b.keys, key)
+	return nil
+}
+
+// Delete implements Batch.
+func (b *cacheDBBatch) Delete(key []byte) error {
+	if err := b.source.Delete(key); err != nil {
+		return err
+	}
+	b.keys = append(b.keys, key)
+	return nil
+}
+
+// DeleteRange implements Batch.
+func (b *cacheDBBatch) DeleteRange(start, end []byte) error {
+	if err := b.source.DeleteRange(start, end); err != nil {
+		return err
+	}
+	b.ranges = append(b.ranges, keyRange{start: start, end: end})
+	return nil
+}
+
+// Write implements Batch.
+func (b *cacheDBBatch) Write() error {
+	return b.write(b.source.Write)
+}
+
+// WriteSync implements Batch.
+func (b *cacheDBBatch) WriteSync() error {
+	return b.write(b.source.WriteSync)
+}
+
+// Close implements Batch.
+func (b *cacheDBBatch) Close() error {
+	b.keys = nil
+	b.ranges = nil
+	return b.source.Close()
+}
+
+// write writes the batch and invalidates its keys. Keys are invalidated even if the write fails,
+// since it may have been partially applied.
+func (b *cacheDBBatch) write(apply func() error) error {
+	err := apply()
+	if len(b.keys) > 0 || len(b.ranges) > 0 {
+		cmp := tmdb.KeyComparer(b.db.source)
+		b.db.invalidate(func(c *lru) {
+			for _, key := range b.keys {
+				c.remove(key)
+			}
+			for _, r := range b.ranges {
+				c.removeRange(cmp, r.start, r.end)
+			}
+		})
+	}
+	if err != nil {
+		return err
+	}
+	b.keys = nil
+	b.ranges = nil
+	return nil
+}
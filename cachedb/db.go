@@ -0,0 +1,314 @@
+// Package cachedb provides a database wrapper which caches the results of point reads, for any
+// database backend.
+package cachedb
+
+import (
+	"io"
+	"strconv"
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// DefaultSize is the default number of keys cached.
+const DefaultSize = 10000
+
+// Options configures a CacheDB.
+type Options struct {
+	// Size is the maximum number of keys cached, where the least recently used keys are evicted
+	// first. Defaults to DefaultSize if 0 or negative.
+	Size int
+}
+
+// CacheDB wraps a database with an LRU cache of the results of Get, Has and GetMany, including
+// missing keys. Cached keys are invalidated by writes, which must all go through the CacheDB for
+// the cache to be consistent with the wrapped database. Iterators and snapshots are not cached.
+// Writes made via transactions of the wrapped database are not seen, and CacheDB does not
+// implement TransactionDB.
+//
+// Cache hits and misses are reported by Stats as the cachedb.hits and cachedb.misses properties.
+type CacheDB struct {
+	source tmdb.DB
+
+	mtx   sync.Mutex // guards the fields below
+	cache *lru
+	// version is incremented by every write, such that values read from the wrapped database
+	// concurrently with a write are not cached, since they may be stale.
+	version uint64
+	hits    uint64
+	misses  uint64
+}
+
+var (
+	_ tmdb.MultiGetDB        = (*CacheDB)(nil)
+	_ tmdb.KeysIteratorDB    = (*CacheDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*CacheDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*CacheDB)(nil)
+	_ tmdb.ComparerDB        = (*CacheDB)(nil)
+)
+
+// NewDB wraps a database with default options.
+func NewDB(db tmdb.DB) *CacheDB {
+	return NewDBWithOpts(db, Options{})
+}
+
+// NewDBWithOpts wraps a database with the given options.
+func NewDBWithOpts(db tmdb.DB, opts Options) *CacheDB {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSize
+	}
+	return &CacheDB{
+		source: db,
+		cache:  newLRU(opts.Size),
+	}
+}
+
+// Get implements DB.
+func (db *CacheDB) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	db.mtx.Lock()
+	if e := db.cache.get(key); e != nil && (!e.exists || e.value != nil) {
+		db.hits++
+		db.mtx.Unlock()
+		return e.value, nil
+	}
+	db.misses++
+	version := db.version
+	db.mtx.Unlock()
+
+	value, err := db.source.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	db.fill(version, key, value, value != nil)
+	return value, nil
+}
+
+// Has implements DB.
+func (db *CacheDB) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	db.mtx.Lock()
+	if e := db.cache.get(key); e != nil {
+		db.hits++
+		db.mtx.Unlock()
+		return e.exists, nil
+	}
+	db.misses++
+	version := db.version
+	db.mtx.Unlock()
+
+	exists, err := db.source.Has(key)
+	if err != nil {
+		return false, err
+	}
+	db.fill(version, key, nil, exists)
+	return exists, nil
+}
+
+// GetMany implements MultiGetDB, fetching the keys which are not cached with GetMany on the
+// wrapped database.
+func (db *CacheDB) GetMany(keys [][]byte) ([][]byte, error) {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, tmdb.ErrKeyEmpty
+		}
+	}
+	values := make([][]byte, len(keys))
+	missing := make([]int, 0, len(keys))
+	db.mtx.Lock()
+	for i, key := range keys {
+		if e := db.cache.get(key); e != nil && (!e.exists || e.value != nil) {
+			db.hits++
+			values[i] = e.value
+		} else {
+			db.misses++
+			missing = append(missing, i)
+		}
+	}
+	version := db.version
+	db.mtx.Unlock()
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	missingKeys := make([][]byte, len(missing))
+	for j, i := range missing {
+		missingKeys[j] = keys[i]
+	}
+	missingValues, err := tmdb.GetMany(db.source, missingKeys)
+	if err != nil {
+		return nil, err
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	for j, i := range missing {
+		values[i] = missingValues[j]
+		if db.version == version {
+			db.cache.add(keys[i], values[i], values[i] != nil)
+		}
+	}
+	return values, nil
+}
+
+// fill caches the result of a read from the wrapped database, unless the database was written
+// to since the given version.
+func (db *CacheDB) fill(version uint64, key, value []byte, exists bool) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.version == version {
+		db.cache.add(key, value, exists)
+	}
+}
+
+// invalidate updates the cache for a write, which has been applied to the wrapped database.
+func (db *CacheDB) invalidate(update func(*lru)) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	db.version++
+	update(db.cache)
+}
+
+// invalidateKey removes a written key from the cache.
+func (db *CacheDB) invalidateKey(key []byte) {
+	db.invalidate(func(c *lru) {
+		c.remove(key)
+	})
+}
+
+// Iterator implements DB.
+func (db *CacheDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	return db.source.Iterator(start, end)
+}
+
+// ReverseIterator implements DB.
+func (db *CacheDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	return db.source.ReverseIterator(start, end)
+}
+
+// KeysIterator implements KeysIteratorDB.
+func (db *CacheDB) KeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	return tmdb.KeysIterator(db.source, start, end)
+}
+
+// ReverseKeysIterator implements KeysIteratorDB.
+func (db *CacheDB) ReverseKeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	return tmdb.ReverseKeysIterator(db.source, start, end)
+}
+
+// IteratorWithOptions implements IteratorOptionsDB.
+func (db *CacheDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	return tmdb.IteratorWithOptions(db.source, start, end, opts)
+}
+
+// Comparer implements ComparerDB.
+func (db *CacheDB) Comparer() tmdb.Comparer {
+	return tmdb.KeyComparer(db.source)
+}
+
+// ApproximateSize implements ApproximateSizeDB.
+func (db *CacheDB) ApproximateSize(start, end []byte) (int64, error) {
+	return tmdb.ApproximateSize(db.source, start, end)
+}
+
+// ApproximateCount implements ApproximateSizeDB.
+func (db *CacheDB) ApproximateCount(start, end []byte) (int64, error) {
+	return tmdb.ApproximateCount(db.source, start, end)
+}
+
+// Set implements DB.
+func (db *CacheDB) Set(key, value []byte) error {
+	defer db.invalidateKey(key)
+	return db.source.Set(key, value)
+}
+
+// SetSync implements DB.
+func (db *CacheDB) SetSync(key, value []byte) error {
+	defer db.invalidateKey(key)
+	return db.source.SetSync(key, value)
+}
+
+// Delete implements DB.
+func (db *CacheDB) Delete(key []byte) error {
+	defer db.invalidateKey(key)
+	return db.source.Delete(key)
+}
+
+// DeleteSync implements DB.
+func (db *CacheDB) DeleteSync(key []byte) error {
+	defer db.invalidateKey(key)
+	return db.source.DeleteSync(key)
+}
+
+// DeleteRange implements DB.
+func (db *CacheDB) DeleteRange(start, end []byte) error {
+	defer db.invalidateRange(start, end)
+	return db.source.DeleteRange(start, end)
+}
+
+// invalidateRange removes the written keys in a range from the cache.
+func (db *CacheDB) invalidateRange(start, end []byte) {
+	cmp := tmdb.KeyComparer(db.source)
+	db.invalidate(func(c *lru) {
+		c.removeRange(cmp, start, end)
+	})
+}
+
+// Update implements DB.
+func (db *CacheDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	defer db.invalidateKey(key)
+	return db.source.Update(key, fn)
+}
+
+// CompareAndSwap implements DB.
+func (db *CacheDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	defer db.invalidateKey(key)
+	return db.source.CompareAndSwap(key, expected, value)
+}
+
+// NewSnapshot implements DB.
+func (db *CacheDB) NewSnapshot() (tmdb.Snapshot, error) {
+	return db.source.NewSnapshot()
+}
+
+// NewBatch implements DB.
+func (db *CacheDB) NewBatch() tmdb.Batch {
+	return newCacheDBBatch(db, db.source.NewBatch())
+}
+
+// Close implements DB. It clears the cache, and closes the wrapped database.
+func (db *CacheDB) Close() error {
+	db.invalidate(func(c *lru) {
+		c.clear()
+	})
+	return db.source.Close()
+}
+
+// Dump implements DB.
+func (db *CacheDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return db.source.Dump(w, opts)
+}
+
+// Stats implements DB. It adds the number of cached keys, cache hits and cache misses to the
+// properties of the wrapped database's stats.
+func (db *CacheDB) Stats() (*tmdb.Stats, error) {
+	stats, err := db.source.Stats()
+	if err != nil {
+		return nil, err
+	}
+	if stats.Properties == nil {
+		stats.Properties = make(map[string]string)
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	stats.Properties["cachedb.entries"] = strconv.Itoa(db.cache.len())
+	stats.Properties["cachedb.hits"] = strconv.FormatUint(db.hits, 10)
+	stats.Properties["cachedb.misses"] = strconv.FormatUint(db.misses, 10)
+	return stats, nil
+}
@@ -0,0 +1,164 @@
+package cachedb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+// counts returns the cache hits and misses reported by a CacheDB.
+func counts(t *testing.T, db *CacheDB) (string, string) {
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	return stats.Properties["cachedb.hits"], stats.Properties["cachedb.misses"]
+}
+
+func TestCacheDB(t *testing.T) {
+	source := memdb.NewDB()
+	db := NewDB(source)
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("empty"), []byte{}))
+
+	// repeated reads are served from the cache, including missing keys
+	for i := 0; i < 2; i++ {
+		value, err := db.Get([]byte("a"))
+		require.NoError(t, err)
+		require.Equal(t, []byte{1}, value)
+		value, err = db.Get([]byte("empty"))
+		require.NoError(t, err)
+		require.Equal(t, []byte{}, value)
+		ok, err := db.Has([]byte("missing"))
+		require.NoError(t, err)
+		require.False(t, ok)
+		values, err := db.GetMany([][]byte{[]byte("a"), []byte("b")})
+		require.NoError(t, err)
+		require.Equal(t, [][]byte{{1}, nil}, values)
+	}
+	hits, misses := counts(t, db)
+	require.Equal(t, "6", hits)
+	require.Equal(t, "4", misses)
+
+	// writes to the wrapped database bypass the cache
+	require.NoError(t, source.Set([]byte("a"), []byte{2}))
+	value, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+
+	// writes through the cache invalidate it
+	require.NoError(t, db.Set([]byte("b"), []byte{3}))
+	require.NoError(t, db.Delete([]byte("a")))
+	values, err := db.GetMany([][]byte{[]byte("a"), []byte("b")})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{nil, {3}}, values)
+
+	require.NoError(t, db.Update([]byte("b"), func(value []byte) ([]byte, bool, error) {
+		return []byte{4}, false, nil
+	}))
+	value, err = db.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{4}, value)
+
+	swapped, err := db.CompareAndSwap([]byte("b"), []byte{4}, []byte{5})
+	require.NoError(t, err)
+	require.True(t, swapped)
+	value, err = db.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{5}, value)
+
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte("a"), []byte{6}))
+	require.NoError(t, batch.DeleteRange([]byte("b"), nil))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	values, err = db.GetMany([][]byte{[]byte("a"), []byte("b"), []byte("empty")})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{6}, nil, nil}, values)
+
+	require.NoError(t, db.DeleteRange(nil, []byte("b")))
+	ok, err := db.Has([]byte("a"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = db.Get([]byte{})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	_, err = db.GetMany([][]byte{[]byte("a"), nil})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	// the cache is cleared on close
+	require.NoError(t, db.Close())
+	_, err = db.Get([]byte("a"))
+	require.Equal(t, tmdb.ErrClosed, err)
+}
+
+func TestCacheDBEviction(t *testing.T) {
+	source := memdb.NewDB()
+	db := NewDBWithOpts(source, Options{Size: 2})
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+
+	// reading c evicts b, since a was read more recently
+	for _, key := range []string{"a", "b", "a", "c"} {
+		_, err := db.Get([]byte(key))
+		require.NoError(t, err)
+	}
+	require.NoError(t, source.Delete([]byte("a")))
+	require.NoError(t, source.Delete([]byte("b")))
+	value, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), value)
+	value, err = db.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	// reading b evicted c
+	require.NoError(t, source.Delete([]byte("c")))
+	value, err = db.Get([]byte("c"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
+
+func TestCacheDBNegativeSize(t *testing.T) {
+	source := memdb.NewDB()
+	db := NewDBWithOpts(source, Options{Size: -1})
+	require.NoError(t, db.Set([]byte("a"), []byte("a")))
+
+	// the default size is used, so the value is cached
+	value, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), value)
+	require.NoError(t, source.Delete([]byte("a")))
+	value, err = db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), value)
+}
+
+func TestCacheDBPrefixDB(t *testing.T) {
+	source := memdb.NewDB()
+	a := NewDB(tmdb.NewPrefixDB(source, []byte("a/")))
+	b := NewDB(tmdb.NewPrefixDB(source, []byte("b/")))
+	require.NoError(t, a.Set([]byte("key"), []byte{1}))
+	require.NoError(t, b.Set([]byte("key"), []byte{2}))
+
+	value, err := a.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+	value, err = b.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, value)
+
+	require.NoError(t, a.DeleteRange(nil, nil))
+	value, err = a.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Nil(t, value)
+	value, err = b.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, value)
+
+	stats, err := a.Stats()
+	require.NoError(t, err)
+	require.Equal(t, "a/", stats.Properties["prefixdb.prefix.string"])
+	require.Equal(t, "1", stats.Properties["cachedb.entries"])
+}
@@ -0,0 +1,86 @@
+package cachedb
+
+import (
+	"container/list"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// entry is a cached key, with its value if known.
+type entry struct {
+	key string
+	// value is the value of the key, or nil if the key is missing or only known to exist.
+	value  []byte
+	exists bool
+}
+
+// lru is a cache of the entries most recently used, which is not safe for concurrent use.
+type lru struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // entries from most to least recently used
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// get returns the entry for a key, marking it as most recently used, or nil if not cached.
+func (c *lru) get(key []byte) *entry {
+	elem, ok := c.entries[string(key)]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*entry)
+}
+
+// add adds or replaces the entry for a key, evicting the least recently used entry if full.
+func (c *lru) add(key []byte, value []byte, exists bool) {
+	if elem, ok := c.entries[string(key)]; ok {
+		e := elem.Value.(*entry)
+		e.value, e.exists = value, exists
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+	e := &entry{key: string(key), value: value, exists: exists}
+	c.entries[e.key] = c.order.PushFront(e)
+}
+
+// remove removes the entry for a key, if any.
+func (c *lru) remove(key []byte) {
+	if elem, ok := c.entries[string(key)]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, string(key))
+	}
+}
+
+// removeRange removes the entries for keys in the range [start, end), in the given order.
+func (c *lru) removeRange(cmp tmdb.Comparer, start, end []byte) {
+	for key, elem := range c.entries {
+		if tmdb.IsKeyInDomainWithComparer(cmp, []byte(key), start, end) {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// clear removes all entries.
+func (c *lru) clear() {
+	c.entries = make(map[string]*list.Element, c.size)
+	c.order.Init()
+}
+
+// len returns the number of entries.
+func (c *lru) len() int {
+	return c.order.Len()
+}
@@ -9,12 +9,16 @@ import (
 type cLevelDBBatch struct {
 	db    *CLevelDB
 	batch *levigo.WriteBatch
+	// keys contains the keys set in the batch, which are needed by DeleteRange since the contents
+	// of a levigo.WriteBatch can't be inspected.
+	keys map[string]struct{}
 }
 
 func newCLevelDBBatch(db *CLevelDB) *cLevelDBBatch {
 	return &cLevelDBBatch{
 		db:    db,
 		batch: levigo.NewWriteBatch(),
+		keys:  make(map[string]struct{}),
 	}
 }
 
@@ -30,6 +34,7 @@ func (b *cLevelDBBatch) Set(key, value []byte) error {
 		return tmdb.ErrBatchClosed
 	}
 	b.batch.Put(key, value)
+	b.keys[string(key)] = struct{}{}
 	return nil
 }
 
@@ -42,14 +47,52 @@ func (b *cLevelDBBatch) Delete(key []byte) error {
 		return tmdb.ErrBatchClosed
 	}
 	b.batch.Delete(key)
+	delete(b.keys, string(key))
 	return nil
 }
 
+// DeleteRange implements Batch. LevelDB does not support range deletes, so this deletes each key
+// in the range individually.
+func (b *cLevelDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.batch == nil {
+		return tmdb.ErrBatchClosed
+	}
+	// Keys set earlier in the batch are not in the database yet, so we must delete these as well.
+	for key := range b.keys {
+		if tmdb.IsKeyInDomain([]byte(key), start, end) {
+			b.batch.Delete([]byte(key))
+			delete(b.keys, key)
+		}
+	}
+
+	b.db.closeMtx.RLock()
+	defer b.db.closeMtx.RUnlock()
+
+	if b.db.closed {
+		return tmdb.ErrClosed
+	}
+	itr := newCLevelDBIterator(b.db.db.NewIterator(b.db.ro), start, end, false)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		b.batch.Delete(itr.Key())
+	}
+	return itr.Error()
+}
+
 // Write implements Batch.
 func (b *cLevelDBBatch) Write() error {
 	if b.batch == nil {
 		return tmdb.ErrBatchClosed
 	}
+	b.db.closeMtx.RLock()
+	defer b.db.closeMtx.RUnlock()
+
+	if b.db.closed {
+		return tmdb.ErrClosed
+	}
 	err := b.db.db.Write(b.db.wo, b.batch)
 	if err != nil {
 		return err
@@ -63,6 +106,12 @@ func (b *cLevelDBBatch) WriteSync() error {
 	if b.batch == nil {
 		return tmdb.ErrBatchClosed
 	}
+	b.db.closeMtx.RLock()
+	defer b.db.closeMtx.RUnlock()
+
+	if b.db.closed {
+		return tmdb.ErrClosed
+	}
 	err := b.db.db.Write(b.db.woSync, b.batch)
 	if err != nil {
 		return err
@@ -77,6 +126,7 @@ func (b *cLevelDBBatch) Close() error {
 	if b.batch != nil {
 		b.batch.Close()
 		b.batch = nil
+		b.keys = nil
 	}
 	return nil
 }
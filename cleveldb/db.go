@@ -1,23 +1,44 @@
 package cleveldb
 
 import (
-	"fmt"
+	"bytes"
+	"io"
+	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/jmhodges/levigo"
 
 	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/estimate"
+	"github.com/tendermint/tm-db/internal/keylock"
 )
 
-// CLevelDB uses the C LevelDB database via a Go wrapper.
+// CLevelDB uses the C LevelDB database via a Go wrapper. Keys are always ordered by their bytes,
+// since the wrapper only supports comparators written in C.
 type CLevelDB struct {
+	// closeMtx is held for reading while the database is used, and for writing by Close, such that
+	// the database is not used after it is freed.
+	closeMtx sync.RWMutex
+	closed   bool
+
 	db     *levigo.DB
+	path   string
 	ro     *levigo.ReadOptions
 	wo     *levigo.WriteOptions
 	woSync *levigo.WriteOptions
+
+	// keyLocks serializes updates of each key.
+	keyLocks keylock.Locks
 }
 
-var _ tmdb.DB = (*CLevelDB)(nil)
+var (
+	_ tmdb.CompactionDB      = (*CLevelDB)(nil)
+	_ tmdb.CheckpointDB      = (*CLevelDB)(nil)
+	_ tmdb.MultiGetDB        = (*CLevelDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*CLevelDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*CLevelDB)(nil)
+)
 
 // New creates a new CLevelDB.
 func NewDB(name string, dir string) (*CLevelDB, error) {
@@ -30,17 +51,22 @@ func NewDB(name string, dir string) (*CLevelDB, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newCLevelDB(db, dbPath), nil
+}
+
+// newCLevelDB wraps an open levigo database.
+func newCLevelDB(db *levigo.DB, path string) *CLevelDB {
 	ro := levigo.NewReadOptions()
 	wo := levigo.NewWriteOptions()
 	woSync := levigo.NewWriteOptions()
 	woSync.SetSync(true)
-	database := &CLevelDB{
+	return &CLevelDB{
 		db:     db,
+		path:   path,
 		ro:     ro,
 		wo:     wo,
 		woSync: woSync,
 	}
-	return database, nil
 }
 
 // Get implements DB.
@@ -48,6 +74,12 @@ func (db *CLevelDB) Get(key []byte) ([]byte, error) {
 	if len(key) == 0 {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
 	res, err := db.db.Get(db.ro, key)
 	if err != nil {
 		return nil, err
@@ -55,6 +87,16 @@ func (db *CLevelDB) Get(key []byte) ([]byte, error) {
 	return res, nil
 }
 
+// GetMany implements MultiGetDB, reading the keys from a snapshot.
+func (db *CLevelDB) GetMany(keys [][]byte) ([][]byte, error) {
+	snapshot, err := db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Close()
+	return tmdb.GetMany(snapshot, keys)
+}
+
 // Has implements DB.
 func (db *CLevelDB) Has(key []byte) (bool, error) {
 	bytes, err := db.Get(key)
@@ -72,6 +114,12 @@ func (db *CLevelDB) Set(key []byte, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
 	if err := db.db.Put(db.wo, key, value); err != nil {
 		return err
 	}
@@ -86,6 +134,12 @@ func (db *CLevelDB) SetSync(key []byte, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
 	if err := db.db.Put(db.woSync, key, value); err != nil {
 		return err
 	}
@@ -97,6 +151,12 @@ func (db *CLevelDB) Delete(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
 	if err := db.db.Delete(db.wo, key); err != nil {
 		return err
 	}
@@ -108,19 +168,177 @@ func (db *CLevelDB) DeleteSync(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
 	if err := db.db.Delete(db.woSync, key); err != nil {
 		return err
 	}
 	return nil
 }
 
+// DeleteRange implements DB.
+func (db *CLevelDB) DeleteRange(start, end []byte) error {
+	batch := newCLevelDBBatch(db)
+	defer batch.Close()
+	if err := batch.DeleteRange(start, end); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Update implements DB, serializing updates of each key with key locks.
+func (db *CLevelDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+
+	value, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+	value, del, err := fn(value)
+	switch {
+	case err != nil:
+		return err
+	case del:
+		return db.Delete(key)
+	default:
+		return db.Set(key, value)
+	}
+}
+
+// CompareAndSwap implements DB, serializing it with updates of the key using key locks.
+func (db *CLevelDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+
+	current, err := db.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+		return false, nil
+	}
+	if value == nil {
+		err = db.Delete(key)
+	} else {
+		err = db.Set(key, value)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // FIXME This should not be exposed
 func (db *CLevelDB) DB() *levigo.DB {
 	return db.db
 }
 
+// Compact implements CompactionDB.
+func (db *CLevelDB) Compact(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	db.db.CompactRange(levigo.Range{Start: start, Limit: end})
+	return nil
+}
+
+// ApproximateSize implements ApproximateSizeDB, using the size of the table files overlapping the
+// range. Writes which have not been flushed to table files are not included.
+func (db *CLevelDB) ApproximateSize(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, tmdb.ErrKeyEmpty
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return 0, tmdb.ErrClosed
+	}
+	// An empty limit is the empty key rather than the end of the database.
+	if end == nil {
+		itr := db.db.NewIterator(db.ro)
+		defer itr.Close()
+		itr.SeekToLast()
+		if !itr.Valid() {
+			return 0, itr.GetError()
+		}
+		end = append(itr.Key(), 0)
+	}
+	sizes := db.db.GetApproximateSizes([]levigo.Range{{Start: start, Limit: end}})
+	return int64(sizes[0]), nil
+}
+
+// ApproximateCount implements ApproximateSizeDB, extrapolating from a sample of the keys in the
+// range and their approximate size.
+func (db *CLevelDB) ApproximateCount(start, end []byte) (int64, error) {
+	size, err := db.ApproximateSize(start, end)
+	if err != nil {
+		return 0, err
+	}
+	itr, err := db.IteratorWithOptions(start, end, tmdb.IteratorOptions{KeysOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
+	return estimate.Count(itr, size, db.ApproximateSize)
+}
+
+// Checkpoint implements CheckpointDB. LevelDB does not support checkpoints natively, so this
+// copies a snapshot of the database into a new database.
+func (db *CLevelDB) Checkpoint(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	snapshot, err := db.NewSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+
+	opts := levigo.NewOptions()
+	defer opts.Close()
+	opts.SetCreateIfMissing(true)
+	opts.SetErrorIfExists(true)
+	path := filepath.Join(dir, filepath.Base(db.path))
+	ldb, err := levigo.Open(path, opts)
+	if err != nil {
+		return err
+	}
+	checkpoint := newCLevelDB(ldb, path)
+	if err := tmdb.Copy(checkpoint, snapshot); err != nil {
+		checkpoint.Close()
+		return err
+	}
+	return checkpoint.Close()
+}
+
 // Close implements DB.
 func (db *CLevelDB) Close() error {
+	db.closeMtx.Lock()
+	defer db.closeMtx.Unlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	db.closed = true
+
 	db.db.Close()
 	db.ro.Close()
 	db.wo.Close()
@@ -128,26 +346,33 @@ func (db *CLevelDB) Close() error {
 	return nil
 }
 
-// Print implements DB.
-func (db *CLevelDB) Print() error {
-	itr, err := db.Iterator(nil, nil)
+// Dump implements DB.
+func (db *CLevelDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, db, opts)
+}
+
+// Stats implements DB. The disk size is that of the files in the database directory.
+func (db *CLevelDB) Stats() (*tmdb.Stats, error) {
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	diskSize, err := dirSize(db.path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer itr.Close()
-	for ; itr.Valid(); itr.Next() {
-		key := itr.Key()
-		value := itr.Value()
-		fmt.Printf("[%X]:\t[%X]\n", key, value)
+	stats := &tmdb.Stats{
+		Backend:    "cleveldb",
+		DiskSize:   diskSize,
+		Properties: make(map[string]string),
 	}
-	return nil
-}
 
-// Stats implements DB.
-func (db *CLevelDB) Stats() map[string]string {
 	keys := []string{
 		"leveldb.aliveiters",
 		"leveldb.alivesnaps",
+		"leveldb.approximate-memory-usage",
 		"leveldb.blockpool",
 		"leveldb.cachedblock",
 		"leveldb.num-files-at-level{n}",
@@ -155,13 +380,28 @@ func (db *CLevelDB) Stats() map[string]string {
 		"leveldb.sstables",
 		"leveldb.stats",
 	}
-
-	stats := make(map[string]string, len(keys))
 	for _, key := range keys {
-		str := db.db.PropertyValue(key)
-		stats[key] = str
+		stats.Properties[key] = db.db.PropertyValue(key)
 	}
-	return stats
+	return stats, nil
+}
+
+// dirSize returns the total size of the files in a directory. Files removed while walking the
+// directory, e.g. by compactions, are skipped.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
 }
 
 // NewBatch implements DB.
@@ -174,6 +414,12 @@ func (db *CLevelDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
 	itr := db.db.NewIterator(db.ro)
 	return newCLevelDBIterator(itr, start, end, false), nil
 }
@@ -183,6 +429,43 @@ func (db *CLevelDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
 	itr := db.db.NewIterator(db.ro)
 	return newCLevelDBIterator(itr, start, end, true), nil
 }
+
+// IteratorWithOptions implements IteratorOptionsDB. LevelDB does not support prefetching or
+// read-ahead, so these options are ignored.
+func (db *CLevelDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	ro := levigo.NewReadOptions()
+	ro.SetFillCache(opts.FillCache)
+	itr := newCLevelDBIterator(db.db.NewIterator(ro), start, end, opts.Reverse)
+	itr.keysOnly = opts.KeysOnly
+	itr.ro = ro
+	return itr, nil
+}
+
+// NewSnapshot implements DB.
+func (db *CLevelDB) NewSnapshot() (tmdb.Snapshot, error) {
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	return newCLevelDBSnapshot(db), nil
+}
@@ -13,39 +13,56 @@ type cLevelDBIterator struct {
 	start, end []byte
 	isReverse  bool
 	isInvalid  bool
+	keysOnly   bool // Value returns nil
+
+	// ro are read options owned by the iterator, if any, which are closed on Close.
+	ro *levigo.ReadOptions
 }
 
-var _ tmdb.Iterator = (*cLevelDBIterator)(nil)
+var _ tmdb.SeekableIterator = (*cLevelDBIterator)(nil)
 
 func newCLevelDBIterator(source *levigo.Iterator, start, end []byte, isReverse bool) *cLevelDBIterator {
-	if isReverse {
-		if len(end) == 0 {
-			source.SeekToLast()
-		} else {
-			source.Seek(end)
-			if source.Valid() {
-				eoakey := source.Key() // end or after key
-				if bytes.Compare(end, eoakey) <= 0 {
-					source.Prev()
-				}
-			} else {
-				source.SeekToLast()
-			}
-		}
-	} else {
-		if len(start) == 0 {
-			source.SeekToFirst()
-		} else {
-			source.Seek(start)
-		}
-	}
-	return &cLevelDBIterator{
+	itr := &cLevelDBIterator{
 		source:    source,
 		start:     start,
 		end:       end,
 		isReverse: isReverse,
 		isInvalid: false,
 	}
+	if isReverse {
+		itr.seekReverse(end, false)
+	} else {
+		itr.seekForward(start)
+	}
+	return itr
+}
+
+// seekForward moves the source to the first key at or after the given key, or to the first key
+// if empty.
+func (itr *cLevelDBIterator) seekForward(key []byte) {
+	if len(key) == 0 {
+		itr.source.SeekToFirst()
+	} else {
+		itr.source.Seek(key)
+	}
+}
+
+// seekReverse moves the source to the last key before the given key (or at it, if inclusive), or
+// to the last key if empty.
+func (itr *cLevelDBIterator) seekReverse(key []byte, inclusive bool) {
+	if len(key) == 0 {
+		itr.source.SeekToLast()
+		return
+	}
+	itr.source.Seek(key)
+	if !itr.source.Valid() {
+		itr.source.SeekToLast()
+		return
+	}
+	eoakey := itr.source.Key() // key or after key
+	if c := bytes.Compare(eoakey, key); c > 0 || (c == 0 && !inclusive) {
+		itr.source.Prev()
+	}
 }
 
 // Domain implements Iterator.
@@ -102,6 +119,9 @@ func (itr cLevelDBIterator) Key() []byte {
 // Value implements Iterator.
 func (itr cLevelDBIterator) Value() []byte {
 	itr.assertIsValid()
+	if itr.keysOnly {
+		return nil
+	}
 	return itr.source.Value()
 }
 
@@ -115,14 +135,33 @@ func (itr cLevelDBIterator) Next() {
 	}
 }
 
+// Seek implements SeekableIterator.
+func (itr *cLevelDBIterator) Seek(key []byte) {
+	itr.isInvalid = false
+	switch {
+	case itr.isReverse && len(itr.end) != 0 && bytes.Compare(key, itr.end) >= 0:
+		itr.seekReverse(itr.end, false)
+	case itr.isReverse:
+		itr.seekReverse(key, true)
+	case len(itr.start) != 0 && bytes.Compare(key, itr.start) < 0:
+		itr.seekForward(itr.start)
+	default:
+		itr.seekForward(key)
+	}
+}
+
 // Error implements Iterator.
 func (itr cLevelDBIterator) Error() error {
 	return itr.source.GetError()
 }
 
 // Close implements Iterator.
-func (itr cLevelDBIterator) Close() error {
+func (itr *cLevelDBIterator) Close() error {
 	itr.source.Close()
+	if itr.ro != nil {
+		itr.ro.Close()
+		itr.ro = nil
+	}
 	return nil
 }
 
@@ -0,0 +1,72 @@
+package cleveldb
+
+import (
+	"github.com/jmhodges/levigo"
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// cLevelDBSnapshot is a LevelDB snapshot, read through dedicated read options.
+type cLevelDBSnapshot struct {
+	db       *CLevelDB
+	snapshot *levigo.Snapshot
+	ro       *levigo.ReadOptions
+}
+
+var _ tmdb.Snapshot = (*cLevelDBSnapshot)(nil)
+
+func newCLevelDBSnapshot(db *CLevelDB) *cLevelDBSnapshot {
+	snapshot := db.db.NewSnapshot()
+	ro := levigo.NewReadOptions()
+	ro.SetSnapshot(snapshot)
+	return &cLevelDBSnapshot{
+		db:       db,
+		snapshot: snapshot,
+		ro:       ro,
+	}
+}
+
+// Get implements Snapshot.
+func (s *cLevelDBSnapshot) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	res, err := s.db.db.Get(s.ro, key)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Has implements Snapshot.
+func (s *cLevelDBSnapshot) Has(key []byte) (bool, error) {
+	bytes, err := s.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return bytes != nil, nil
+}
+
+// Iterator implements Snapshot.
+func (s *cLevelDBSnapshot) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	itr := s.db.db.NewIterator(s.ro)
+	return newCLevelDBIterator(itr, start, end, false), nil
+}
+
+// ReverseIterator implements Snapshot.
+func (s *cLevelDBSnapshot) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	itr := s.db.db.NewIterator(s.ro)
+	return newCLevelDBIterator(itr, start, end, true), nil
+}
+
+// Close implements Snapshot.
+func (s *cLevelDBSnapshot) Close() error {
+	s.ro.Close()
+	s.db.db.ReleaseSnapshot(s.snapshot)
+	return nil
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+)
+
+// errNotSwapped aborts the update made by CompareAndSwapWithUpdate.
+var errNotSwapped = errors.New("value did not match")
+
+// CompareAndSwapWithUpdate implements DB.CompareAndSwap using DB.Update, for wrappers whose values
+// must be compared after transforming them, e.g. decoding, such that the underlying database's
+// CompareAndSwap cannot be used. It has the same atomicity as the database's Update.
+func CompareAndSwapWithUpdate(db DB, key, expected, value []byte) (bool, error) {
+	err := db.Update(key, func(current []byte) ([]byte, bool, error) {
+		if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+			return nil, false, errNotSwapped
+		}
+		return value, value == nil, nil
+	})
+	if err == errNotSwapped {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,43 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/dbtest"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+func TestCompareAndSwapWithUpdate(t *testing.T) {
+	db := memdb.NewDB()
+
+	// a nil expected value requires the key not to exist
+	swapped, err := tmdb.CompareAndSwapWithUpdate(db, []byte("key"), nil, []byte("a"))
+	require.NoError(t, err)
+	require.True(t, swapped)
+	swapped, err = tmdb.CompareAndSwapWithUpdate(db, []byte("key"), nil, []byte("b"))
+	require.NoError(t, err)
+	require.False(t, swapped)
+	dbtest.Value(t, db, []byte("key"), []byte("a"))
+
+	swapped, err = tmdb.CompareAndSwapWithUpdate(db, []byte("key"), []byte("b"), []byte("c"))
+	require.NoError(t, err)
+	require.False(t, swapped)
+	swapped, err = tmdb.CompareAndSwapWithUpdate(db, []byte("key"), []byte("a"), []byte("c"))
+	require.NoError(t, err)
+	require.True(t, swapped)
+	dbtest.Value(t, db, []byte("key"), []byte("c"))
+
+	// an empty expected value only matches an empty value, and a nil value deletes the key
+	swapped, err = tmdb.CompareAndSwapWithUpdate(db, []byte("key"), []byte{}, nil)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	swapped, err = tmdb.CompareAndSwapWithUpdate(db, []byte("key"), []byte("c"), nil)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	dbtest.Value(t, db, []byte("key"), nil)
+
+	_, err = tmdb.CompareAndSwapWithUpdate(db, []byte{}, nil, []byte("a"))
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+}
@@ -0,0 +1,34 @@
+package db
+
+import "bytes"
+
+// BytesComparer orders keys lexicographically by their bytes, as bytes.Compare does. It is the
+// default order of all backends, and has the same name as the default LevelDB and RocksDB order.
+var BytesComparer Comparer = bytesComparer{}
+
+type bytesComparer struct{}
+
+// Compare implements Comparer.
+func (bytesComparer) Compare(a, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+// Name implements Comparer.
+func (bytesComparer) Name() string {
+	return "leveldb.BytewiseComparator"
+}
+
+// KeyComparer returns the comparer which orders the keys of a reader. It uses
+// ComparerDB.Comparer if the reader implements it, and otherwise returns BytesComparer.
+func KeyComparer(r Reader) Comparer {
+	if cdb, ok := r.(ComparerDB); ok {
+		return cdb.Comparer()
+	}
+	return BytesComparer
+}
+
+// IsBytesComparer returns whether a comparer is nil or orders keys by their bytes, as identified
+// by its name. Backends which only support byte order use it to reject other comparers.
+func IsBytesComparer(cmp Comparer) bool {
+	return cmp == nil || cmp.Name() == BytesComparer.Name()
+}
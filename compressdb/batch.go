@@ -0,0 +1,72 @@
+package compressdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// compressDBBatch compresses values written to a batch, and counts them once it is written.
+type compressDBBatch struct {
+	db     *CompressDB
+	source tmdb.Batch
+	sizes  sizes
+}
+
+var _ tmdb.Batch = (*compressDBBatch)(nil)
+
+func newCompressDBBatch(db *CompressDB, source tmdb.Batch) *compressDBBatch {
+	return &compressDBBatch{db: db, source: source}
+}
+
+// Set implements Batch.
+func (b *compressDBBatch) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	stored, err := b.db.encode(value)
+	if err != nil {
+		return err
+	}
+	if err := b.source.Set(key, stored); err != nil {
+		return err
+	}
+	b.sizes.add(value, stored)
+	return nil
+}
+
+// Delete implements Batch.
+func (b *compressDBBatch) Delete(key []byte) error {
+	return b.source.Delete(key)
+}
+
+// DeleteRange implements Batch.
+func (b *compressDBBatch) DeleteRange(start, end []byte) error {
+	return b.source.DeleteRange(start, end)
+}
+
+// Write implements Batch.
+func (b *compressDBBatch) Write() error {
+	return b.write(b.source.Write())
+}
+
+// WriteSync implements Batch.
+func (b *compressDBBatch) WriteSync() error {
+	return b.write(b.source.WriteSync())
+}
+
+// write counts the values of the written batch, unless the write failed.
+func (b *compressDBBatch) write(err error) error {
+	if err != nil {
+		return err
+	}
+	b.db.record(b.sizes)
+	b.sizes = sizes{}
+	return nil
+}
+
+// Close implements Batch.
+func (b *compressDBBatch) Close() error {
+	return b.source.Close()
+}
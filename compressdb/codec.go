@@ -0,0 +1,179 @@
+package compressdb
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// Tag identifies the codec of a stored value, and is stored as its first byte.
+type Tag byte
+
+const (
+	// TagNone marks values which are stored uncompressed.
+	TagNone Tag = 0
+	// TagFlate marks values compressed by FlateCodec.
+	TagFlate Tag = 1
+	// TagGzip marks values compressed by GzipCodec.
+	TagGzip Tag = 2
+)
+
+// Codec compresses values. It must be safe for concurrent use.
+type Codec interface {
+	// Tag returns the tag of values compressed by the codec, which must not be TagNone.
+	Tag() Tag
+	// Compress appends the compressed value to dst, and returns the result.
+	Compress(dst, value []byte) ([]byte, error)
+	// Decompress decompresses a value compressed by Compress.
+	Decompress(compressed []byte) ([]byte, error)
+}
+
+var (
+	codecsMtx sync.RWMutex
+	codecs    = map[Tag]Codec{}
+)
+
+func init() {
+	RegisterCodec(newFlateCodec(flate.DefaultCompression))
+	RegisterCodec(newGzipCodec(gzip.DefaultCompression))
+}
+
+// RegisterCodec registers a codec, such that values compressed by it can be read by any
+// CompressDB, regardless of the codec it compresses values with. Codecs are typically registered
+// from init functions. It panics if the codec's tag is TagNone or is already registered, e.g. by
+// the built-in FlateCodec and GzipCodec.
+func RegisterCodec(codec Codec) {
+	tag := codec.Tag()
+	if tag == TagNone {
+		panic("cannot register a codec for TagNone")
+	}
+	codecsMtx.Lock()
+	defer codecsMtx.Unlock()
+	if _, ok := codecs[tag]; ok {
+		panic(fmt.Sprintf("codec tag %v is already registered", tag))
+	}
+	codecs[tag] = codec
+}
+
+// registeredCodec returns the registered codec of a tag, if any.
+func registeredCodec(tag Tag) (Codec, bool) {
+	codecsMtx.RLock()
+	defer codecsMtx.RUnlock()
+	codec, ok := codecs[tag]
+	return codec, ok
+}
+
+// FlateCodec compresses values with DEFLATE, as specified by RFC 1951.
+type FlateCodec struct {
+	level   int
+	writers sync.Pool
+}
+
+var _ Codec = (*FlateCodec)(nil)
+
+// NewFlateCodec creates a FlateCodec with a compression level from the compress/flate package.
+func NewFlateCodec(level int) (*FlateCodec, error) {
+	if _, err := flate.NewWriter(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	return newFlateCodec(level), nil
+}
+
+func newFlateCodec(level int) *FlateCodec {
+	return &FlateCodec{level: level}
+}
+
+// Tag implements Codec.
+func (c *FlateCodec) Tag() Tag {
+	return TagFlate
+}
+
+// Compress implements Codec.
+func (c *FlateCodec) Compress(dst, value []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, ok := c.writers.Get().(*flate.Writer)
+	if ok {
+		w.Reset(buf)
+	} else {
+		var err error
+		if w, err = flate.NewWriter(buf, c.level); err != nil {
+			return nil, err
+		}
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress implements Codec.
+func (c *FlateCodec) Decompress(compressed []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// GzipCodec compresses values with gzip, as specified by RFC 1952. It adds a header and checksum
+// to DEFLATE, so FlateCodec is preferable unless the values are read by other gzip tools.
+type GzipCodec struct {
+	level   int
+	writers sync.Pool
+}
+
+var _ Codec = (*GzipCodec)(nil)
+
+// NewGzipCodec creates a GzipCodec with a compression level from the compress/gzip package.
+func NewGzipCodec(level int) (*GzipCodec, error) {
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	return newGzipCodec(level), nil
+}
+
+func newGzipCodec(level int) *GzipCodec {
+	return &GzipCodec{level: level}
+}
+
+// Tag implements Codec.
+func (c *GzipCodec) Tag() Tag {
+	return TagGzip
+}
+
+// Compress implements Codec.
+func (c *GzipCodec) Compress(dst, value []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w, ok := c.writers.Get().(*gzip.Writer)
+	if ok {
+		w.Reset(buf)
+	} else {
+		var err error
+		if w, err = gzip.NewWriterLevel(buf, c.level); err != nil {
+			return nil, err
+		}
+	}
+	defer c.writers.Put(w)
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress implements Codec.
+func (c *GzipCodec) Decompress(compressed []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,321 @@
+// Package compressdb provides a database wrapper which compresses values, for backends without
+// native compression or where it is less effective.
+package compressdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// DefaultThreshold is the default minimum size of values which are compressed.
+const DefaultThreshold = 256
+
+// Options configures a CompressDB.
+type Options struct {
+	// Codec compresses values. Defaults to a FlateCodec with the default compression level if
+	// nil. Values compressed by other registered codecs can still be read.
+	Codec Codec
+	// Threshold is the minimum size of values which are compressed. Defaults to DefaultThreshold
+	// if 0, while a negative threshold compresses all values.
+	Threshold int
+}
+
+// CompressDB wraps a database to compress values of at least a threshold size. Stored values are
+// prefixed by the tag of the codec they were compressed with, or by TagNone if they were not
+// compressed, e.g. because they were too small or did not shrink, so that databases remain
+// readable when the codec or threshold changes. The wrapped database must only be used through
+// the CompressDB, and is closed along with it.
+//
+// The number and size of the values written since the database was opened, before and after
+// compression, are reported by Stats as the compressdb.* properties.
+type CompressDB struct {
+	reader
+	source tmdb.DB
+
+	mtx    sync.Mutex // guards totals
+	totals sizes
+}
+
+var (
+	_ tmdb.MultiGetDB        = (*CompressDB)(nil)
+	_ tmdb.KeysIteratorDB    = (*CompressDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*CompressDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*CompressDB)(nil)
+	_ tmdb.ComparerDB        = (*CompressDB)(nil)
+)
+
+// NewDB wraps a database with default options.
+func NewDB(db tmdb.DB) *CompressDB {
+	return NewDBWithOpts(db, Options{})
+}
+
+// NewDBWithOpts wraps a database with the given options.
+func NewDBWithOpts(db tmdb.DB, opts Options) *CompressDB {
+	if opts.Codec == nil {
+		opts.Codec, _ = registeredCodec(TagFlate)
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	return &CompressDB{
+		reader: reader{source: db, format: format{codec: opts.Codec, threshold: opts.Threshold}},
+		source: db,
+	}
+}
+
+// format encodes values into their stored form, and decodes them.
+type format struct {
+	codec     Codec
+	threshold int
+}
+
+// encode compresses a value if it is large enough and shrinks, and prefixes it with its tag.
+func (f format) encode(value []byte) ([]byte, error) {
+	if len(value) >= f.threshold {
+		stored, err := f.codec.Compress([]byte{byte(f.codec.Tag())}, value)
+		if err != nil {
+			return nil, err
+		}
+		if len(stored) <= len(value) {
+			return stored, nil
+		}
+	}
+	stored := make([]byte, 1+len(value))
+	stored[0] = byte(TagNone)
+	copy(stored[1:], value)
+	return stored, nil
+}
+
+// decode decompresses a stored value, or returns nil if it is nil.
+func (f format) decode(stored []byte) ([]byte, error) {
+	if stored == nil {
+		return nil, nil
+	}
+	if len(stored) == 0 {
+		return nil, errors.New("invalid stored value without codec tag")
+	}
+	codec := f.codec
+	switch tag := Tag(stored[0]); tag {
+	case TagNone:
+		return stored[1:], nil
+	case codec.Tag():
+	default:
+		var ok bool
+		if codec, ok = registeredCodec(tag); !ok {
+			return nil, fmt.Errorf("unknown codec tag %v", tag)
+		}
+	}
+	value, err := codec.Decompress(stored[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress value: %w", err)
+	}
+	return value, nil
+}
+
+// sizes counts written values, and their size before and after compression.
+type sizes struct {
+	values     uint64
+	compressed uint64 // number of values which were compressed
+	written    uint64 // bytes of values before compression
+	stored     uint64 // bytes of stored values, including tags
+}
+
+// add counts a written value.
+func (s *sizes) add(value, stored []byte) {
+	s.values++
+	if Tag(stored[0]) != TagNone {
+		s.compressed++
+	}
+	s.written += uint64(len(value))
+	s.stored += uint64(len(stored))
+}
+
+// record adds the sizes of values which have been written to the wrapped database to the totals.
+func (db *CompressDB) record(s sizes) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	db.totals.values += s.values
+	db.totals.compressed += s.compressed
+	db.totals.written += s.written
+	db.totals.stored += s.stored
+}
+
+// GetMany implements MultiGetDB.
+func (db *CompressDB) GetMany(keys [][]byte) ([][]byte, error) {
+	values, err := tmdb.GetMany(db.source, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, stored := range values {
+		if values[i], err = db.decode(stored); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// KeysIterator implements KeysIteratorDB. Values are not read or decompressed.
+func (db *CompressDB) KeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	return tmdb.KeysIterator(db.source, start, end)
+}
+
+// ReverseKeysIterator implements KeysIteratorDB. Values are not read or decompressed.
+func (db *CompressDB) ReverseKeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	return tmdb.ReverseKeysIterator(db.source, start, end)
+}
+
+// IteratorWithOptions implements IteratorOptionsDB.
+func (db *CompressDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	itr, err := tmdb.IteratorWithOptions(db.source, start, end, opts)
+	if err != nil || opts.KeysOnly {
+		return itr, err
+	}
+	return newCompressDBIterator(itr, db.format), nil
+}
+
+// Comparer implements ComparerDB.
+func (db *CompressDB) Comparer() tmdb.Comparer {
+	return tmdb.KeyComparer(db.source)
+}
+
+// ApproximateSize implements ApproximateSizeDB. The size is that of the compressed values.
+func (db *CompressDB) ApproximateSize(start, end []byte) (int64, error) {
+	return tmdb.ApproximateSize(db.source, start, end)
+}
+
+// ApproximateCount implements ApproximateSizeDB.
+func (db *CompressDB) ApproximateCount(start, end []byte) (int64, error) {
+	return tmdb.ApproximateCount(db.source, start, end)
+}
+
+// set writes a value, with or without syncing.
+func (db *CompressDB) set(key, value []byte, sync bool) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	stored, err := db.encode(value)
+	if err != nil {
+		return err
+	}
+	if sync {
+		err = db.source.SetSync(key, stored)
+	} else {
+		err = db.source.Set(key, stored)
+	}
+	if err != nil {
+		return err
+	}
+	var s sizes
+	s.add(value, stored)
+	db.record(s)
+	return nil
+}
+
+// Set implements DB.
+func (db *CompressDB) Set(key, value []byte) error {
+	return db.set(key, value, false)
+}
+
+// SetSync implements DB.
+func (db *CompressDB) SetSync(key, value []byte) error {
+	return db.set(key, value, true)
+}
+
+// Delete implements DB.
+func (db *CompressDB) Delete(key []byte) error {
+	return db.source.Delete(key)
+}
+
+// DeleteSync implements DB.
+func (db *CompressDB) DeleteSync(key []byte) error {
+	return db.source.DeleteSync(key)
+}
+
+// DeleteRange implements DB.
+func (db *CompressDB) DeleteRange(start, end []byte) error {
+	return db.source.DeleteRange(start, end)
+}
+
+// Update implements DB.
+func (db *CompressDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	var s sizes
+	err := db.source.Update(key, func(stored []byte) ([]byte, bool, error) {
+		s = sizes{}
+		value, err := db.decode(stored)
+		if err != nil {
+			return nil, false, err
+		}
+		value, del, err := fn(value)
+		if err != nil || del || value == nil {
+			return nil, del, err
+		}
+		if stored, err = db.encode(value); err != nil {
+			return nil, false, err
+		}
+		s.add(value, stored)
+		return stored, false, nil
+	})
+	if err != nil {
+		return err
+	}
+	db.record(s)
+	return nil
+}
+
+// CompareAndSwap implements DB. Values are compared after decompression, using Update.
+func (db *CompressDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	return tmdb.CompareAndSwapWithUpdate(db, key, expected, value)
+}
+
+// NewSnapshot implements DB.
+func (db *CompressDB) NewSnapshot() (tmdb.Snapshot, error) {
+	snapshot, err := db.source.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return newCompressDBSnapshot(snapshot, db.format), nil
+}
+
+// NewBatch implements DB.
+func (db *CompressDB) NewBatch() tmdb.Batch {
+	return newCompressDBBatch(db, db.source.NewBatch())
+}
+
+// Close implements DB. It closes the wrapped database.
+func (db *CompressDB) Close() error {
+	return db.source.Close()
+}
+
+// Dump implements DB.
+func (db *CompressDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, db, opts)
+}
+
+// Stats implements DB. It adds the number of values written since the database was opened, how
+// many of them were compressed, and their total size before and after compression, to the
+// properties of the wrapped database's stats.
+func (db *CompressDB) Stats() (*tmdb.Stats, error) {
+	stats, err := db.source.Stats()
+	if err != nil {
+		return nil, err
+	}
+	if stats.Properties == nil {
+		stats.Properties = make(map[string]string)
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	stats.Properties["compressdb.values"] = strconv.FormatUint(db.totals.values, 10)
+	stats.Properties["compressdb.compressed_values"] = strconv.FormatUint(db.totals.compressed, 10)
+	stats.Properties["compressdb.written_bytes"] = strconv.FormatUint(db.totals.written, 10)
+	stats.Properties["compressdb.stored_bytes"] = strconv.FormatUint(db.totals.stored, 10)
+	return stats, nil
+}
@@ -0,0 +1,171 @@
+package compressdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+// jsonValue returns a compressible JSON value of at least the given size.
+func jsonValue(size int) []byte {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, `{"height":%d,"hash":"%064d","votes":[]},`, i, i)
+	}
+	b.WriteString("{}]")
+	return []byte(b.String())
+}
+
+// rleCodec is a test codec which run-length encodes values as pairs of count and byte.
+type rleCodec struct{}
+
+func (rleCodec) Tag() Tag {
+	return 100
+}
+
+func (rleCodec) Compress(dst, value []byte) ([]byte, error) {
+	for i := 0; i < len(value); {
+		n := 1
+		for i+n < len(value) && value[i+n] == value[i] && n < 255 {
+			n++
+		}
+		dst = append(dst, byte(n), value[i])
+		i += n
+	}
+	return dst, nil
+}
+
+func (rleCodec) Decompress(compressed []byte) ([]byte, error) {
+	value := []byte{}
+	for i := 0; i+1 < len(compressed); i += 2 {
+		value = append(value, bytes.Repeat(compressed[i+1:i+2], int(compressed[i]))...)
+	}
+	return value, nil
+}
+
+func TestCompressDB(t *testing.T) {
+	db := memdb.NewDB()
+	cdb := NewDB(db)
+	defer cdb.Close()
+
+	large := jsonValue(4096)
+	random := make([]byte, 4096)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+
+	require.NoError(t, cdb.Set([]byte("large"), large))
+	require.NoError(t, cdb.Set([]byte("small"), []byte("small")))
+	require.NoError(t, cdb.Set([]byte("random"), random))
+	require.NoError(t, cdb.Set([]byte("empty"), []byte{}))
+
+	// Only large, compressible values are compressed, and all values are tagged.
+	stored, err := db.Get([]byte("large"))
+	require.NoError(t, err)
+	require.EqualValues(t, TagFlate, stored[0])
+	require.Less(t, len(stored), len(large)/5)
+	for key, value := range map[string][]byte{"small": []byte("small"), "random": random, "empty": {}} {
+		stored, err := db.Get([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, append([]byte{byte(TagNone)}, value...), stored)
+	}
+
+	for key, value := range map[string][]byte{"large": large, "small": []byte("small"), "empty": {}} {
+		actual, err := cdb.Get([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, value, actual)
+	}
+	values, err := cdb.GetMany([][]byte{[]byte("large"), []byte("missing")})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{large, nil}, values)
+
+	itr, err := cdb.Iterator(nil, nil)
+	require.NoError(t, err)
+	entries := map[string]int{}
+	for ; itr.Valid(); itr.Next() {
+		entries[string(itr.Key())] = len(itr.Value())
+	}
+	require.NoError(t, itr.Error())
+	require.NoError(t, itr.Close())
+	require.Equal(t, map[string]int{"empty": 0, "large": len(large), "random": 4096, "small": 5}, entries)
+
+	swapped, err := cdb.CompareAndSwap([]byte("large"), large, []byte("swapped"))
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.NoError(t, cdb.Update([]byte("small"), func(value []byte) ([]byte, bool, error) {
+		return jsonValue(1024), false, nil
+	}))
+	stored, err = db.Get([]byte("small"))
+	require.NoError(t, err)
+	require.EqualValues(t, TagFlate, stored[0])
+
+	batch := cdb.NewBatch()
+	require.NoError(t, batch.Set([]byte("batch"), large))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+
+	stats, err := cdb.Stats()
+	require.NoError(t, err)
+	require.Equal(t, "7", stats.Properties["compressdb.values"])
+	require.Equal(t, "3", stats.Properties["compressdb.compressed_values"])
+	written := 2*len(large) + 5 + 4096 + len("swapped") + len(jsonValue(1024))
+	require.Equal(t, fmt.Sprint(written), stats.Properties["compressdb.written_bytes"])
+	require.NotEqual(t, "0", stats.Properties["compressdb.stored_bytes"])
+}
+
+func TestCompressDBCodecs(t *testing.T) {
+	db := memdb.NewDB()
+	value := jsonValue(1024)
+
+	gzipCodec, err := NewGzipCodec(gzip.BestSpeed)
+	require.NoError(t, err)
+	gdb := NewDBWithOpts(db, Options{Codec: gzipCodec})
+	require.NoError(t, gdb.Set([]byte("gzip"), value))
+
+	// Values compressed with registered codecs remain readable when the codec changes.
+	cdb := NewDBWithOpts(db, Options{Codec: rleCodec{}, Threshold: -1})
+	require.NoError(t, cdb.Set([]byte("rle"), []byte("aaaaaaaa")))
+	stored, err := db.Get([]byte("rle"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{100, 8, 'a'}, stored)
+
+	actual, err := cdb.Get([]byte("gzip"))
+	require.NoError(t, err)
+	require.Equal(t, value, actual)
+
+	fdb := NewDB(db)
+	_, err = fdb.Get([]byte("rle"))
+	require.Error(t, err)
+	RegisterCodec(rleCodec{})
+	defer func() {
+		codecsMtx.Lock()
+		delete(codecs, rleCodec{}.Tag())
+		codecsMtx.Unlock()
+	}()
+	actual, err = fdb.Get([]byte("rle"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("aaaaaaaa"), actual)
+
+	require.Panics(t, func() { RegisterCodec(gzipCodec) })
+	_, err = NewFlateCodec(100)
+	require.Error(t, err)
+
+	// Keys iterators do not decompress values.
+	itr, err := tmdb.KeysIterator(NewDB(db), nil, nil)
+	require.NoError(t, err)
+	require.True(t, itr.Valid())
+	require.Equal(t, []byte("gzip"), itr.Key())
+	require.Nil(t, itr.Value())
+	require.NoError(t, itr.Close())
+
+	require.NoError(t, db.Set([]byte("corrupt"), append([]byte{byte(TagFlate)}, bytes.Repeat([]byte{0xff}, 8)...)))
+	_, err = fdb.Get([]byte("corrupt"))
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package compressdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// compressDBIterator decompresses the values of an iterator.
+type compressDBIterator struct {
+	source tmdb.Iterator
+	format format
+	value  []byte
+	err    error
+}
+
+var _ tmdb.SeekableIterator = (*compressDBIterator)(nil)
+
+func newCompressDBIterator(source tmdb.Iterator, format format) *compressDBIterator {
+	itr := &compressDBIterator{source: source, format: format}
+	itr.decode()
+	return itr
+}
+
+// decode decompresses the value at the current position, if any. Decompression errors invalidate
+// the iterator.
+func (itr *compressDBIterator) decode() {
+	itr.value = nil
+	if itr.err == nil && itr.source.Valid() {
+		itr.value, itr.err = itr.format.decode(itr.source.Value())
+	}
+}
+
+// Domain implements Iterator.
+func (itr *compressDBIterator) Domain() ([]byte, []byte) {
+	return itr.source.Domain()
+}
+
+// Valid implements Iterator.
+func (itr *compressDBIterator) Valid() bool {
+	return itr.err == nil && itr.source.Valid()
+}
+
+// Next implements Iterator.
+func (itr *compressDBIterator) Next() {
+	itr.assertIsValid()
+	itr.source.Next()
+	itr.decode()
+}
+
+// Seek implements SeekableIterator.
+func (itr *compressDBIterator) Seek(key []byte) {
+	source, ok := itr.source.(tmdb.SeekableIterator)
+	if !ok {
+		itr.err = tmdb.ErrNotSupported
+		return
+	}
+	source.Seek(key)
+	itr.err = nil
+	itr.decode()
+}
+
+// Key implements Iterator.
+func (itr *compressDBIterator) Key() []byte {
+	itr.assertIsValid()
+	return itr.source.Key()
+}
+
+// Value implements Iterator.
+func (itr *compressDBIterator) Value() []byte {
+	itr.assertIsValid()
+	return itr.value
+}
+
+// Error implements Iterator.
+func (itr *compressDBIterator) Error() error {
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *compressDBIterator) Close() error {
+	return itr.source.Close()
+}
+
+func (itr *compressDBIterator) assertIsValid() {
+	if !itr.Valid() {
+		panic("iterator is invalid")
+	}
+}
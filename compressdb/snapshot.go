@@ -0,0 +1,65 @@
+package compressdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// reader decompresses the values of a Reader. It is shared by CompressDB and its snapshots.
+type reader struct {
+	source tmdb.Reader
+	format
+}
+
+var _ tmdb.Reader = reader{}
+
+// Get implements Reader.
+func (r reader) Get(key []byte) ([]byte, error) {
+	stored, err := r.source.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return r.decode(stored)
+}
+
+// Has implements Reader. Values are not decompressed.
+func (r reader) Has(key []byte) (bool, error) {
+	return r.source.Has(key)
+}
+
+// Iterator implements Reader.
+func (r reader) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	itr, err := r.source.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newCompressDBIterator(itr, r.format), nil
+}
+
+// ReverseIterator implements Reader.
+func (r reader) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	itr, err := r.source.ReverseIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newCompressDBIterator(itr, r.format), nil
+}
+
+// compressDBSnapshot is a snapshot of a CompressDB.
+type compressDBSnapshot struct {
+	reader
+	source tmdb.Snapshot
+}
+
+var _ tmdb.Snapshot = (*compressDBSnapshot)(nil)
+
+func newCompressDBSnapshot(source tmdb.Snapshot, format format) *compressDBSnapshot {
+	return &compressDBSnapshot{
+		reader: reader{source: source, format: format},
+		source: source,
+	}
+}
+
+// Close implements Snapshot.
+func (s *compressDBSnapshot) Close() error {
+	return s.source.Close()
+}
@@ -0,0 +1,38 @@
+package db
+
+// copyBatchSize is the approximate number of bytes of keys and values written per batch by Copy.
+const copyBatchSize = 4 << 20
+
+// Copy copies all key/value pairs from a reader into a database, writing them in batches. Keys
+// already in the destination database are overwritten, and other keys are left as-is. To copy a
+// consistent view of a live database, e.g. a MemDB, use a Snapshot of it as the reader.
+func Copy(dst DB, src Reader) error {
+	itr, err := src.Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	batch := dst.NewBatch()
+	defer func() { batch.Close() }() // closes the last batch
+	size := 0
+	for ; itr.Valid(); itr.Next() {
+		key, value := itr.Key(), itr.Value()
+		if err := batch.Set(key, value); err != nil {
+			return err
+		}
+		size += len(key) + len(value)
+		if size >= copyBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Close()
+			batch = dst.NewBatch()
+			size = 0
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return batch.WriteSync()
+}
@@ -0,0 +1,44 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/dbtest"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+func TestCopy(t *testing.T) {
+	src := mockDBWithStuff(t)
+	snapshot, err := src.NewSnapshot()
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	// writes after the snapshot should not be copied
+	require.NoError(t, src.Set([]byte("key1"), []byte("changed")))
+	require.NoError(t, src.Set([]byte("new"), []byte("new")))
+
+	dst := memdb.NewDB()
+	require.NoError(t, dst.Set([]byte("key2"), []byte("old")))
+	require.NoError(t, dst.Set([]byte("other"), []byte("other")))
+	require.NoError(t, tmdb.Copy(dst, snapshot))
+
+	dbtest.Value(t, dst, []byte("key1"), []byte("value1"))
+	dbtest.Value(t, dst, []byte("key2"), []byte("value2"))
+	dbtest.Value(t, dst, []byte("kee"), []byte("valuu"))
+	dbtest.Value(t, dst, []byte("new"), nil)
+	dbtest.Value(t, dst, []byte("other"), []byte("other"))
+
+	// copies larger than a batch should be written in several batches
+	src = memdb.NewDB()
+	value := make([]byte, 1<<20)
+	for i := byte(0); i < 10; i++ {
+		require.NoError(t, src.Set([]byte{i}, value))
+	}
+	dst = memdb.NewDB()
+	require.NoError(t, tmdb.Copy(dst, src))
+	for i := byte(0); i < 10; i++ {
+		dbtest.Value(t, dst, []byte{i}, value)
+	}
+}
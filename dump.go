@@ -0,0 +1,102 @@
+package db
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DumpFormat is an output format for Dump, which writes one line per key/value pair.
+type DumpFormat int32
+
+const (
+	// DumpHex writes pairs as "[KEY]:\t[VALUE]" in uppercase hex.
+	DumpHex DumpFormat = iota
+	// DumpBase64 writes pairs as "key\tvalue" in standard base64.
+	DumpBase64
+	// DumpJSON writes pairs as JSON objects with base64 "key" and "value" fields, and a
+	// "truncated" field set for truncated values.
+	DumpJSON
+	// DumpRaw writes pairs as "key"\t"value" Go string literals, escaping non-printable bytes.
+	DumpRaw
+)
+
+// DumpOptions configures Dump.
+type DumpOptions struct {
+	// Start and End bound the dumped keys like an iterator domain, i.e. [Start, End).
+	Start, End []byte
+	// Format is the output format. Defaults to DumpHex.
+	Format DumpFormat
+	// MaxValueSize truncates values to the given number of bytes, if greater than 0. Truncated
+	// values are followed by "..." in text formats.
+	MaxValueSize int
+}
+
+// dumpLine is a key/value pair in the DumpJSON format.
+type dumpLine struct {
+	Key       []byte `json:"key"`
+	Value     []byte `json:"value"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Dump writes the key/value pairs of a reader in the given range to w, for debugging.
+func Dump(w io.Writer, r Reader, opts DumpOptions) error {
+	itr, err := r.Iterator(opts.Start, opts.End)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+	return DumpIterator(w, itr, opts)
+}
+
+// DumpIterator writes the remaining key/value pairs of an iterator to w, ignoring the range given
+// in the options. It does not close the iterator.
+func DumpIterator(w io.Writer, itr Iterator, opts DumpOptions) error {
+	if opts.Format < DumpHex || opts.Format > DumpRaw {
+		return fmt.Errorf("unknown dump format %v", opts.Format)
+	}
+	bw := bufio.NewWriter(w)
+	for ; itr.Valid(); itr.Next() {
+		key, value := itr.Key(), itr.Value()
+		truncated := opts.MaxValueSize > 0 && len(value) > opts.MaxValueSize
+		if truncated {
+			value = value[:opts.MaxValueSize]
+		}
+		if err := dumpPair(bw, opts.Format, key, value, truncated); err != nil {
+			return err
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// dumpPair writes a key/value pair as a line in the given format.
+func dumpPair(w io.Writer, format DumpFormat, key, value []byte, truncated bool) error {
+	ellipsis := ""
+	if truncated {
+		ellipsis = "..."
+	}
+	var err error
+	switch format {
+	case DumpHex:
+		_, err = fmt.Fprintf(w, "[%X]:\t[%X%v]\n", key, value, ellipsis)
+	case DumpBase64:
+		_, err = fmt.Fprintf(w, "%v\t%v%v\n", base64.StdEncoding.EncodeToString(key),
+			base64.StdEncoding.EncodeToString(value), ellipsis)
+	case DumpJSON:
+		var line []byte
+		line, err = json.Marshal(dumpLine{Key: key, Value: value, Truncated: truncated})
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s\n", line)
+		}
+	case DumpRaw:
+		_, err = fmt.Fprintf(w, "%v\t%v%v\n", strconv.Quote(string(key)), strconv.Quote(string(value)),
+			ellipsis)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package db_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+func TestDump(t *testing.T) {
+	db := memdb.NewDB()
+	require.NoError(t, db.Set([]byte("a"), []byte{0x00, 0xff}))
+	require.NoError(t, db.Set([]byte("b"), []byte("value\n")))
+	require.NoError(t, db.Set([]byte("c"), []byte{}))
+
+	testcases := map[string]struct {
+		opts   tmdb.DumpOptions
+		expect string
+	}{
+		"hex": {tmdb.DumpOptions{}, "[61]:\t[00FF]\n[62]:\t[76616C75650A]\n[63]:\t[]\n"},
+		"base64": {tmdb.DumpOptions{Format: tmdb.DumpBase64},
+			"YQ==\tAP8=\nYg==\tdmFsdWUK\nYw==\t\n"},
+		"json": {tmdb.DumpOptions{Format: tmdb.DumpJSON},
+			`{"key":"YQ==","value":"AP8="}` + "\n" +
+				`{"key":"Yg==","value":"dmFsdWUK"}` + "\n" +
+				`{"key":"Yw==","value":""}` + "\n"},
+		"raw": {tmdb.DumpOptions{Format: tmdb.DumpRaw},
+			`"a"` + "\t" + `"\x00\xff"` + "\n" + `"b"` + "\t" + `"value\n"` + "\n" + `"c"` + "\t" + `""` + "\n"},
+		"range": {tmdb.DumpOptions{Start: []byte("b"), End: []byte("c")}, "[62]:\t[76616C75650A]\n"},
+		"truncated hex": {tmdb.DumpOptions{Start: []byte("b"), MaxValueSize: 2},
+			"[62]:\t[7661...]\n[63]:\t[]\n"},
+		"truncated json": {tmdb.DumpOptions{End: []byte("b"), Format: tmdb.DumpJSON, MaxValueSize: 1},
+			`{"key":"YQ==","value":"AA==","truncated":true}` + "\n"},
+		"truncated raw": {tmdb.DumpOptions{End: []byte("c"), Format: tmdb.DumpRaw, MaxValueSize: 1},
+			`"a"` + "\t" + `"\x00"...` + "\n" + `"b"` + "\t" + `"v"...` + "\n"},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, db.Dump(buf, tc.opts))
+			require.Equal(t, tc.expect, buf.String())
+		})
+	}
+
+	err := db.Dump(&bytes.Buffer{}, tmdb.DumpOptions{Format: 99})
+	require.Error(t, err)
+	err = db.Dump(&bytes.Buffer{}, tmdb.DumpOptions{Start: []byte{}})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	// PrefixDB dumps keys without the prefix
+	pdb := tmdb.NewPrefixDB(db, []byte("b"))
+	require.NoError(t, pdb.Set([]byte("x"), []byte{1}))
+	buf := &bytes.Buffer{}
+	require.NoError(t, pdb.Dump(buf, tmdb.DumpOptions{}))
+	require.Equal(t, "[78]:\t[01]\n", buf.String())
+}
@@ -0,0 +1,63 @@
+package encdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// encDBBatch encrypts the values, and keys if configured, written to a batch.
+type encDBBatch struct {
+	db     *EncryptedDB
+	source tmdb.Batch
+}
+
+var _ tmdb.Batch = (*encDBBatch)(nil)
+
+func newEncDBBatch(db *EncryptedDB, source tmdb.Batch) *encDBBatch {
+	return &encDBBatch{db: db, source: source}
+}
+
+// Set implements Batch.
+func (b *encDBBatch) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return b.db.write(b.source, key, value)
+}
+
+// Delete implements Batch.
+func (b *encDBBatch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	return b.db.write(b.source, key, nil)
+}
+
+// DeleteRange implements Batch. It fails with ErrNotSupported if keys are encrypted.
+func (b *encDBBatch) DeleteRange(start, end []byte) error {
+	if b.db.encryptKeys {
+		return tmdb.ErrNotSupported
+	}
+	return b.source.DeleteRange(start, end)
+}
+
+// Write implements Batch.
+func (b *encDBBatch) Write() error {
+	b.db.mtx.RLock()
+	defer b.db.mtx.RUnlock()
+	return b.source.Write()
+}
+
+// WriteSync implements Batch.
+func (b *encDBBatch) WriteSync() error {
+	b.db.mtx.RLock()
+	defer b.db.mtx.RUnlock()
+	return b.source.WriteSync()
+}
+
+// Close implements Batch.
+func (b *encDBBatch) Close() error {
+	return b.source.Close()
+}
@@ -0,0 +1,169 @@
+package encdb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// versionLen is the length of the key version header of encrypted keys and values, as a
+	// big-endian uint32.
+	versionLen = 4
+	// nonceLen is the length of the AES-GCM nonce following the key version header.
+	nonceLen = 12
+	// headerLen is the length of the header of encrypted keys and values.
+	headerLen = versionLen + nonceLen
+)
+
+// keyring encrypts and decrypts keys and values with versioned keys.
+type keyring struct {
+	current  uint32
+	versions map[uint32]*versionCipher
+	// lookup contains the key versions in the order they are looked up by encrypted keys, with
+	// the current version last.
+	lookup []uint32
+}
+
+// versionCipher contains the ciphers of a key version, using subkeys derived from the key.
+type versionCipher struct {
+	values cipher.AEAD // encrypts values with random nonces
+	keys   cipher.AEAD // encrypts keys with synthetic nonces
+	nonces []byte      // HMAC key deriving the synthetic nonces of keys
+}
+
+func newKeyring(keys map[uint32][]byte, current uint32) (*keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("no key given for current version %v", current)
+	}
+	k := &keyring{
+		current:  current,
+		versions: make(map[uint32]*versionCipher, len(keys)),
+	}
+	for version, key := range keys {
+		vc, err := newVersionCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %v: %w", version, err)
+		}
+		k.versions[version] = vc
+		if version != current {
+			k.lookup = append(k.lookup, version)
+		}
+	}
+	sort.Slice(k.lookup, func(i, j int) bool { return k.lookup[i] < k.lookup[j] })
+	k.lookup = append(k.lookup, current)
+	return k, nil
+}
+
+func newVersionCipher(key []byte) (*versionCipher, error) {
+	// Subkeys are truncated to the length of the key, which must be checked first.
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid key length %v, must be 16, 24 or 32 bytes", len(key))
+	}
+	values, err := newAEAD(deriveKey(key, "value")[:len(key)])
+	if err != nil {
+		return nil, err
+	}
+	keys, err := newAEAD(deriveKey(key, "key")[:len(key)])
+	if err != nil {
+		return nil, err
+	}
+	return &versionCipher{
+		values: values,
+		keys:   keys,
+		nonces: deriveKey(key, "nonce"),
+	}, nil
+}
+
+// newAEAD creates an AES-GCM cipher.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey derives a subkey for the given purpose from a key.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("tm-db encdb " + purpose))
+	return mac.Sum(nil)
+}
+
+// header returns a header with the given key version, and space for a nonce.
+func header(version uint32, size int) []byte {
+	b := make([]byte, headerLen, headerLen+size)
+	binary.BigEndian.PutUint32(b, version)
+	return b
+}
+
+// version returns the key version of an encrypted key or value.
+func (k *keyring) version(encrypted []byte) (uint32, *versionCipher, error) {
+	if len(encrypted) < headerLen {
+		return 0, nil, fmt.Errorf("invalid encrypted data %X", encrypted)
+	}
+	version := binary.BigEndian.Uint32(encrypted)
+	vc, ok := k.versions[version]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown key version %v", version)
+	}
+	return version, vc, nil
+}
+
+// encryptValue encrypts the value of a key with the current key version, using the key as
+// additional data such that encrypted values can not be moved between keys.
+func (k *keyring) encryptValue(key, value []byte) ([]byte, error) {
+	vc := k.versions[k.current]
+	encrypted := header(k.current, len(value)+vc.values.Overhead())
+	if _, err := io.ReadFull(rand.Reader, encrypted[versionLen:]); err != nil {
+		return nil, err
+	}
+	return vc.values.Seal(encrypted, encrypted[versionLen:], value, key), nil
+}
+
+// decryptValue decrypts the value of a key.
+func (k *keyring) decryptValue(key, encrypted []byte) ([]byte, error) {
+	_, vc, err := k.version(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	// Empty values are decrypted into a non-nil slice, as nil means that a key does not exist.
+	value, err := vc.values.Open([]byte{}, encrypted[versionLen:headerLen], encrypted[headerLen:], key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return value, nil
+}
+
+// encryptKey deterministically encrypts a key with the given key version. The nonce is derived
+// from the key, such that the same key is always encrypted the same way.
+func (k *keyring) encryptKey(version uint32, key []byte) []byte {
+	vc := k.versions[version]
+	mac := hmac.New(sha256.New, vc.nonces)
+	mac.Write(key)
+	encrypted := header(version, len(key)+vc.keys.Overhead())
+	copy(encrypted[versionLen:], mac.Sum(nil))
+	return vc.keys.Seal(encrypted, encrypted[versionLen:], key, encrypted[:versionLen])
+}
+
+// decryptKey decrypts an encrypted key, and returns its key version.
+func (k *keyring) decryptKey(encrypted []byte) ([]byte, uint32, error) {
+	version, vc, err := k.version(encrypted)
+	if err != nil {
+		return nil, 0, err
+	}
+	key, err := vc.keys.Open(nil, encrypted[versionLen:headerLen], encrypted[headerLen:], encrypted[:versionLen])
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decrypt key: %w", err)
+	}
+	return key, version, nil
+}
@@ -0,0 +1,340 @@
+// Package encdb provides a database wrapper which encrypts data at rest with AES-GCM, using
+// versioned keys which can be rotated while the database is in use.
+package encdb
+
+import (
+	"io"
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/keylock"
+)
+
+// reencryptBatchSize is the maximum number of entries rewritten at a time by Reencrypt.
+const reencryptBatchSize = 1000
+
+// Options configures an EncryptedDB.
+type Options struct {
+	// Keys are the encryption keys by key version, which must be 16, 24 or 32 bytes long to use
+	// AES-128, AES-192 or AES-256. Keys of all versions still in use by the database are needed
+	// to read it, until Reencrypt has moved its entries to the current version.
+	Keys map[uint32][]byte
+	// Version is the current key version, which new entries are encrypted with.
+	Version uint32
+	// EncryptKeys also encrypts keys, deterministically such that they can still be looked up.
+	// Order-preserving encryption would leak the order of keys, so it is not offered: with
+	// encrypted keys, range operations (iterators, DeleteRange and Dump) fail with ErrNotSupported.
+	EncryptKeys bool
+}
+
+// EncryptedDB wraps a database to encrypt its values with AES-GCM, authenticated along with their
+// key such that values can not be moved between keys, and optionally to encrypt its keys. The
+// wrapped database must only be used through the EncryptedDB, and is closed along with it. To
+// encrypt an existing database, copy it into an EncryptedDB with Copy.
+//
+// Encrypted keys and values are prefixed by the version of the key they were encrypted with. Keys
+// are rotated by adding a new key version, making it current, and calling Reencrypt to rewrite the
+// entries of older versions, after which the older keys can be removed.
+//
+// Without encrypted keys, updates have the same atomicity as DB.Update for the wrapped database.
+// With encrypted keys, updates are serialized with key locks, so writes of the key by other
+// methods during an update may be overwritten.
+type EncryptedDB struct {
+	reader
+	source tmdb.DB
+
+	// mtx is held for reading by writes, and for writing while Reencrypt rewrites entries, so that
+	// rewritten entries can not be modified concurrently.
+	mtx      sync.RWMutex
+	keyLocks keylock.Locks
+}
+
+var _ tmdb.DB = (*EncryptedDB)(nil)
+
+// NewDB wraps a database with the given options. It errors if a key has an invalid length, or no
+// key is given for the current version.
+func NewDB(db tmdb.DB, opts Options) (*EncryptedDB, error) {
+	keyring, err := newKeyring(opts.Keys, opts.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedDB{
+		reader: reader{source: db, keyring: keyring, encryptKeys: opts.EncryptKeys},
+		source: db,
+	}, nil
+}
+
+// write adds a write of a key to a batch, deleting the key if value is nil. With encrypted keys,
+// the key's entries of other key versions are deleted too.
+func (db *EncryptedDB) write(batch tmdb.Batch, key, value []byte) error {
+	storeKey := key
+	if db.encryptKeys {
+		storeKey = db.keyring.encryptKey(db.keyring.current, key)
+		for _, version := range db.keyring.lookup {
+			if version == db.keyring.current {
+				continue
+			}
+			if err := batch.Delete(db.keyring.encryptKey(version, key)); err != nil {
+				return err
+			}
+		}
+	}
+	if value == nil {
+		return batch.Delete(storeKey)
+	}
+	encrypted, err := db.keyring.encryptValue(key, value)
+	if err != nil {
+		return err
+	}
+	return batch.Set(storeKey, encrypted)
+}
+
+// writeKey writes a key, deleting it if value is nil.
+func (db *EncryptedDB) writeKey(key, value []byte, sync bool) error {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+	if !db.encryptKeys {
+		switch {
+		case value == nil && sync:
+			return db.source.DeleteSync(key)
+		case value == nil:
+			return db.source.Delete(key)
+		}
+		encrypted, err := db.keyring.encryptValue(key, value)
+		if err != nil {
+			return err
+		}
+		if sync {
+			return db.source.SetSync(key, encrypted)
+		}
+		return db.source.Set(key, encrypted)
+	}
+
+	batch := db.source.NewBatch()
+	defer batch.Close()
+	if err := db.write(batch, key, value); err != nil {
+		return err
+	}
+	if sync {
+		return batch.WriteSync()
+	}
+	return batch.Write()
+}
+
+// Set implements DB.
+func (db *EncryptedDB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return db.writeKey(key, value, false)
+}
+
+// SetSync implements DB.
+func (db *EncryptedDB) SetSync(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return db.writeKey(key, value, true)
+}
+
+// Delete implements DB.
+func (db *EncryptedDB) Delete(key []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	return db.writeKey(key, nil, false)
+}
+
+// DeleteSync implements DB.
+func (db *EncryptedDB) DeleteSync(key []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	return db.writeKey(key, nil, true)
+}
+
+// DeleteRange implements DB. It fails with ErrNotSupported if keys are encrypted.
+func (db *EncryptedDB) DeleteRange(start, end []byte) error {
+	if db.encryptKeys {
+		return tmdb.ErrNotSupported
+	}
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+	return db.source.DeleteRange(start, end)
+}
+
+// Update implements DB.
+func (db *EncryptedDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+	if !db.encryptKeys {
+		return db.source.Update(key, func(encrypted []byte) ([]byte, bool, error) {
+			var value []byte
+			if encrypted != nil {
+				var err error
+				if value, err = db.keyring.decryptValue(key, encrypted); err != nil {
+					return nil, false, err
+				}
+			}
+			value, del, err := fn(value)
+			if err != nil || del || value == nil {
+				return nil, del, err
+			}
+			encrypted, err = db.keyring.encryptValue(key, value)
+			return encrypted, false, err
+		})
+	}
+
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+	value, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+	value, del, err := fn(value)
+	switch {
+	case err != nil:
+		return err
+	case del:
+		value = nil
+	case value == nil:
+		return tmdb.ErrValueNil
+	}
+	batch := db.source.NewBatch()
+	defer batch.Close()
+	if err := db.write(batch, key, value); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// CompareAndSwap implements DB. Values are compared after decryption, using Update.
+func (db *EncryptedDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	return tmdb.CompareAndSwapWithUpdate(db, key, expected, value)
+}
+
+// NewSnapshot implements DB.
+func (db *EncryptedDB) NewSnapshot() (tmdb.Snapshot, error) {
+	snapshot, err := db.source.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return newEncDBSnapshot(snapshot, db.keyring, db.encryptKeys), nil
+}
+
+// NewBatch implements DB.
+func (db *EncryptedDB) NewBatch() tmdb.Batch {
+	return newEncDBBatch(db, db.source.NewBatch())
+}
+
+// Close implements DB. It closes the wrapped database.
+func (db *EncryptedDB) Close() error {
+	return db.source.Close()
+}
+
+// Dump implements DB. It fails with ErrNotSupported if keys are encrypted.
+func (db *EncryptedDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, db, opts)
+}
+
+// Stats implements DB. The statistics are those of the wrapped database, so sizes include the
+// encryption overhead.
+func (db *EncryptedDB) Stats() (*tmdb.Stats, error) {
+	return db.source.Stats()
+}
+
+// Reencrypt rewrites all entries encrypted with a key version other than the current one, and
+// returns the number of rewritten entries. It can be called while the database is in use: entries
+// are rewritten in batches, and writes (but not reads) are blocked while each batch is written.
+func (db *EncryptedDB) Reencrypt() (int, error) {
+	count := 0
+	var start []byte
+	for {
+		// Keys cannot be written while iterating over them, so we collect a batch of them first.
+		itr, err := db.source.Iterator(start, nil)
+		if err != nil {
+			return count, err
+		}
+		var keys [][]byte
+		for ; itr.Valid() && len(keys) < reencryptBatchSize; itr.Next() {
+			start = append(append(start[:0:0], itr.Key()...), 0)
+			if db.stale(itr.Key(), itr.Value()) {
+				keys = append(keys, append([]byte{}, itr.Key()...))
+			}
+		}
+		done := !itr.Valid()
+		err = itr.Error()
+		itr.Close()
+		if err != nil {
+			return count, err
+		}
+
+		n, err := db.reencrypt(keys)
+		count += n
+		if err != nil || done {
+			return count, err
+		}
+	}
+}
+
+// stale returns true if an entry of the wrapped database was not encrypted with the current key
+// version.
+func (db *EncryptedDB) stale(storeKey, encrypted []byte) bool {
+	current := db.keyring.current
+	if db.encryptKeys {
+		if version, _, err := db.keyring.version(storeKey); err != nil || version != current {
+			return true
+		}
+	}
+	version, _, err := db.keyring.version(encrypted)
+	return err != nil || version != current
+}
+
+// reencrypt rewrites the given entries of the wrapped database with the current key version,
+// unless they have been rewritten or deleted since, and returns the number of rewritten entries.
+func (db *EncryptedDB) reencrypt(storeKeys [][]byte) (int, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	batch := db.source.NewBatch()
+	defer batch.Close()
+	count := 0
+	for _, storeKey := range storeKeys {
+		encrypted, err := db.source.Get(storeKey)
+		if err != nil {
+			return 0, err
+		}
+		if encrypted == nil || !db.stale(storeKey, encrypted) {
+			continue
+		}
+		key := storeKey
+		if db.encryptKeys {
+			if key, _, err = db.keyring.decryptKey(storeKey); err != nil {
+				return 0, err
+			}
+		}
+		value, err := db.keyring.decryptValue(key, encrypted)
+		if err != nil {
+			return 0, err
+		}
+		if err := db.write(batch, key, value); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,200 @@
+package encdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 32)
+	key2 = bytes.Repeat([]byte{2}, 32)
+)
+
+func newTestDB(t *testing.T, db tmdb.DB, version uint32, encryptKeys bool) *EncryptedDB {
+	edb, err := NewDB(db, Options{
+		Keys:        map[uint32][]byte{1: key1, 2: key2},
+		Version:     version,
+		EncryptKeys: encryptKeys,
+	})
+	require.NoError(t, err)
+	return edb
+}
+
+// assertEncrypted checks that no key or value of the wrapped database contains the given bytes.
+func assertEncrypted(t *testing.T, db tmdb.DB, plaintext []byte) {
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		require.NotContains(t, string(itr.Key()), string(plaintext))
+		require.NotContains(t, string(itr.Value()), string(plaintext))
+	}
+	require.NoError(t, itr.Error())
+}
+
+func TestEncryptedDB(t *testing.T) {
+	db := memdb.NewDB()
+	edb := newTestDB(t, db, 1, false)
+	defer edb.Close()
+
+	require.NoError(t, edb.Set([]byte("a"), []byte("secret a")))
+	require.NoError(t, edb.Set([]byte("b"), []byte("secret b")))
+	assertEncrypted(t, db, []byte("secret"))
+
+	value, err := edb.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret a"), value)
+
+	// Values are encrypted with random nonces.
+	encrypted, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, edb.Set([]byte("a"), []byte("secret a")))
+	reencrypted, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, reencrypted)
+
+	// Values are authenticated along with their key.
+	tampered := append([]byte{}, encrypted...)
+	tampered[len(tampered)-1] ^= 1
+	require.NoError(t, db.Set([]byte("a"), tampered))
+	_, err = edb.Get([]byte("a"))
+	require.Error(t, err)
+	require.NoError(t, db.Set([]byte("a"), encrypted))
+	require.NoError(t, db.Set([]byte("c"), encrypted))
+	_, err = edb.Get([]byte("c"))
+	require.Error(t, err)
+	require.NoError(t, db.Delete([]byte("c")))
+
+	itr, err := edb.ReverseIterator(nil, nil)
+	require.NoError(t, err)
+	entries := []string{}
+	for ; itr.Valid(); itr.Next() {
+		entries = append(entries, string(itr.Key())+"="+string(itr.Value()))
+	}
+	require.NoError(t, itr.Error())
+	require.NoError(t, itr.Close())
+	require.Equal(t, []string{"b=secret b", "a=secret a"}, entries)
+
+	require.NoError(t, edb.Update([]byte("a"), func(value []byte) ([]byte, bool, error) {
+		return append(value, '!'), false, nil
+	}))
+	swapped, err := edb.CompareAndSwap([]byte("a"), []byte("secret a!"), []byte("swapped"))
+	require.NoError(t, err)
+	require.True(t, swapped)
+	swapped, err = edb.CompareAndSwap([]byte("a"), []byte("secret a!"), []byte("swapped"))
+	require.NoError(t, err)
+	require.False(t, swapped)
+	value, err = edb.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("swapped"), value)
+	assertEncrypted(t, db, []byte("swapped"))
+}
+
+func TestEncryptedDBEncryptKeys(t *testing.T) {
+	db := memdb.NewDB()
+	edb := newTestDB(t, db, 1, true)
+	defer edb.Close()
+
+	batch := edb.NewBatch()
+	require.NoError(t, batch.Set([]byte("secret a"), []byte("secret 1")))
+	require.NoError(t, batch.Set([]byte("secret b"), []byte("secret 2")))
+	require.Equal(t, tmdb.ErrNotSupported, batch.DeleteRange(nil, nil))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	require.NoError(t, edb.Set([]byte("secret c"), []byte("secret 3")))
+	require.NoError(t, edb.Delete([]byte("secret c")))
+	assertEncrypted(t, db, []byte("secret"))
+
+	value, err := edb.Get([]byte("secret a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret 1"), value)
+	has, err := edb.Has([]byte("secret c"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	swapped, err := edb.CompareAndSwap([]byte("secret b"), []byte("secret 2"), nil)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	has, err = edb.Has([]byte("secret b"))
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Equal(t, tmdb.ErrValueNil, edb.Update([]byte("secret b"), func([]byte) ([]byte, bool, error) {
+		return nil, false, nil
+	}))
+
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, stats.KeyCount)
+
+	_, err = edb.Iterator(nil, nil)
+	require.Equal(t, tmdb.ErrNotSupported, err)
+	_, err = edb.ReverseIterator(nil, nil)
+	require.Equal(t, tmdb.ErrNotSupported, err)
+	require.Equal(t, tmdb.ErrNotSupported, edb.DeleteRange(nil, nil))
+	require.Equal(t, tmdb.ErrNotSupported, edb.Dump(&bytes.Buffer{}, tmdb.DumpOptions{}))
+	require.Equal(t, tmdb.ErrKeyEmpty, edb.Delete([]byte{}))
+}
+
+func TestEncryptedDBReencrypt(t *testing.T) {
+	for _, encryptKeys := range []bool{false, true} {
+		db := memdb.NewDB()
+		edb := newTestDB(t, db, 1, encryptKeys)
+		for _, key := range []string{"a", "b", "c"} {
+			require.NoError(t, edb.Set([]byte(key), []byte("value "+key)))
+		}
+
+		// Rotate to key version 2, and overwrite a key before re-encrypting the rest.
+		edb = newTestDB(t, db, 2, encryptKeys)
+		require.NoError(t, edb.Set([]byte("a"), []byte("new a")))
+		value, err := edb.Get([]byte("b"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("value b"), value)
+
+		count, err := edb.Reencrypt()
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+		count, err = edb.Reencrypt()
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+
+		stats, err := db.Stats()
+		require.NoError(t, err)
+		require.EqualValues(t, 3, stats.KeyCount)
+
+		// Key version 1 is no longer needed.
+		edb, err = NewDB(db, Options{
+			Keys:        map[uint32][]byte{2: key2},
+			Version:     2,
+			EncryptKeys: encryptKeys,
+		})
+		require.NoError(t, err)
+		for key, expect := range map[string]string{"a": "new a", "b": "value b", "c": "value c"} {
+			value, err := edb.Get([]byte(key))
+			require.NoError(t, err)
+			require.Equal(t, []byte(expect), value)
+		}
+		require.NoError(t, edb.Close())
+	}
+}
+
+func TestEncryptedDBOptions(t *testing.T) {
+	_, err := NewDB(memdb.NewDB(), Options{Keys: map[uint32][]byte{1: key1}, Version: 2})
+	require.Error(t, err)
+	for _, size := range []int{0, 5, 64} {
+		_, err = NewDB(memdb.NewDB(), Options{Keys: map[uint32][]byte{1: make([]byte, size)}, Version: 1})
+		require.Error(t, err, "key size %v", size)
+	}
+
+	// Entries of unknown key versions can not be read.
+	db := memdb.NewDB()
+	edb := newTestDB(t, db, 2, false)
+	require.NoError(t, edb.Set([]byte("a"), []byte("1")))
+	edb, err = NewDB(db, Options{Keys: map[uint32][]byte{1: key1}, Version: 1})
+	require.NoError(t, err)
+	_, err = edb.Get([]byte("a"))
+	require.Error(t, err)
+}
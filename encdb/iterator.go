@@ -0,0 +1,90 @@
+package encdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// encDBIterator decrypts the values of an iterator with plaintext keys.
+type encDBIterator struct {
+	source  tmdb.Iterator
+	keyring *keyring
+	value   []byte
+	err     error
+}
+
+var _ tmdb.SeekableIterator = (*encDBIterator)(nil)
+
+func newEncDBIterator(source tmdb.Iterator, keyring *keyring) *encDBIterator {
+	itr := &encDBIterator{source: source, keyring: keyring}
+	itr.decrypt()
+	return itr
+}
+
+// decrypt decrypts the value at the current position, if any. Decryption errors invalidate the
+// iterator.
+func (itr *encDBIterator) decrypt() {
+	itr.value = nil
+	if itr.err == nil && itr.source.Valid() {
+		itr.value, itr.err = itr.keyring.decryptValue(itr.source.Key(), itr.source.Value())
+	}
+}
+
+// Domain implements Iterator.
+func (itr *encDBIterator) Domain() ([]byte, []byte) {
+	return itr.source.Domain()
+}
+
+// Valid implements Iterator.
+func (itr *encDBIterator) Valid() bool {
+	return itr.err == nil && itr.source.Valid()
+}
+
+// Next implements Iterator.
+func (itr *encDBIterator) Next() {
+	itr.assertIsValid()
+	itr.source.Next()
+	itr.decrypt()
+}
+
+// Seek implements SeekableIterator.
+func (itr *encDBIterator) Seek(key []byte) {
+	source, ok := itr.source.(tmdb.SeekableIterator)
+	if !ok {
+		itr.err = tmdb.ErrNotSupported
+		return
+	}
+	source.Seek(key)
+	itr.err = nil
+	itr.decrypt()
+}
+
+// Key implements Iterator.
+func (itr *encDBIterator) Key() []byte {
+	itr.assertIsValid()
+	return itr.source.Key()
+}
+
+// Value implements Iterator.
+func (itr *encDBIterator) Value() []byte {
+	itr.assertIsValid()
+	return itr.value
+}
+
+// Error implements Iterator.
+func (itr *encDBIterator) Error() error {
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *encDBIterator) Close() error {
+	return itr.source.Close()
+}
+
+func (itr *encDBIterator) assertIsValid() {
+	if !itr.Valid() {
+		panic("iterator is invalid")
+	}
+}
@@ -0,0 +1,94 @@
+package encdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// reader decrypts the values, and encrypts the keys if configured, of a Reader. It is shared by
+// EncryptedDB and its snapshots.
+type reader struct {
+	source      tmdb.Reader
+	keyring     *keyring
+	encryptKeys bool
+}
+
+var _ tmdb.Reader = reader{}
+
+// Get implements Reader.
+func (r reader) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	if !r.encryptKeys {
+		encrypted, err := r.source.Get(key)
+		if err != nil || encrypted == nil {
+			return nil, err
+		}
+		return r.keyring.decryptValue(key, encrypted)
+	}
+	// Writes move keys to the current key version, deleting them from other versions in the
+	// same batch, so there is at most one version of a key.
+	for _, version := range r.keyring.lookup {
+		encrypted, err := r.source.Get(r.keyring.encryptKey(version, key))
+		if err != nil {
+			return nil, err
+		}
+		if encrypted != nil {
+			return r.keyring.decryptValue(key, encrypted)
+		}
+	}
+	return nil, nil
+}
+
+// Has implements Reader.
+func (r reader) Has(key []byte) (bool, error) {
+	value, err := r.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+// Iterator implements Reader. It fails with ErrNotSupported if keys are encrypted.
+func (r reader) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if r.encryptKeys {
+		return nil, tmdb.ErrNotSupported
+	}
+	itr, err := r.source.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newEncDBIterator(itr, r.keyring), nil
+}
+
+// ReverseIterator implements Reader. It fails with ErrNotSupported if keys are encrypted.
+func (r reader) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if r.encryptKeys {
+		return nil, tmdb.ErrNotSupported
+	}
+	itr, err := r.source.ReverseIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newEncDBIterator(itr, r.keyring), nil
+}
+
+// encDBSnapshot is a snapshot of an EncryptedDB.
+type encDBSnapshot struct {
+	reader
+	source tmdb.Snapshot
+}
+
+var _ tmdb.Snapshot = (*encDBSnapshot)(nil)
+
+func newEncDBSnapshot(source tmdb.Snapshot, keyring *keyring, encryptKeys bool) *encDBSnapshot {
+	return &encDBSnapshot{
+		reader: reader{source: source, keyring: keyring, encryptKeys: encryptKeys},
+		source: source,
+	}
+}
+
+// Close implements Snapshot.
+func (s *encDBSnapshot) Close() error {
+	return s.source.Close()
+}
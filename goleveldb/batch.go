@@ -3,6 +3,7 @@ package goleveldb
 import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	tmdb "github.com/tendermint/tm-db"
 )
 
@@ -47,6 +48,32 @@ func (b *goLevelDBBatch) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange implements Batch. LevelDB does not support range deletes, so this deletes each key
+// in the range individually.
+func (b *goLevelDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.batch == nil {
+		return tmdb.ErrBatchClosed
+	}
+	// Keys set earlier in the batch are not in the database yet, so we must delete these as well.
+	pending := &pendingKeys{cmp: b.db.cmp, start: start, end: end}
+	if err := b.batch.Replay(pending); err != nil {
+		return err
+	}
+	for _, key := range pending.keys {
+		b.batch.Delete(key)
+	}
+
+	itr := b.db.db.NewIterator(&util.Range{Start: start, Limit: end}, nil)
+	defer itr.Release()
+	for itr.Next() {
+		b.batch.Delete(itr.Key())
+	}
+	return convertError(itr.Error())
+}
+
 // Write implements Batch.
 func (b *goLevelDBBatch) Write() error {
 	return b.write(false)
@@ -61,9 +88,14 @@ func (b *goLevelDBBatch) write(sync bool) error {
 	if b.batch == nil {
 		return tmdb.ErrBatchClosed
 	}
+	if b.db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	b.db.commitMtx.RLock()
+	defer b.db.commitMtx.RUnlock()
 	err := b.db.db.Write(b.batch, &opt.WriteOptions{Sync: sync})
 	if err != nil {
-		return err
+		return convertError(err)
 	}
 	// Make sure batch cannot be used afterwards. Callers should still call Close(), for errors.
 	return b.Close()
@@ -77,3 +109,20 @@ func (b *goLevelDBBatch) Close() error {
 	}
 	return nil
 }
+
+// pendingKeys is a leveldb.BatchReplay which collects the keys set by a batch within a range.
+type pendingKeys struct {
+	cmp        tmdb.Comparer
+	start, end []byte
+	keys       [][]byte
+}
+
+// Put implements leveldb.BatchReplay.
+func (p *pendingKeys) Put(key, value []byte) {
+	if tmdb.IsKeyInDomainWithComparer(p.cmp, key, p.start, p.end) {
+		p.keys = append(p.keys, cp(key))
+	}
+}
+
+// Delete implements leveldb.BatchReplay.
+func (p *pendingKeys) Delete(key []byte) {}
@@ -1,21 +1,49 @@
 package goleveldb
 
 import (
-	"fmt"
+	"bytes"
+	"io"
 	"path/filepath"
+	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
 	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/estimate"
+	"github.com/tendermint/tm-db/internal/keylock"
 )
 
 type GoLevelDB struct {
-	db *leveldb.DB
+	db   *leveldb.DB
+	path string
+	opts *opt.Options
+	cmp  tmdb.Comparer
+
+	// readOnly is true if the database was opened with opt.Options.ReadOnly, in which case all
+	// writes fail with ErrReadOnly.
+	readOnly bool
+
+	// commitMtx is held for reading by writes, and for writing by transaction commits, such that
+	// commits can validate and write without interleaved writes.
+	commitMtx sync.RWMutex
+
+	// keyLocks serializes updates of each key.
+	keyLocks keylock.Locks
 }
 
-var _ tmdb.DB = (*GoLevelDB)(nil)
+var (
+	_ tmdb.TransactionDB     = (*GoLevelDB)(nil)
+	_ tmdb.CompactionDB      = (*GoLevelDB)(nil)
+	_ tmdb.CheckpointDB      = (*GoLevelDB)(nil)
+	_ tmdb.MultiGetDB        = (*GoLevelDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*GoLevelDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*GoLevelDB)(nil)
+	_ tmdb.ComparerDB        = (*GoLevelDB)(nil)
+)
 
 func NewDB(name string, dir string) (*GoLevelDB, error) {
 	return NewDBWithOpts(name, dir, nil)
@@ -28,11 +56,66 @@ func NewDBWithOpts(name string, dir string, o *opt.Options) (*GoLevelDB, error)
 		return nil, err
 	}
 	database := &GoLevelDB{
-		db: db,
+		db:       db,
+		path:     dbPath,
+		opts:     o,
+		cmp:      tmdb.BytesComparer,
+		readOnly: o.GetReadOnly(),
+	}
+	if o != nil && o.Comparer != nil {
+		database.cmp = o.Comparer
 	}
 	return database, nil
 }
 
+// NewDBWithComparer creates a GoLevelDB which orders keys with the given comparer. The database
+// must always be opened with a comparer of the same name.
+func NewDBWithComparer(name string, dir string, cmp tmdb.Comparer) (*GoLevelDB, error) {
+	if tmdb.IsBytesComparer(cmp) {
+		return NewDB(name, dir)
+	}
+	return NewDBWithOpts(name, dir, &opt.Options{Comparer: NewComparer(cmp)})
+}
+
+// NewReadOnlyDB opens an existing GoLevelDB without modifying its files, using the given comparer
+// if not nil. All writes fail with ErrReadOnly.
+func NewReadOnlyDB(name string, dir string, cmp tmdb.Comparer) (*GoLevelDB, error) {
+	o := &opt.Options{ReadOnly: true}
+	if !tmdb.IsBytesComparer(cmp) {
+		o.Comparer = NewComparer(cmp)
+	}
+	return NewDBWithOpts(name, dir, o)
+}
+
+// NewComparer adapts a Comparer for use as opt.Options.Comparer.
+func NewComparer(cmp tmdb.Comparer) comparer.Comparer {
+	if lcmp, ok := cmp.(comparer.Comparer); ok {
+		return lcmp
+	}
+	return levelDBComparer{cmp}
+}
+
+// levelDBComparer adapts a Comparer for LevelDB, which can use comparers to shorten keys in table
+// indexes. Keys are never shortened, which is always correct.
+type levelDBComparer struct {
+	tmdb.Comparer
+}
+
+// Separator implements comparer.Comparer.
+func (levelDBComparer) Separator(dst, a, b []byte) []byte {
+	return nil
+}
+
+// Successor implements comparer.Comparer.
+func (levelDBComparer) Successor(dst, b []byte) []byte {
+	return nil
+}
+
+// Comparer implements ComparerDB.
+func (db *GoLevelDB) Comparer() tmdb.Comparer {
+	return db.cmp
+}
+
 // Get implements DB.
 func (db *GoLevelDB) Get(key []byte) ([]byte, error) {
 	if len(key) == 0 {
@@ -43,11 +126,21 @@ func (db *GoLevelDB) Get(key []byte) ([]byte, error) {
 		if err == errors.ErrNotFound {
 			return nil, nil
 		}
-		return nil, err
+		return nil, convertError(err)
 	}
 	return res, nil
 }
 
+// GetMany implements MultiGetDB, reading the keys from a snapshot.
+func (db *GoLevelDB) GetMany(keys [][]byte) ([][]byte, error) {
+	snapshot, err := db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.Close()
+	return tmdb.GetMany(snapshot, keys)
+}
+
 // Has implements DB.
 func (db *GoLevelDB) Has(key []byte) (bool, error) {
 	bytes, err := db.Get(key)
@@ -65,8 +158,13 @@ func (db *GoLevelDB) Set(key []byte, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
 	if err := db.db.Put(key, value, nil); err != nil {
-		return err
+		return convertError(err)
 	}
 	return nil
 }
@@ -79,8 +177,13 @@ func (db *GoLevelDB) SetSync(key []byte, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
 	if err := db.db.Put(key, value, &opt.WriteOptions{Sync: true}); err != nil {
-		return err
+		return convertError(err)
 	}
 	return nil
 }
@@ -90,8 +193,13 @@ func (db *GoLevelDB) Delete(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
 	if err := db.db.Delete(key, nil); err != nil {
-		return err
+		return convertError(err)
 	}
 	return nil
 }
@@ -101,44 +209,204 @@ func (db *GoLevelDB) DeleteSync(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
 	err := db.db.Delete(key, &opt.WriteOptions{Sync: true})
 	if err != nil {
-		return err
+		return convertError(err)
 	}
 	return nil
 }
 
-func (db *GoLevelDB) DB() *leveldb.DB {
-	return db.db
+// DeleteRange implements DB.
+func (db *GoLevelDB) DeleteRange(start, end []byte) error {
+	batch := newGoLevelDBBatch(db)
+	defer batch.Close()
+	if err := batch.DeleteRange(start, end); err != nil {
+		return err
+	}
+	return batch.Write()
 }
 
-// Close implements DB.
-func (db *GoLevelDB) Close() error {
-	if err := db.db.Close(); err != nil {
+// Update implements DB, serializing updates of each key with key locks.
+func (db *GoLevelDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+
+	value, err := db.Get(key)
+	if err != nil {
 		return err
 	}
-	return nil
+	value, del, err := fn(value)
+	switch {
+	case err != nil:
+		return err
+	case del:
+		return db.Delete(key)
+	default:
+		return db.Set(key, value)
+	}
 }
 
-// Print implements DB.
-func (db *GoLevelDB) Print() error {
-	str, err := db.db.GetProperty("leveldb.stats")
+// CompareAndSwap implements DB, serializing it with updates of the key using key locks.
+func (db *GoLevelDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	if db.readOnly {
+		return false, tmdb.ErrReadOnly
+	}
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+
+	current, err := db.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+		return false, nil
+	}
+	if value == nil {
+		err = db.Delete(key)
+	} else {
+		err = db.Set(key, value)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Compact implements CompactionDB.
+func (db *GoLevelDB) Compact(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	return convertError(db.db.CompactRange(util.Range{Start: start, Limit: end}))
+}
+
+// ApproximateSize implements ApproximateSizeDB, using the size of the table files overlapping the
+// range. Writes which have not been flushed to table files are not included.
+func (db *GoLevelDB) ApproximateSize(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, tmdb.ErrKeyEmpty
+	}
+	// A nil limit is the empty key rather than the end of the database.
+	if end == nil {
+		last, err := db.lastKey()
+		if err != nil || last == nil {
+			return 0, err
+		}
+		end = append(last, 0)
+	}
+	sizes, err := db.db.SizeOf([]util.Range{{Start: start, Limit: end}})
+	if err != nil {
+		return 0, convertError(err)
+	}
+	return sizes.Sum(), nil
+}
+
+// ApproximateCount implements ApproximateSizeDB, extrapolating from a sample of the keys in the
+// range and their approximate size.
+func (db *GoLevelDB) ApproximateCount(start, end []byte) (int64, error) {
+	size, err := db.ApproximateSize(start, end)
+	if err != nil {
+		return 0, err
+	}
+	itr, err := db.IteratorWithOptions(start, end, tmdb.IteratorOptions{KeysOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
+	return estimate.Count(itr, size, db.ApproximateSize)
+}
+
+// lastKey returns the last key in the database, or nil if it is empty.
+func (db *GoLevelDB) lastKey() ([]byte, error) {
+	itr, err := db.newIterator(nil, nil, &opt.ReadOptions{DontFillCache: true})
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Release()
+	if !itr.Last() {
+		return nil, convertError(itr.Error())
+	}
+	return cp(itr.Key()), nil
+}
+
+// Checkpoint implements CheckpointDB. LevelDB does not support checkpoints natively, so this
+// copies a snapshot of the database into a new database with the same options.
+func (db *GoLevelDB) Checkpoint(dir string) error {
+	snapshot, err := db.NewSnapshot()
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%v\n", str)
+	defer snapshot.Close()
 
-	itr := db.db.NewIterator(nil, nil)
-	for itr.Next() {
-		key := itr.Key()
-		value := itr.Value()
-		fmt.Printf("[%X]:\t[%X]\n", key, value)
+	o := &opt.Options{}
+	if db.opts != nil {
+		*o = *db.opts
+	}
+	o.ErrorIfMissing = false
+	o.ErrorIfExist = true
+	o.ReadOnly = false
+	ldb, err := leveldb.OpenFile(filepath.Join(dir, filepath.Base(db.path)), o)
+	if err != nil {
+		return err
+	}
+	checkpoint := &GoLevelDB{db: ldb, cmp: db.cmp}
+	if err := tmdb.Copy(checkpoint, snapshot); err != nil {
+		checkpoint.Close()
+		return err
+	}
+	return checkpoint.Close()
+}
+
+func (db *GoLevelDB) DB() *leveldb.DB {
+	return db.db
+}
+
+// Close implements DB.
+func (db *GoLevelDB) Close() error {
+	if err := db.db.Close(); err != nil {
+		return convertError(err)
 	}
 	return nil
 }
 
+// Dump implements DB.
+func (db *GoLevelDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, db, opts)
+}
+
 // Stats implements DB.
-func (db *GoLevelDB) Stats() map[string]string {
+func (db *GoLevelDB) Stats() (*tmdb.Stats, error) {
+	var dbStats leveldb.DBStats
+	if err := db.db.Stats(&dbStats); err != nil {
+		return nil, convertError(err)
+	}
+	stats := &tmdb.Stats{
+		Backend:       "goleveldb",
+		DiskSize:      dbStats.LevelSizes.Sum(),
+		CacheSize:     int64(dbStats.BlockCacheSize),
+		OpenIterators: int64(dbStats.AliveIterators),
+		Compactions: int64(dbStats.MemComp) + int64(dbStats.Level0Comp) +
+			int64(dbStats.NonLevel0Comp) + int64(dbStats.SeekComp),
+		Properties: make(map[string]string),
+	}
+
 	keys := []string{
 		"leveldb.num-files-at-level{n}",
 		"leveldb.stats",
@@ -149,15 +417,13 @@ func (db *GoLevelDB) Stats() map[string]string {
 		"leveldb.alivesnaps",
 		"leveldb.aliveiters",
 	}
-
-	stats := make(map[string]string)
 	for _, key := range keys {
 		str, err := db.db.GetProperty(key)
 		if err == nil {
-			stats[key] = str
+			stats.Properties[key] = str
 		}
 	}
-	return stats
+	return stats, nil
 }
 
 // NewBatch implements DB.
@@ -170,8 +436,11 @@ func (db *GoLevelDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
-	itr := db.db.NewIterator(&util.Range{Start: start, Limit: end}, nil)
-	return newGoLevelDBIterator(itr, start, end, false), nil
+	itr, err := db.newIterator(start, end, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newGoLevelDBIterator(itr, db.cmp, start, end, false), nil
 }
 
 // ReverseIterator implements DB.
@@ -179,6 +448,85 @@ func (db *GoLevelDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
-	itr := db.db.NewIterator(&util.Range{Start: start, Limit: end}, nil)
-	return newGoLevelDBIterator(itr, start, end, true), nil
+	itr, err := db.newIterator(start, end, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newGoLevelDBIterator(itr, db.cmp, start, end, true), nil
+}
+
+// IteratorWithOptions implements IteratorOptionsDB. GoLevelDB does not support prefetching or
+// read-ahead, so these options are ignored.
+func (db *GoLevelDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	itr, err := db.newIterator(start, end, &opt.ReadOptions{DontFillCache: !opts.FillCache})
+	if err != nil {
+		return nil, err
+	}
+	iter := newGoLevelDBIterator(itr, db.cmp, start, end, opts.Reverse)
+	iter.keysOnly = opts.KeysOnly
+	return iter, nil
+}
+
+// newIterator creates an iterator over the given range, with optional read options.
+func (db *GoLevelDB) newIterator(start, end []byte, ro *opt.ReadOptions) (iterator.Iterator, error) {
+	itr := db.db.NewIterator(&util.Range{Start: start, Limit: end}, ro)
+	// Iterators of closed databases are empty, with the error set.
+	if err := itr.Error(); err != nil {
+		itr.Release()
+		return nil, convertError(err)
+	}
+	return itr, nil
+}
+
+// NewSnapshot implements DB.
+func (db *GoLevelDB) NewSnapshot() (tmdb.Snapshot, error) {
+	snapshot, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return newGoLevelDBSnapshot(snapshot, db.cmp), nil
+}
+
+// NewTransaction implements TransactionDB. Transactions are committed while blocking other writes
+// through this GoLevelDB, but writes made directly to the underlying leveldb.DB are not detected.
+// It fails with ErrReadOnly for read-only databases.
+func (db *GoLevelDB) NewTransaction() (tmdb.Transaction, error) {
+	if db.readOnly {
+		return nil, tmdb.ErrReadOnly
+	}
+	return tmdb.NewOptimisticTransaction(db, db.commitTransaction)
+}
+
+// commitTransaction validates and writes a transaction while blocking other writes.
+func (db *GoLevelDB) commitTransaction(batch tmdb.Batch, validate func(tmdb.Reader) error) error {
+	b := batch.(*goLevelDBBatch)
+	if b.batch == nil {
+		return tmdb.ErrBatchClosed
+	}
+	db.commitMtx.Lock()
+	defer db.commitMtx.Unlock()
+
+	if err := validate(db); err != nil {
+		return err
+	}
+	if err := db.db.Write(b.batch, nil); err != nil {
+		return convertError(err)
+	}
+	return b.Close()
+}
+
+// convertError converts goleveldb errors into their tm-db equivalents. Reads of released snapshots
+// fail with ErrClosed, like reads of closed databases.
+func convertError(err error) error {
+	switch err {
+	case leveldb.ErrClosed, leveldb.ErrSnapshotReleased:
+		return tmdb.ErrClosed
+	case leveldb.ErrReadOnly:
+		return tmdb.ErrReadOnly
+	default:
+		return err
+	}
 }
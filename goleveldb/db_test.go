@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	tmdb "github.com/tendermint/tm-db"
 	"github.com/tendermint/tm-db/internal/dbtest"
 )
 
@@ -29,6 +30,30 @@ func TestGoLevelDBNewDB(t *testing.T) {
 	defer ro2.Close()
 }
 
+func TestGoLevelDBApproximateCount(t *testing.T) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	defer dbtest.CleanupDBDir("", name)
+	db, err := NewDB(name, "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// ranges with more keys than are sampled are extrapolated from their size once on disk
+	batch := db.NewBatch()
+	for i := 0; i < 5000; i++ {
+		require.NoError(t, batch.Set([]byte(fmt.Sprintf("key%05d", i)), []byte(fmt.Sprintf("value%05d", i))))
+	}
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	require.NoError(t, db.Compact(nil, nil))
+
+	count, err := db.ApproximateCount(nil, nil)
+	require.NoError(t, err)
+	require.InDelta(t, 5000, count, 1000)
+	count, err = db.ApproximateCount([]byte("key02500"), nil)
+	require.NoError(t, err)
+	require.InDelta(t, 2500, count, 500)
+}
+
 func BenchmarkGoLevelDBRandomReadsWrites(b *testing.B) {
 	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
 	db, err := NewDB(name, "")
@@ -42,3 +67,31 @@ func BenchmarkGoLevelDBRandomReadsWrites(b *testing.B) {
 
 	dbtest.BenchmarkRandomReadsWrites(b, db)
 }
+
+func TestGoLevelDBSnapshotClosed(t *testing.T) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	defer dbtest.CleanupDBDir("", name)
+	db, err := NewDB(name, "")
+	require.NoError(t, err)
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+
+	// released snapshots, and snapshots of closed databases, fail with ErrClosed
+	released, err := db.NewSnapshot()
+	require.NoError(t, err)
+	require.NoError(t, released.Close())
+	open, err := db.NewSnapshot()
+	require.NoError(t, err)
+	defer open.Close()
+	require.NoError(t, db.Close())
+
+	for _, snapshot := range []tmdb.Snapshot{released, open} {
+		_, err = snapshot.Get([]byte("a"))
+		require.Equal(t, tmdb.ErrClosed, err)
+		_, err = snapshot.Has([]byte("a"))
+		require.Equal(t, tmdb.ErrClosed, err)
+		_, err = snapshot.Iterator(nil, nil)
+		require.Equal(t, tmdb.ErrClosed, err)
+		_, err = snapshot.ReverseIterator(nil, nil)
+		require.Equal(t, tmdb.ErrClosed, err)
+	}
+}
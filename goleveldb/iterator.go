@@ -1,51 +1,60 @@
 package goleveldb
 
 import (
-	"bytes"
-
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	tmdb "github.com/tendermint/tm-db"
 )
 
 type goLevelDBIterator struct {
 	source    iterator.Iterator
+	cmp       tmdb.Comparer
 	start     []byte
 	end       []byte
 	isReverse bool
 	isInvalid bool
+	keysOnly  bool // Value returns nil
 }
 
-var _ tmdb.Iterator = (*goLevelDBIterator)(nil)
+var _ tmdb.SeekableIterator = (*goLevelDBIterator)(nil)
 
-func newGoLevelDBIterator(source iterator.Iterator, start, end []byte, isReverse bool) *goLevelDBIterator {
-	if isReverse {
-		if end == nil {
-			source.Last()
-		} else {
-			valid := source.Seek(end)
-			if valid {
-				eoakey := source.Key() // end or after key
-				if bytes.Compare(end, eoakey) <= 0 {
-					source.Prev()
-				}
-			} else {
-				source.Last()
-			}
-		}
-	} else {
-		if start == nil {
-			source.First()
-		} else {
-			source.Seek(start)
-		}
-	}
-	return &goLevelDBIterator{
+func newGoLevelDBIterator(source iterator.Iterator, cmp tmdb.Comparer, start, end []byte,
+	isReverse bool) *goLevelDBIterator {
+	itr := &goLevelDBIterator{
 		source:    source,
+		cmp:       cmp,
 		start:     start,
 		end:       end,
 		isReverse: isReverse,
 		isInvalid: false,
 	}
+	if isReverse {
+		itr.seekReverse(end, false)
+	} else {
+		itr.seekForward(start)
+	}
+	return itr
+}
+
+// seekForward moves the source to the first key at or after the given key, or to the first key
+// if nil.
+func (itr *goLevelDBIterator) seekForward(key []byte) {
+	if key == nil {
+		itr.source.First()
+	} else {
+		itr.source.Seek(key)
+	}
+}
+
+// seekReverse moves the source to the last key before the given key (or at it, if inclusive), or
+// to the last key if nil.
+func (itr *goLevelDBIterator) seekReverse(key []byte, inclusive bool) {
+	if key == nil || !itr.source.Seek(key) {
+		itr.source.Last()
+		return
+	}
+	if c := itr.cmp.Compare(itr.source.Key(), key); c > 0 || (c == 0 && !inclusive) {
+		itr.source.Prev()
+	}
 }
 
 // Domain implements Iterator.
@@ -79,12 +88,12 @@ func (itr *goLevelDBIterator) Valid() bool {
 	var key = itr.source.Key()
 
 	if itr.isReverse {
-		if start != nil && bytes.Compare(key, start) < 0 {
+		if start != nil && itr.cmp.Compare(key, start) < 0 {
 			itr.isInvalid = true
 			return false
 		}
 	} else {
-		if end != nil && bytes.Compare(end, key) <= 0 {
+		if end != nil && itr.cmp.Compare(end, key) <= 0 {
 			itr.isInvalid = true
 			return false
 		}
@@ -107,6 +116,9 @@ func (itr *goLevelDBIterator) Value() []byte {
 	// Value returns a copy of the current value.
 	// See https://github.com/syndtr/goleveldb/blob/52c212e6c196a1404ea59592d3f1c227c9f034b2/leveldb/iterator/iter.go#L88
 	itr.assertIsValid()
+	if itr.keysOnly {
+		return nil
+	}
 	return cp(itr.source.Value())
 }
 
@@ -126,6 +138,21 @@ func (itr *goLevelDBIterator) Next() {
 	}
 }
 
+// Seek implements SeekableIterator.
+func (itr *goLevelDBIterator) Seek(key []byte) {
+	itr.isInvalid = false
+	switch {
+	case itr.isReverse && itr.end != nil && itr.cmp.Compare(key, itr.end) >= 0:
+		itr.seekReverse(itr.end, false)
+	case itr.isReverse:
+		itr.seekReverse(key, true)
+	case itr.start != nil && itr.cmp.Compare(key, itr.start) < 0:
+		itr.seekForward(itr.start)
+	default:
+		itr.seekForward(key)
+	}
+}
+
 // Error implements Iterator.
 func (itr *goLevelDBIterator) Error() error {
 	return itr.source.Error()
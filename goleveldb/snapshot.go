@@ -0,0 +1,91 @@
+package goleveldb
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	tmdb "github.com/tendermint/tm-db"
+)
+
+type goLevelDBSnapshot struct {
+	snapshot *leveldb.Snapshot
+	cmp      tmdb.Comparer
+}
+
+var _ tmdb.Snapshot = (*goLevelDBSnapshot)(nil)
+
+func newGoLevelDBSnapshot(snapshot *leveldb.Snapshot, cmp tmdb.Comparer) *goLevelDBSnapshot {
+	return &goLevelDBSnapshot{
+		snapshot: snapshot,
+		cmp:      cmp,
+	}
+}
+
+// Get implements Snapshot.
+func (s *goLevelDBSnapshot) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	res, err := s.snapshot.Get(key, nil)
+	if err != nil {
+		if err == errors.ErrNotFound {
+			return nil, nil
+		}
+		return nil, convertError(err)
+	}
+	return res, nil
+}
+
+// Has implements Snapshot.
+func (s *goLevelDBSnapshot) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	has, err := s.snapshot.Has(key, nil)
+	if err != nil {
+		return false, convertError(err)
+	}
+	return has, nil
+}
+
+// Iterator implements Snapshot.
+func (s *goLevelDBSnapshot) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	itr, err := s.newIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newGoLevelDBIterator(itr, s.cmp, start, end, false), nil
+}
+
+// ReverseIterator implements Snapshot.
+func (s *goLevelDBSnapshot) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	itr, err := s.newIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newGoLevelDBIterator(itr, s.cmp, start, end, true), nil
+}
+
+// newIterator creates an iterator over the given range of the snapshot.
+func (s *goLevelDBSnapshot) newIterator(start, end []byte) (iterator.Iterator, error) {
+	itr := s.snapshot.NewIterator(&util.Range{Start: start, Limit: end}, nil)
+	// Iterators of released snapshots and closed databases are empty, with the error set.
+	if err := itr.Error(); err != nil {
+		itr.Release()
+		return nil, convertError(err)
+	}
+	return itr, nil
+}
+
+// Close implements Snapshot.
+func (s *goLevelDBSnapshot) Close() error {
+	s.snapshot.Release()
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package estimate estimates the number of keys in a range, for backends which can estimate the
+// size of a range but not the number of keys in it.
+package estimate
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// sampleKeys is the number of keys which are counted before extrapolating.
+const sampleKeys = 1000
+
+// SizeFunc returns the approximate size of the range [start, end), where a nil end is the end of
+// the database.
+type SizeFunc func(start, end []byte) (int64, error)
+
+// Count estimates the number of keys in the domain of an iterator, given the approximate size of
+// the domain. It counts up to sampleKeys keys, and if there are more, extrapolates from the
+// approximate size of the range of the counted keys. Ranges with more keys than sampled whose
+// size is unknown, e.g. because they have not been written to disk yet, count as sampleKeys keys.
+// The iterator is not closed.
+func Count(itr tmdb.Iterator, size int64, sizeOf SizeFunc) (int64, error) {
+	start, _ := itr.Domain()
+	var (
+		count int64
+		last  []byte
+	)
+	for ; itr.Valid() && count < sampleKeys; itr.Next() {
+		count++
+		last = append(last[:0], itr.Key()...)
+	}
+	if err := itr.Error(); err != nil {
+		return 0, err
+	}
+	if !itr.Valid() {
+		return count, nil
+	}
+
+	// The sampled range ends right after the last counted key.
+	sampleSize, err := sizeOf(start, append(last, 0))
+	if err != nil {
+		return 0, err
+	}
+	if sampleSize <= 0 || sampleSize >= size {
+		return count, nil
+	}
+	return count * size / sampleSize, nil
+}
@@ -0,0 +1,41 @@
+// Package keylock provides striped key locks, for backends which have no native way to update a
+// key atomically.
+package keylock
+
+import "sync"
+
+// stripes is the number of mutexes that keys are hashed to. Keys which hash to the same mutex
+// contend for it, which affects performance but not correctness.
+const stripes = 256
+
+// FNV-1a hash parameters.
+const (
+	fnvOffset uint32 = 2166136261
+	fnvPrime  uint32 = 16777619
+)
+
+// Locks is a fixed set of mutexes, where each key is hashed to one of them. The zero value is
+// ready to use.
+type Locks struct {
+	mtxs [stripes]sync.Mutex
+}
+
+// Lock locks the mutex for the given key.
+func (l *Locks) Lock(key []byte) {
+	l.mtxs[stripe(key)].Lock()
+}
+
+// Unlock unlocks the mutex for the given key.
+func (l *Locks) Unlock(key []byte) {
+	l.mtxs[stripe(key)].Unlock()
+}
+
+// stripe returns the index of the mutex for a key.
+func stripe(key []byte) uint32 {
+	h := fnvOffset
+	for _, b := range key {
+		h ^= uint32(b)
+		h *= fnvPrime
+	}
+	return h % stripes
+}
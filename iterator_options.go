@@ -0,0 +1,20 @@
+package db
+
+// IteratorWithOptions returns an iterator over a domain of keys of a reader, configured by the
+// given options. It uses IteratorOptionsDB.IteratorWithOptions if the reader implements it, and
+// otherwise ignores all options except Reverse and KeysOnly.
+func IteratorWithOptions(r Reader, start, end []byte, opts IteratorOptions) (Iterator, error) {
+	if idb, ok := r.(IteratorOptionsDB); ok {
+		return idb.IteratorWithOptions(start, end, opts)
+	}
+	switch {
+	case opts.Reverse && opts.KeysOnly:
+		return ReverseKeysIterator(r, start, end)
+	case opts.Reverse:
+		return r.ReverseIterator(start, end)
+	case opts.KeysOnly:
+		return KeysIterator(r, start, end)
+	default:
+		return r.Iterator(start, end)
+	}
+}
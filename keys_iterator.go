@@ -0,0 +1,89 @@
+package db
+
+// KeysIterator returns an iterator over a domain of keys of a reader, whose Value method returns
+// nil. It uses KeysIteratorDB.KeysIterator if the reader implements it, and otherwise wraps a
+// regular iterator.
+func KeysIterator(r Reader, start, end []byte) (Iterator, error) {
+	if kdb, ok := r.(KeysIteratorDB); ok {
+		return kdb.KeysIterator(start, end)
+	}
+	itr, err := r.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &keysIterator{source: itr}, nil
+}
+
+// ReverseKeysIterator returns an iterator over a domain of keys of a reader in reverse, whose
+// Value method returns nil. It uses KeysIteratorDB.ReverseKeysIterator if the reader implements
+// it, and otherwise wraps a regular iterator.
+func ReverseKeysIterator(r Reader, start, end []byte) (Iterator, error) {
+	if kdb, ok := r.(KeysIteratorDB); ok {
+		return kdb.ReverseKeysIterator(start, end)
+	}
+	itr, err := r.ReverseIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &keysIterator{source: itr}, nil
+}
+
+// keysIterator wraps an iterator to hide its values.
+type keysIterator struct {
+	source Iterator
+	err    error
+}
+
+var _ SeekableIterator = (*keysIterator)(nil)
+
+// Domain implements Iterator.
+func (itr *keysIterator) Domain() (start, end []byte) {
+	return itr.source.Domain()
+}
+
+// Valid implements Iterator.
+func (itr *keysIterator) Valid() bool {
+	return itr.err == nil && itr.source.Valid()
+}
+
+// Next implements Iterator.
+func (itr *keysIterator) Next() {
+	itr.source.Next()
+}
+
+// Seek implements SeekableIterator. It fails with ErrNotSupported if the source iterator does not
+// implement SeekableIterator.
+func (itr *keysIterator) Seek(key []byte) {
+	source, ok := itr.source.(SeekableIterator)
+	if !ok {
+		itr.err = ErrNotSupported
+		return
+	}
+	source.Seek(key)
+}
+
+// Key implements Iterator.
+func (itr *keysIterator) Key() []byte {
+	return itr.source.Key()
+}
+
+// Value implements Iterator. It always returns nil.
+func (itr *keysIterator) Value() []byte {
+	if !itr.Valid() {
+		panic("iterator is invalid")
+	}
+	return nil
+}
+
+// Error implements Iterator.
+func (itr *keysIterator) Error() error {
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *keysIterator) Close() error {
+	return itr.source.Close()
+}
@@ -0,0 +1,69 @@
+package logdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// logDBBatch records batch operations. Records of batch writes contain the total size of the
+// values set in the batch.
+type logDBBatch struct {
+	in        *instrument
+	source    tmdb.Batch
+	valueSize int
+}
+
+var _ tmdb.Batch = (*logDBBatch)(nil)
+
+func newLogDBBatch(in *instrument, source tmdb.Batch) *logDBBatch {
+	return &logDBBatch{in: in, source: source}
+}
+
+// Set implements Batch.
+func (b *logDBBatch) Set(key, value []byte) (err error) {
+	c := b.in.start("batch_set")
+	defer c.finish(&err)
+	c.key, c.record.ValueSize = key, len(value)
+	if err = b.source.Set(key, value); err == nil {
+		b.valueSize += len(value)
+	}
+	return err
+}
+
+// Delete implements Batch.
+func (b *logDBBatch) Delete(key []byte) (err error) {
+	c := b.in.start("batch_delete")
+	defer c.finish(&err)
+	c.key = key
+	return b.source.Delete(key)
+}
+
+// DeleteRange implements Batch.
+func (b *logDBBatch) DeleteRange(start, end []byte) (err error) {
+	c := b.in.start("batch_delete_range")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return b.source.DeleteRange(start, end)
+}
+
+// Write implements Batch.
+func (b *logDBBatch) Write() (err error) {
+	c := b.in.start("batch_write")
+	defer c.finish(&err)
+	c.record.ValueSize = b.valueSize
+	return b.source.Write()
+}
+
+// WriteSync implements Batch.
+func (b *logDBBatch) WriteSync() (err error) {
+	c := b.in.start("batch_write_sync")
+	defer c.finish(&err)
+	c.record.ValueSize = b.valueSize
+	return b.source.WriteSync()
+}
+
+// Close implements Batch.
+func (b *logDBBatch) Close() (err error) {
+	c := b.in.start("batch_close")
+	defer c.finish(&err)
+	return b.source.Close()
+}
@@ -0,0 +1,345 @@
+// Package logdb provides a database wrapper which emits structured log records and trace spans for
+// database, batch and iterator operations.
+package logdb
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"time"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// DefaultMaxKeyLength is the default number of key bytes included in records.
+const DefaultMaxKeyLength = 32
+
+// Options configures a LogDB.
+type Options struct {
+	// Logger receives log records, if given.
+	Logger Logger
+	// Tracer starts trace spans, if given.
+	Tracer Tracer
+	// Fields are caller-supplied fields added to every record, e.g. the name of the database.
+	Fields map[string]string
+	// SampleRate is the fraction of operations which are logged and traced, between 0 and 1.
+	// Defaults to 1 if 0, while a negative rate only logs slow and failed operations. Slow and
+	// failed operations are always logged, but only traced if sampled.
+	SampleRate float64
+	// SlowThreshold is the duration at which operations are considered slow, if non-zero.
+	SlowThreshold time.Duration
+	// MaxKeyLength is the number of key bytes included in records. Defaults to
+	// DefaultMaxKeyLength if 0.
+	MaxKeyLength int
+}
+
+// LogDB wraps a database to emit a log record and a trace span for every database, batch and
+// iterator operation, subject to sampling. Use WithContext to trace operations as part of a
+// caller's span. Snapshots are not instrumented, and LogDB does not implement TransactionDB.
+type LogDB struct {
+	source tmdb.DB
+	in     *instrument
+}
+
+var (
+	_ tmdb.MultiGetDB        = (*LogDB)(nil)
+	_ tmdb.KeysIteratorDB    = (*LogDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*LogDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*LogDB)(nil)
+	_ tmdb.ComparerDB        = (*LogDB)(nil)
+)
+
+// NewDB wraps a database with the given options.
+func NewDB(db tmdb.DB, opts Options) *LogDB {
+	if opts.MaxKeyLength == 0 {
+		opts.MaxKeyLength = DefaultMaxKeyLength
+	}
+	return &LogDB{
+		source: db,
+		in:     &instrument{opts: opts, ctx: context.Background()},
+	}
+}
+
+// WithContext returns a view of the database whose records carry the given context, and whose
+// spans are started from it, including those of its batches and iterators.
+func (db *LogDB) WithContext(ctx context.Context) *LogDB {
+	return &LogDB{
+		source: db.source,
+		in:     &instrument{opts: db.in.opts, ctx: ctx},
+	}
+}
+
+// instrument emits records and spans for operations.
+type instrument struct {
+	opts Options
+	ctx  context.Context
+}
+
+// start starts an operation.
+func (in *instrument) start(op string) *call {
+	c := &call{
+		in:      in,
+		started: time.Now(),
+		sampled: in.sample(),
+		record:  Record{Op: op, Context: in.ctx, Fields: in.opts.Fields},
+	}
+	if c.sampled && in.opts.Tracer != nil {
+		c.span = in.opts.Tracer.StartSpan(in.ctx, op)
+	}
+	return c
+}
+
+// sample decides whether to log and trace an operation.
+func (in *instrument) sample() bool {
+	switch rate := in.opts.SampleRate; {
+	case rate == 0 || rate >= 1:
+		return true
+	case rate < 0:
+		return false
+	default:
+		return rand.Float64() < rate // nolint: gosec
+	}
+}
+
+// call is an operation in progress. Keys are only formatted once the operation is recorded.
+type call struct {
+	in         *instrument
+	started    time.Time
+	sampled    bool
+	span       Span
+	key        []byte
+	start, end []byte
+	record     Record
+}
+
+// setRange sets the range of a range operation.
+func (c *call) setRange(start, end []byte) {
+	c.start, c.end = start, end
+}
+
+// finish ends the operation, which failed if err is non-nil and points to a non-nil error, and
+// emits its record. It is meant to be deferred.
+func (c *call) finish(err *error) {
+	c.record.Duration = time.Since(c.started)
+	if err != nil {
+		c.record.Err = *err
+	}
+	threshold := c.in.opts.SlowThreshold
+	c.record.Slow = threshold > 0 && c.record.Duration >= threshold
+	logged := c.in.opts.Logger != nil && (c.sampled || c.record.Slow || c.record.Err != nil)
+	if c.span == nil && !logged {
+		return
+	}
+
+	maxLength := c.in.opts.MaxKeyLength
+	if c.key != nil {
+		c.record.Key = formatKey(c.key, maxLength)
+	}
+	if c.start != nil {
+		c.record.Start = formatKey(c.start, maxLength)
+	}
+	if c.end != nil {
+		c.record.End = formatKey(c.end, maxLength)
+	}
+	if c.span != nil {
+		c.span.End(c.record)
+	}
+	if logged {
+		c.in.opts.Logger.Log(c.record)
+	}
+}
+
+// Get implements DB.
+func (db *LogDB) Get(key []byte) (value []byte, err error) {
+	c := db.in.start("get")
+	defer c.finish(&err)
+	c.key = key
+	value, err = db.source.Get(key)
+	c.record.ValueSize = len(value)
+	return value, err
+}
+
+// Has implements DB.
+func (db *LogDB) Has(key []byte) (exists bool, err error) {
+	c := db.in.start("has")
+	defer c.finish(&err)
+	c.key = key
+	return db.source.Has(key)
+}
+
+// GetMany implements MultiGetDB. Records contain the total size of the values, but no keys.
+func (db *LogDB) GetMany(keys [][]byte) (values [][]byte, err error) {
+	c := db.in.start("get_many")
+	defer c.finish(&err)
+	values, err = tmdb.GetMany(db.source, keys)
+	for _, value := range values {
+		c.record.ValueSize += len(value)
+	}
+	return values, err
+}
+
+// Iterator implements DB.
+func (db *LogDB) Iterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	c := db.in.start("iterator")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return db.newIterator(db.source.Iterator(start, end))
+}
+
+// ReverseIterator implements DB.
+func (db *LogDB) ReverseIterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	c := db.in.start("reverse_iterator")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return db.newIterator(db.source.ReverseIterator(start, end))
+}
+
+// KeysIterator implements KeysIteratorDB.
+func (db *LogDB) KeysIterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	c := db.in.start("keys_iterator")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return db.newIterator(tmdb.KeysIterator(db.source, start, end))
+}
+
+// ReverseKeysIterator implements KeysIteratorDB.
+func (db *LogDB) ReverseKeysIterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	c := db.in.start("reverse_keys_iterator")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return db.newIterator(tmdb.ReverseKeysIterator(db.source, start, end))
+}
+
+// IteratorWithOptions implements IteratorOptionsDB.
+func (db *LogDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (itr tmdb.Iterator, err error) {
+	c := db.in.start("iterator_with_options")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return db.newIterator(tmdb.IteratorWithOptions(db.source, start, end, opts))
+}
+
+// newIterator wraps an iterator returned by the wrapped database.
+func (db *LogDB) newIterator(source tmdb.Iterator, err error) (tmdb.Iterator, error) {
+	if err != nil {
+		return nil, err
+	}
+	return newLogDBIterator(db.in, source), nil
+}
+
+// Comparer implements ComparerDB.
+func (db *LogDB) Comparer() tmdb.Comparer {
+	return tmdb.KeyComparer(db.source)
+}
+
+// ApproximateSize implements ApproximateSizeDB.
+func (db *LogDB) ApproximateSize(start, end []byte) (size int64, err error) {
+	c := db.in.start("approximate_size")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return tmdb.ApproximateSize(db.source, start, end)
+}
+
+// ApproximateCount implements ApproximateSizeDB.
+func (db *LogDB) ApproximateCount(start, end []byte) (count int64, err error) {
+	c := db.in.start("approximate_count")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return tmdb.ApproximateCount(db.source, start, end)
+}
+
+// Set implements DB.
+func (db *LogDB) Set(key, value []byte) (err error) {
+	c := db.in.start("set")
+	defer c.finish(&err)
+	c.key, c.record.ValueSize = key, len(value)
+	return db.source.Set(key, value)
+}
+
+// SetSync implements DB.
+func (db *LogDB) SetSync(key, value []byte) (err error) {
+	c := db.in.start("set_sync")
+	defer c.finish(&err)
+	c.key, c.record.ValueSize = key, len(value)
+	return db.source.SetSync(key, value)
+}
+
+// Delete implements DB.
+func (db *LogDB) Delete(key []byte) (err error) {
+	c := db.in.start("delete")
+	defer c.finish(&err)
+	c.key = key
+	return db.source.Delete(key)
+}
+
+// DeleteSync implements DB.
+func (db *LogDB) DeleteSync(key []byte) (err error) {
+	c := db.in.start("delete_sync")
+	defer c.finish(&err)
+	c.key = key
+	return db.source.DeleteSync(key)
+}
+
+// DeleteRange implements DB.
+func (db *LogDB) DeleteRange(start, end []byte) (err error) {
+	c := db.in.start("delete_range")
+	defer c.finish(&err)
+	c.setRange(start, end)
+	return db.source.DeleteRange(start, end)
+}
+
+// Update implements DB. Records contain the size of the new value returned by the last call to
+// the update function.
+func (db *LogDB) Update(key []byte, fn tmdb.UpdateFunc) (err error) {
+	c := db.in.start("update")
+	defer c.finish(&err)
+	c.key = key
+	return db.source.Update(key, func(value []byte) ([]byte, bool, error) {
+		newValue, del, err := fn(value)
+		c.record.ValueSize = len(newValue)
+		return newValue, del, err
+	})
+}
+
+// CompareAndSwap implements DB.
+func (db *LogDB) CompareAndSwap(key, expected, value []byte) (swapped bool, err error) {
+	c := db.in.start("compare_and_swap")
+	defer c.finish(&err)
+	c.key, c.record.ValueSize = key, len(value)
+	return db.source.CompareAndSwap(key, expected, value)
+}
+
+// NewSnapshot implements DB. The snapshot is not instrumented.
+func (db *LogDB) NewSnapshot() (snapshot tmdb.Snapshot, err error) {
+	c := db.in.start("new_snapshot")
+	defer c.finish(&err)
+	return db.source.NewSnapshot()
+}
+
+// NewBatch implements DB.
+func (db *LogDB) NewBatch() tmdb.Batch {
+	c := db.in.start("new_batch")
+	defer c.finish(nil)
+	return newLogDBBatch(db.in, db.source.NewBatch())
+}
+
+// Close implements DB.
+func (db *LogDB) Close() (err error) {
+	c := db.in.start("close")
+	defer c.finish(&err)
+	return db.source.Close()
+}
+
+// Dump implements DB.
+func (db *LogDB) Dump(w io.Writer, opts tmdb.DumpOptions) (err error) {
+	c := db.in.start("dump")
+	defer c.finish(&err)
+	c.setRange(opts.Start, opts.End)
+	return db.source.Dump(w, opts)
+}
+
+// Stats implements DB.
+func (db *LogDB) Stats() (stats *tmdb.Stats, err error) {
+	c := db.in.start("stats")
+	defer c.finish(&err)
+	return db.source.Stats()
+}
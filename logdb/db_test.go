@@ -0,0 +1,146 @@
+package logdb
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+// recorder is a Logger and Tracer which records log records and ended spans.
+type recorder struct {
+	mtx     sync.Mutex
+	records []Record
+	spans   []Record
+	parents []context.Context
+}
+
+func (r *recorder) Log(record Record) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.records = append(r.records, record)
+}
+
+func (r *recorder) StartSpan(ctx context.Context, op string) Span {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.parents = append(r.parents, ctx)
+	return recorderSpan{r}
+}
+
+type recorderSpan struct {
+	r *recorder
+}
+
+func (s recorderSpan) End(record Record) {
+	s.r.mtx.Lock()
+	defer s.r.mtx.Unlock()
+	s.r.spans = append(s.r.spans, record)
+}
+
+// slowDB is a database whose Get calls are slow.
+type slowDB struct {
+	*memdb.MemDB
+}
+
+func (db slowDB) Get(key []byte) ([]byte, error) {
+	time.Sleep(10 * time.Millisecond)
+	return db.MemDB.Get(key)
+}
+
+type ctxKey struct{}
+
+func TestLogDB(t *testing.T) {
+	r := &recorder{}
+	db := NewDB(memdb.NewDB(), Options{
+		Logger:       r,
+		Tracer:       r,
+		Fields:       map[string]string{"db": "state"},
+		MaxKeyLength: 2,
+	})
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	require.NoError(t, db.Set([]byte{1, 2, 3}, []byte{4, 5}))
+	_, err := db.WithContext(ctx).Get(nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte{9}, []byte{1, 2, 3}))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	itr, err := db.ReverseIterator([]byte{1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, itr.Close())
+
+	require.Len(t, r.records, 8)
+	assert.Equal(t, r.records, r.spans)
+	assert.Equal(t, Record{
+		Op:        "set",
+		Key:       "0102...",
+		ValueSize: 2,
+		Duration:  r.records[0].Duration,
+		Context:   context.Background(),
+		Fields:    map[string]string{"db": "state"},
+	}, r.records[0])
+	assert.Equal(t, "get", r.records[1].Op)
+	assert.Equal(t, tmdb.ErrKeyEmpty, r.records[1].Err)
+	assert.Equal(t, ctx, r.records[1].Context)
+	assert.Equal(t, ctx, r.parents[1])
+	assert.Equal(t, "new_batch", r.records[2].Op)
+	assert.Equal(t, "batch_set", r.records[3].Op)
+	assert.Equal(t, "batch_write", r.records[4].Op)
+	assert.Equal(t, 3, r.records[4].ValueSize)
+	assert.Equal(t, "batch_close", r.records[5].Op)
+	assert.Equal(t, "reverse_iterator", r.records[6].Op)
+	assert.Equal(t, "01", r.records[6].Start)
+	assert.Equal(t, "", r.records[6].End)
+	assert.Equal(t, "iterator_close", r.records[7].Op)
+}
+
+func TestLogDBSampling(t *testing.T) {
+	r := &recorder{}
+	db := NewDB(slowDB{memdb.NewDB()}, Options{
+		Logger:        r,
+		Tracer:        r,
+		SampleRate:    -1,
+		SlowThreshold: 5 * time.Millisecond,
+	})
+
+	// only slow and failed operations are logged, and none are traced
+	require.NoError(t, db.Set([]byte{1}, []byte{1}))
+	_, err := db.Has(nil)
+	require.Error(t, err)
+	_, err = db.Get([]byte{1})
+	require.NoError(t, err)
+
+	require.Len(t, r.records, 2)
+	assert.Equal(t, "has", r.records[0].Op)
+	assert.False(t, r.records[0].Slow)
+	assert.Equal(t, "get", r.records[1].Op)
+	assert.True(t, r.records[1].Slow)
+	assert.Empty(t, r.spans)
+}
+
+func TestWriterLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewWriterLogger(buf)
+	logger.Log(Record{
+		Op:        "get",
+		Key:       "0102",
+		ValueSize: 3,
+		Duration:  time.Millisecond,
+		Slow:      true,
+		Err:       tmdb.ErrClosed,
+		Fields:    map[string]string{"name": "my db", "backend": "memdb"},
+	})
+	logger.Log(Record{Op: "iterator", Start: "01", End: "02"})
+	assert.Equal(t, ""+
+		"op=get key=0102 value_size=3 duration=1ms slow=true err=\"database is closed\" backend=memdb name=\"my db\"\n"+
+		"op=iterator start=01 end=02 duration=0s\n",
+		buf.String())
+}
@@ -0,0 +1,90 @@
+package logdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// logDBIterator records iterator operations.
+type logDBIterator struct {
+	in     *instrument
+	source tmdb.Iterator
+	err    error
+}
+
+var _ tmdb.SeekableIterator = (*logDBIterator)(nil)
+
+func newLogDBIterator(in *instrument, source tmdb.Iterator) *logDBIterator {
+	return &logDBIterator{in: in, source: source}
+}
+
+// Domain implements Iterator.
+func (itr *logDBIterator) Domain() (start, end []byte) {
+	c := itr.in.start("iterator_domain")
+	defer c.finish(nil)
+	start, end = itr.source.Domain()
+	c.setRange(start, end)
+	return start, end
+}
+
+// Valid implements Iterator.
+func (itr *logDBIterator) Valid() bool {
+	c := itr.in.start("iterator_valid")
+	defer c.finish(nil)
+	return itr.err == nil && itr.source.Valid()
+}
+
+// Next implements Iterator.
+func (itr *logDBIterator) Next() {
+	c := itr.in.start("iterator_next")
+	defer c.finish(nil)
+	itr.source.Next()
+}
+
+// Seek implements SeekableIterator. It fails with ErrNotSupported if the source iterator does not
+// implement SeekableIterator.
+func (itr *logDBIterator) Seek(key []byte) {
+	c := itr.in.start("iterator_seek")
+	defer c.finish(&itr.err)
+	c.key = key
+	source, ok := itr.source.(tmdb.SeekableIterator)
+	if !ok {
+		itr.err = tmdb.ErrNotSupported
+		return
+	}
+	source.Seek(key)
+}
+
+// Key implements Iterator.
+func (itr *logDBIterator) Key() (key []byte) {
+	c := itr.in.start("iterator_key")
+	defer c.finish(nil)
+	key = itr.source.Key()
+	c.key = key
+	return key
+}
+
+// Value implements Iterator.
+func (itr *logDBIterator) Value() (value []byte) {
+	c := itr.in.start("iterator_value")
+	defer c.finish(nil)
+	value = itr.source.Value()
+	c.record.ValueSize = len(value)
+	return value
+}
+
+// Error implements Iterator.
+func (itr *logDBIterator) Error() (err error) {
+	c := itr.in.start("iterator_error")
+	defer c.finish(&err)
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *logDBIterator) Close() (err error) {
+	c := itr.in.start("iterator_close")
+	defer c.finish(&err)
+	return itr.source.Close()
+}
@@ -0,0 +1,122 @@
+package logdb
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record describes a database, batch or iterator operation.
+type Record struct {
+	// Op is the name of the operation, e.g. "get" or "batch_write".
+	Op string
+	// Key is the hex-encoded key of point operations, truncated to Options.MaxKeyLength bytes.
+	Key string
+	// Start and End are the hex-encoded, truncated bounds of range operations, empty if unbounded.
+	Start string
+	End   string
+	// ValueSize is the size of the values read or written, if any.
+	ValueSize int
+	// Duration is the duration of the operation.
+	Duration time.Duration
+	// Err is the error returned by the operation, if any.
+	Err error
+	// Slow is true if the operation took at least Options.SlowThreshold.
+	Slow bool
+	// Context is the context given to LogDB.WithContext, or context.Background().
+	Context context.Context
+	// Fields are the caller-supplied Options.Fields.
+	Fields map[string]string
+}
+
+// Logger receives log records. It must be safe for concurrent use.
+type Logger interface {
+	// Log logs a record of a completed operation.
+	Log(record Record)
+}
+
+// Tracer starts trace spans. It must be safe for concurrent use.
+type Tracer interface {
+	// StartSpan starts a span for an operation, as a child of any span in the given context.
+	StartSpan(ctx context.Context, op string) Span
+}
+
+// Span is a trace span for an operation.
+type Span interface {
+	// End ends the span, with a record of the completed operation.
+	End(record Record)
+}
+
+// WriterLogger is a Logger which writes records to an io.Writer in the logfmt format, one line per
+// record.
+type WriterLogger struct {
+	mtx sync.Mutex
+	w   io.Writer
+}
+
+var _ Logger = (*WriterLogger)(nil)
+
+// NewWriterLogger creates a new WriterLogger.
+func NewWriterLogger(w io.Writer) *WriterLogger {
+	return &WriterLogger{w: w}
+}
+
+// Log implements Logger. Write errors are ignored.
+func (l *WriterLogger) Log(record Record) {
+	var b strings.Builder
+	b.WriteString("op=" + record.Op)
+	if record.Key != "" {
+		b.WriteString(" key=" + record.Key)
+	}
+	if record.Start != "" {
+		b.WriteString(" start=" + record.Start)
+	}
+	if record.End != "" {
+		b.WriteString(" end=" + record.End)
+	}
+	if record.ValueSize > 0 {
+		b.WriteString(" value_size=" + strconv.Itoa(record.ValueSize))
+	}
+	b.WriteString(" duration=" + record.Duration.String())
+	if record.Slow {
+		b.WriteString(" slow=true")
+	}
+	if record.Err != nil {
+		b.WriteString(" err=" + logfmtValue(record.Err.Error()))
+	}
+	names := make([]string, 0, len(record.Fields))
+	for name := range record.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(" " + name + "=" + logfmtValue(record.Fields[name]))
+	}
+	b.WriteString("\n")
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+// logfmtValue quotes a logfmt value if necessary.
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\\n\t") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// formatKey hex-encodes a key, truncated to the given length.
+func formatKey(key []byte, maxLength int) string {
+	if len(key) > maxLength {
+		return fmt.Sprintf("%x...", key[:maxLength])
+	}
+	return hex.EncodeToString(key)
+}
@@ -12,8 +12,11 @@ type opType int
 const (
 	opTypeSet opType = iota + 1
 	opTypeDelete
+	opTypeDeleteRange
 )
 
+// operation is a batch operation. For opTypeDeleteRange, key and value are the start and end of
+// the range.
 type operation struct {
 	opType
 	key   []byte
@@ -63,6 +66,18 @@ func (b *memDBBatch) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange implements Batch.
+func (b *memDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.ops == nil {
+		return tmdb.ErrBatchClosed
+	}
+	b.ops = append(b.ops, operation{opTypeDeleteRange, start, end})
+	return nil
+}
+
 // Write implements Batch.
 func (b *memDBBatch) Write() error {
 	if b.ops == nil {
@@ -71,12 +86,22 @@ func (b *memDBBatch) Write() error {
 	b.db.mtx.Lock()
 	defer b.db.mtx.Unlock()
 
+	if b.db.closed {
+		return tmdb.ErrClosed
+	}
+	return b.write()
+}
+
+// write applies the batch to the database, the caller must hold the database's write lock.
+func (b *memDBBatch) write() error {
 	for _, op := range b.ops {
 		switch op.opType {
 		case opTypeSet:
 			b.db.set(op.key, op.value)
 		case opTypeDelete:
 			b.db.delete(op.key)
+		case opTypeDeleteRange:
+			b.db.deleteRange(op.key, op.value)
 		default:
 			return fmt.Errorf("unknown operation type %v (%v)", op.opType, op)
 		}
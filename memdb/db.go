@@ -2,7 +2,7 @@ package memdb
 
 import (
 	"bytes"
-	"fmt"
+	"io"
 	"sync"
 
 	"github.com/google/btree"
@@ -18,23 +18,32 @@ const (
 type item struct {
 	key   []byte
 	value []byte
+	cmp   tmdb.Comparer // orders keys, or nil for byte order
 }
 
 // Less implements btree.Item.
 func (i *item) Less(other btree.Item) bool {
 	// this considers nil == []byte{}, but that's ok since we handle nil endpoints
 	// in iterators specially anyway
+	if i.cmp != nil {
+		return i.cmp.Compare(i.key, other.(*item).key) < 0
+	}
 	return bytes.Compare(i.key, other.(*item).key) == -1
 }
 
 // newKey creates a new key item.
-func newKey(key []byte) *item {
-	return &item{key: key}
+func (db *MemDB) newKey(key []byte) *item {
+	return &item{key: key, cmp: db.cmp}
 }
 
 // newPair creates a new pair item.
-func newPair(key, value []byte) *item {
-	return &item{key: key, value: value}
+func (db *MemDB) newPair(key, value []byte) *item {
+	return &item{key: key, value: value, cmp: db.cmp}
+}
+
+// size returns the size of the item's key and value.
+func (i *item) size() int64 {
+	return int64(len(i.key) + len(i.value))
 }
 
 // MemDB is an in-memory database backend using a B-tree for storage.
@@ -44,11 +53,19 @@ func newPair(key, value []byte) *item {
 // already specify that keys and values should be considered read-only, but this is especially
 // important with MemDB.
 type MemDB struct {
-	mtx   sync.RWMutex
-	btree *btree.BTree
+	mtx    sync.RWMutex
+	btree  *btree.BTree
+	cmp    tmdb.Comparer // orders keys, or nil for byte order
+	size   int64         // total size of keys and values
+	closed bool
 }
 
-var _ tmdb.DB = (*MemDB)(nil)
+var (
+	_ tmdb.TransactionDB     = (*MemDB)(nil)
+	_ tmdb.MultiGetDB        = (*MemDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*MemDB)(nil)
+	_ tmdb.ComparerDB        = (*MemDB)(nil)
+)
 
 // NewDB creates a new in-memory database.
 func NewDB() *MemDB {
@@ -58,6 +75,31 @@ func NewDB() *MemDB {
 	return database
 }
 
+// NewDBWithComparer creates a new in-memory database which orders keys with the given comparer.
+func NewDBWithComparer(cmp tmdb.Comparer) *MemDB {
+	database := NewDB()
+	if !tmdb.IsBytesComparer(cmp) {
+		database.cmp = cmp
+	}
+	return database
+}
+
+// Comparer implements ComparerDB.
+func (db *MemDB) Comparer() tmdb.Comparer {
+	if db.cmp == nil {
+		return tmdb.BytesComparer
+	}
+	return db.cmp
+}
+
+// compare compares two keys in the database's order.
+func (db *MemDB) compare(a, b []byte) int {
+	if db.cmp != nil {
+		return db.cmp.Compare(a, b)
+	}
+	return bytes.Compare(a, b)
+}
+
 // Get implements DB.
 func (db *MemDB) Get(key []byte) ([]byte, error) {
 	if len(key) == 0 {
@@ -66,13 +108,38 @@ func (db *MemDB) Get(key []byte) ([]byte, error) {
 	db.mtx.RLock()
 	defer db.mtx.RUnlock()
 
-	i := db.btree.Get(newKey(key))
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	i := db.btree.Get(db.newKey(key))
 	if i != nil {
 		return i.(*item).value, nil
 	}
 	return nil, nil
 }
 
+// GetMany implements MultiGetDB.
+func (db *MemDB) GetMany(keys [][]byte) ([][]byte, error) {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, tmdb.ErrKeyEmpty
+		}
+	}
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		if bi := db.btree.Get(db.newKey(key)); bi != nil {
+			values[i] = bi.(*item).value
+		}
+	}
+	return values, nil
+}
+
 // Has implements DB.
 func (db *MemDB) Has(key []byte) (bool, error) {
 	if len(key) == 0 {
@@ -81,7 +148,10 @@ func (db *MemDB) Has(key []byte) (bool, error) {
 	db.mtx.RLock()
 	defer db.mtx.RUnlock()
 
-	return db.btree.Has(newKey(key)), nil
+	if db.closed {
+		return false, tmdb.ErrClosed
+	}
+	return db.btree.Has(db.newKey(key)), nil
 }
 
 // Set implements DB.
@@ -95,13 +165,19 @@ func (db *MemDB) Set(key []byte, value []byte) error {
 	db.mtx.Lock()
 	defer db.mtx.Unlock()
 
+	if db.closed {
+		return tmdb.ErrClosed
+	}
 	db.set(key, value)
 	return nil
 }
 
 // set sets a value without locking the mutex.
 func (db *MemDB) set(key []byte, value []byte) {
-	db.btree.ReplaceOrInsert(newPair(key, value))
+	db.size += int64(len(key) + len(value))
+	if old := db.btree.ReplaceOrInsert(db.newPair(key, value)); old != nil {
+		db.size -= old.(*item).size()
+	}
 }
 
 // SetSync implements DB.
@@ -117,13 +193,18 @@ func (db *MemDB) Delete(key []byte) error {
 	db.mtx.Lock()
 	defer db.mtx.Unlock()
 
+	if db.closed {
+		return tmdb.ErrClosed
+	}
 	db.delete(key)
 	return nil
 }
 
 // delete deletes a key without locking the mutex.
 func (db *MemDB) delete(key []byte) {
-	db.btree.Delete(newKey(key))
+	if old := db.btree.Delete(db.newKey(key)); old != nil {
+		db.size -= old.(*item).size()
+	}
 }
 
 // DeleteSync implements DB.
@@ -131,36 +212,181 @@ func (db *MemDB) DeleteSync(key []byte) error {
 	return db.Delete(key)
 }
 
-// Close implements DB.
+// DeleteRange implements DB.
+func (db *MemDB) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	db.deleteRange(start, end)
+	return nil
+}
+
+// deleteRange deletes a range of keys without locking the mutex.
+func (db *MemDB) deleteRange(start, end []byte) {
+	// The B-tree can't be modified while traversing it, so we collect the items first.
+	var items []btree.Item
+	db.ascendRange(start, end, func(i btree.Item) bool {
+		items = append(items, i)
+		return true
+	})
+	for _, i := range items {
+		db.btree.Delete(i)
+		db.size -= i.(*item).size()
+	}
+}
+
+// ascendRange calls the visitor for each item in a range of keys, in order, until it returns
+// false.
+func (db *MemDB) ascendRange(start, end []byte, visitor btree.ItemIterator) {
+	switch {
+	case start == nil && end == nil:
+		db.btree.Ascend(visitor)
+	case end == nil:
+		db.btree.AscendGreaterOrEqual(db.newKey(start), visitor)
+	case start == nil:
+		db.btree.AscendLessThan(db.newKey(end), visitor)
+	default:
+		db.btree.AscendRange(db.newKey(start), db.newKey(end), visitor)
+	}
+}
+
+// Update implements DB.
+func (db *MemDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	var value []byte
+	if i := db.btree.Get(db.newKey(key)); i != nil {
+		value = i.(*item).value
+	}
+	value, del, err := fn(value)
+	switch {
+	case err != nil:
+		return err
+	case del:
+		db.delete(key)
+	case value == nil:
+		return tmdb.ErrValueNil
+	default:
+		db.set(key, value)
+	}
+	return nil
+}
+
+// CompareAndSwap implements DB.
+func (db *MemDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return false, tmdb.ErrClosed
+	}
+	var current []byte
+	if i := db.btree.Get(db.newKey(key)); i != nil {
+		current = i.(*item).value
+	}
+	if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+		return false, nil
+	}
+	if value == nil {
+		db.delete(key)
+	} else {
+		db.set(key, value)
+	}
+	return true, nil
+}
+
+// Close implements DB. The contents are kept in memory until the database is garbage collected,
+// but further use of the database returns ErrClosed. Open iterators and snapshots remain usable.
 func (db *MemDB) Close() error {
-	// Close is a noop since for an in-memory database, we don't have a destination to flush
-	// contents to nor do we want any data loss on invoking Close().
-	// See the discussion in https://github.com/tendermint/tendermint/libs/pull/56
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	db.closed = true
 	return nil
 }
 
-// Print implements DB.
-func (db *MemDB) Print() error {
+// ApproximateSize implements ApproximateSizeDB. The size is exactly that of the keys and values
+// in the range.
+func (db *MemDB) ApproximateSize(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, tmdb.ErrKeyEmpty
+	}
 	db.mtx.RLock()
 	defer db.mtx.RUnlock()
 
-	db.btree.Ascend(func(i btree.Item) bool {
-		item := i.(*item)
-		fmt.Printf("[%X]:\t[%X]\n", item.key, item.value)
+	if db.closed {
+		return 0, tmdb.ErrClosed
+	}
+	if start == nil && end == nil {
+		return db.size, nil
+	}
+	var size int64
+	db.ascendRange(start, end, func(i btree.Item) bool {
+		size += i.(*item).size()
 		return true
 	})
-	return nil
+	return size, nil
+}
+
+// ApproximateCount implements ApproximateSizeDB. The count is exact.
+func (db *MemDB) ApproximateCount(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, tmdb.ErrKeyEmpty
+	}
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+
+	if db.closed {
+		return 0, tmdb.ErrClosed
+	}
+	if start == nil && end == nil {
+		return int64(db.btree.Len()), nil
+	}
+	var count int64
+	db.ascendRange(start, end, func(btree.Item) bool {
+		count++
+		return true
+	})
+	return count, nil
+}
+
+// Dump implements DB.
+func (db *MemDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, db, opts)
 }
 
 // Stats implements DB.
-func (db *MemDB) Stats() map[string]string {
+func (db *MemDB) Stats() (*tmdb.Stats, error) {
 	db.mtx.RLock()
 	defer db.mtx.RUnlock()
 
-	stats := make(map[string]string)
-	stats["database.type"] = "memDB"
-	stats["database.size"] = fmt.Sprintf("%d", db.btree.Len())
-	return stats
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	return &tmdb.Stats{
+		Backend:      "memdb",
+		KeyCount:     int64(db.btree.Len()),
+		MemTableSize: db.size,
+	}, nil
 }
 
 // NewBatch implements DB.
@@ -169,19 +395,62 @@ func (db *MemDB) NewBatch() tmdb.Batch {
 }
 
 // Iterator implements DB.
-// Takes out a read-lock on the database until the iterator is closed.
+// Items are read in batches, taking out a read-lock on the database only while reading each batch.
 func (db *MemDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	if db.isClosed() {
+		return nil, tmdb.ErrClosed
+	}
 	return newMemDBIterator(db, start, end, false), nil
 }
 
 // ReverseIterator implements DB.
-// Takes out a read-lock on the database until the iterator is closed.
+// Items are read in batches, taking out a read-lock on the database only while reading each batch.
 func (db *MemDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	if db.isClosed() {
+		return nil, tmdb.ErrClosed
+	}
 	return newMemDBIterator(db, start, end, true), nil
 }
+
+// NewSnapshot implements DB.
+func (db *MemDB) NewSnapshot() (tmdb.Snapshot, error) {
+	return newMemDBSnapshot(db)
+}
+
+// isClosed returns whether the database has been closed.
+func (db *MemDB) isClosed() bool {
+	db.mtx.RLock()
+	defer db.mtx.RUnlock()
+	return db.closed
+}
+
+// NewTransaction implements TransactionDB.
+func (db *MemDB) NewTransaction() (tmdb.Transaction, error) {
+	return tmdb.NewOptimisticTransaction(db, db.commitTransaction)
+}
+
+// commitTransaction validates and writes a transaction while holding the write lock.
+func (db *MemDB) commitTransaction(batch tmdb.Batch, validate func(tmdb.Reader) error) error {
+	b := batch.(*memDBBatch)
+	if b.ops == nil {
+		return tmdb.ErrBatchClosed
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	// We already hold the write lock, so validate using a database which shares the B-tree but
+	// has its own mutex.
+	if err := validate(&MemDB{btree: db.btree, cmp: db.cmp}); err != nil {
+		return err
+	}
+	return b.write()
+}
@@ -1,104 +1,104 @@
 package memdb
 
 import (
-	"bytes"
-	"context"
-
 	"github.com/google/btree"
 	tmdb "github.com/tendermint/tm-db"
 )
 
 const (
-	// Size of the channel buffer between traversal goroutine and iterator. Using an unbuffered
-	// channel causes two context switches per item sent, while buffering allows more work per
-	// context switch. Tuned with benchmarks.
-	chBufferSize = 64
+	// The number of items read from the B-tree at a time, while holding the database read lock.
+	// The B-tree has no cursors, so each batch requires a new traversal from the last key, while
+	// larger batches read more items that may never be used. Tuned with benchmarks.
+	iteratorBatchSize = 64
 )
 
-// memDBIterator is a memDB iterator.
+// memDBIterator is a memDB iterator. The B-tree does not support cursors, so items are read in
+// batches by traversing the tree from the last key read, which also makes it cheap to seek.
 type memDBIterator struct {
-	ch     <-chan *item
-	cancel context.CancelFunc
-	item   *item
-	start  []byte
-	end    []byte
+	db      *MemDB
+	start   []byte
+	end     []byte
+	reverse bool
+
+	items     []*item // buffered items, where items[pos] is the current item
+	pos       int
+	exhausted bool // whether the buffered items include the last item in the domain
 }
 
-var _ tmdb.Iterator = (*memDBIterator)(nil)
+var _ tmdb.SeekableIterator = (*memDBIterator)(nil)
 
 // newMemDBIterator creates a new memDBIterator.
 func newMemDBIterator(db *MemDB, start []byte, end []byte, reverse bool) *memDBIterator {
-	ctx, cancel := context.WithCancel(context.Background())
-	ch := make(chan *item, chBufferSize)
 	iter := &memDBIterator{
-		ch:     ch,
-		cancel: cancel,
-		start:  start,
-		end:    end,
+		db:      db,
+		start:   start,
+		end:     end,
+		reverse: reverse,
+		items:   make([]*item, 0, iteratorBatchSize),
 	}
+	// prime the iterator with the first batch, if any
+	iter.fill(nil, false)
+	return iter
+}
 
-	db.mtx.RLock()
-	go func() {
-		defer db.mtx.RUnlock()
-		// Because we use [start, end) for reverse ranges, while btree uses (start, end], we need
-		// the following variables to handle some reverse iteration conditions ourselves.
-		var (
-			skipEqual     []byte
-			abortLessThan []byte
-		)
-		visitor := func(i btree.Item) bool {
-			item := i.(*item)
-			if skipEqual != nil && bytes.Equal(item.key, skipEqual) {
-				skipEqual = nil
-				return true
-			}
-			if abortLessThan != nil && bytes.Compare(item.key, abortLessThan) == -1 {
-				return false
-			}
-			select {
-			case <-ctx.Done():
-				return false
-			case ch <- item:
-				return true
-			}
+// fill replaces the buffered items with the next batch of items in iteration order, beginning at
+// the given key (or after it, unless inclusive). A nil key begins at the start of the domain.
+func (i *memDBIterator) fill(from []byte, inclusive bool) {
+	i.db.mtx.RLock()
+	defer i.db.mtx.RUnlock()
+
+	i.items = i.items[:0]
+	i.pos = 0
+	i.exhausted = true
+
+	// Because we use [start, end) for reverse ranges, while btree uses (start, end], we need
+	// to skip the end key and abort after start ourselves.
+	skipEqual := from
+	if from == nil && i.reverse {
+		from = i.end
+		skipEqual = i.end
+	} else if from == nil {
+		from = i.start
+	}
+	if inclusive {
+		skipEqual = nil
+	}
+	visitor := func(bi btree.Item) bool {
+		item := bi.(*item)
+		if skipEqual != nil && i.db.compare(item.key, skipEqual) == 0 {
+			skipEqual = nil
+			return true
 		}
-		switch {
-		case start == nil && end == nil && !reverse:
-			db.btree.Ascend(visitor)
-		case start == nil && end == nil && reverse:
-			db.btree.Descend(visitor)
-		case end == nil && !reverse:
-			// must handle this specially, since nil is considered less than anything else
-			db.btree.AscendGreaterOrEqual(newKey(start), visitor)
-		case !reverse:
-			db.btree.AscendRange(newKey(start), newKey(end), visitor)
-		case end == nil:
-			// abort after start, since we use [start, end) while btree uses (start, end]
-			abortLessThan = start
-			db.btree.Descend(visitor)
-		default:
-			// skip end and abort after start, since we use [start, end) while btree uses (start, end]
-			skipEqual = end
-			abortLessThan = start
-			db.btree.DescendLessOrEqual(newKey(end), visitor)
+		if i.reverse && i.start != nil && i.db.compare(item.key, i.start) < 0 {
+			return false
 		}
-		close(ch)
-	}()
-
-	// prime the iterator with the first value, if any
-	if item, ok := <-ch; ok {
-		iter.item = item
+		if !i.reverse && i.end != nil && i.db.compare(item.key, i.end) >= 0 {
+			return false
+		}
+		if len(i.items) == iteratorBatchSize {
+			i.exhausted = false
+			return false
+		}
+		i.items = append(i.items, item)
+		return true
+	}
+	switch {
+	case from == nil && !i.reverse:
+		i.db.btree.Ascend(visitor)
+	case from == nil:
+		i.db.btree.Descend(visitor)
+	case !i.reverse:
+		// must handle this specially, since nil is considered less than anything else
+		i.db.btree.AscendGreaterOrEqual(i.db.newKey(from), visitor)
+	default:
+		i.db.btree.DescendLessOrEqual(i.db.newKey(from), visitor)
 	}
-
-	return iter
 }
 
 // Close implements Iterator.
 func (i *memDBIterator) Close() error {
-	i.cancel()
-	for range i.ch { // drain channel
-	}
-	i.item = nil
+	i.items = nil
+	i.pos = 0
 	return nil
 }
 
@@ -109,18 +109,27 @@ func (i *memDBIterator) Domain() ([]byte, []byte) {
 
 // Valid implements Iterator.
 func (i *memDBIterator) Valid() bool {
-	return i.item != nil
+	return i.pos < len(i.items)
 }
 
 // Next implements Iterator.
 func (i *memDBIterator) Next() {
 	i.assertIsValid()
-	item, ok := <-i.ch
+	i.pos++
+	if i.pos == len(i.items) && !i.exhausted {
+		i.fill(i.items[len(i.items)-1].key, false)
+	}
+}
+
+// Seek implements SeekableIterator.
+func (i *memDBIterator) Seek(key []byte) {
 	switch {
-	case ok:
-		i.item = item
+	case i.reverse && i.end != nil && i.db.compare(key, i.end) >= 0:
+		i.fill(nil, false)
+	case !i.reverse && i.start != nil && i.db.compare(key, i.start) < 0:
+		i.fill(nil, false)
 	default:
-		i.item = nil
+		i.fill(key, true)
 	}
 }
 
@@ -132,13 +141,13 @@ func (i *memDBIterator) Error() error {
 // Key implements Iterator.
 func (i *memDBIterator) Key() []byte {
 	i.assertIsValid()
-	return i.item.key
+	return i.items[i.pos].key
 }
 
 // Value implements Iterator.
 func (i *memDBIterator) Value() []byte {
 	i.assertIsValid()
-	return i.item.value
+	return i.items[i.pos].value
 }
 
 func (i *memDBIterator) assertIsValid() {
@@ -0,0 +1,54 @@
+package memdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// memDBSnapshot is a point-in-time view of a MemDB. It is backed by a lazy copy-on-write clone
+// of the database's B-tree, so taking a snapshot is cheap and nodes are only copied as the
+// database is modified.
+type memDBSnapshot struct {
+	db *MemDB
+}
+
+var _ tmdb.Snapshot = (*memDBSnapshot)(nil)
+
+// newMemDBSnapshot creates a new memDBSnapshot.
+func newMemDBSnapshot(db *MemDB) (*memDBSnapshot, error) {
+	// Clone modifies the copy-on-write context of the source tree, so we need a write lock.
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	return &memDBSnapshot{
+		db: &MemDB{btree: db.btree.Clone(), cmp: db.cmp},
+	}, nil
+}
+
+// Get implements Snapshot.
+func (s *memDBSnapshot) Get(key []byte) ([]byte, error) {
+	return s.db.Get(key)
+}
+
+// Has implements Snapshot.
+func (s *memDBSnapshot) Has(key []byte) (bool, error) {
+	return s.db.Has(key)
+}
+
+// Iterator implements Snapshot.
+func (s *memDBSnapshot) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	return s.db.Iterator(start, end)
+}
+
+// ReverseIterator implements Snapshot.
+func (s *memDBSnapshot) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	return s.db.ReverseIterator(start, end)
+}
+
+// Close implements Snapshot.
+func (s *memDBSnapshot) Close() error {
+	// Close is a noop, the cloned tree is garbage collected once the snapshot is unreferenced.
+	return nil
+}
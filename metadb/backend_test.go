@@ -1,26 +1,40 @@
 package metadb
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/cachedb"
 	"github.com/tendermint/tm-db/cleveldb"
+	"github.com/tendermint/tm-db/compressdb"
+	"github.com/tendermint/tm-db/encdb"
 	"github.com/tendermint/tm-db/goleveldb"
 	"github.com/tendermint/tm-db/internal/dbtest"
+	"github.com/tendermint/tm-db/logdb"
 	"github.com/tendermint/tm-db/memdb"
+	"github.com/tendermint/tm-db/metricsdb"
 	"github.com/tendermint/tm-db/rocksdb"
 )
 
 // Register a test backend for PrefixDB as well, with some unrelated junk data
 func init() {
 	// nolint: errcheck
-	registerDBCreator("prefixdb", func(name, dir string) (tmdb.DB, error) {
-		mdb := memdb.NewDB()
+	registerDBCreator("prefixdb", func(name, dir string, opts Options) (tmdb.DB, error) {
+		if err := requireWritable("prefixdb", opts); err != nil {
+			return nil, err
+		}
+		mdb := memdb.NewDBWithComparer(opts.Comparer)
 		mdb.Set([]byte("a"), []byte{1})
 		mdb.Set([]byte("b"), []byte{2})
 		mdb.Set([]byte("t"), []byte{20})
@@ -29,6 +43,44 @@ func init() {
 		mdb.Set([]byte("z"), []byte{26})
 		return tmdb.NewPrefixDB(mdb, []byte("test/")), nil
 	}, false)
+	registerDBCreator("cachedb", func(name, dir string, opts Options) (tmdb.DB, error) {
+		if err := requireWritable("cachedb", opts); err != nil {
+			return nil, err
+		}
+		return cachedb.NewDBWithOpts(memdb.NewDBWithComparer(opts.Comparer), cachedb.Options{Size: 4}), nil
+	}, false)
+	registerDBCreator("metricsdb", func(name, dir string, opts Options) (tmdb.DB, error) {
+		if err := requireWritable("metricsdb", opts); err != nil {
+			return nil, err
+		}
+		return metricsdb.NewDB(memdb.NewDBWithComparer(opts.Comparer), metricsdb.NewMetrics(), "memdb", name), nil
+	}, false)
+	registerDBCreator("logdb", func(name, dir string, opts Options) (tmdb.DB, error) {
+		if err := requireWritable("logdb", opts); err != nil {
+			return nil, err
+		}
+		logger := logdb.NewWriterLogger(ioutil.Discard)
+		return logdb.NewDB(memdb.NewDBWithComparer(opts.Comparer), logdb.Options{Logger: logger}), nil
+	}, false)
+	registerDBCreator("compressdb", func(name, dir string, opts Options) (tmdb.DB, error) {
+		if err := requireWritable("compressdb", opts); err != nil {
+			return nil, err
+		}
+		db := memdb.NewDBWithComparer(opts.Comparer)
+		return compressdb.NewDBWithOpts(db, compressdb.Options{Threshold: -1}), nil
+	}, false)
+	registerDBCreator("encdb", func(name, dir string, opts Options) (tmdb.DB, error) {
+		if err := requireWritable("encdb", opts); err != nil {
+			return nil, err
+		}
+		if err := requireBytesComparer("encdb", opts); err != nil {
+			return nil, err
+		}
+		return encdb.NewDB(memdb.NewDB(), encdb.Options{
+			Keys:    map[uint32][]byte{1: bytes.Repeat([]byte{1}, 32)},
+			Version: 1,
+		})
+	}, false)
 }
 
 func testBackendGetSetDelete(t *testing.T, backend BackendType) {
@@ -180,7 +232,9 @@ func TestCLevelDBStats(t *testing.T) {
 	require.NoError(t, err)
 	defer dbtest.CleanupDBDir(dir, name)
 
-	assert.NotEmpty(t, db.Stats())
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	assert.NotEmpty(t, stats.Properties)
 }
 
 func TestRocksDBBackend(t *testing.T) {
@@ -207,7 +261,9 @@ func TestRocksDBStats(t *testing.T) {
 	require.NoError(t, err)
 	defer dbtest.CleanupDBDir(dir, name)
 
-	assert.NotEmpty(t, db.Stats())
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	assert.NotEmpty(t, stats.Properties)
 }
 
 func TestDBIterator(t *testing.T) {
@@ -385,6 +441,72 @@ func verifyIterator(t *testing.T, itr tmdb.Iterator, expected []int64, msg strin
 	assert.Equal(t, expected, list, msg)
 }
 
+func TestDBIteratorSeek(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBIteratorSeek(t, dbType)
+		})
+	}
+}
+
+func testDBIteratorSeek(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	for _, key := range []string{"a", "c", "e", "g"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+
+	testCases := []struct {
+		reverse bool
+		seek    string
+		expect  []string
+	}{
+		{false, "d", []string{"e"}},
+		{false, "c", []string{"c", "e"}},
+		{false, "a", []string{"c", "e"}},
+		{false, "f", nil},
+		{false, "z", nil},
+		{true, "d", []string{"c"}},
+		{true, "e", []string{"e", "c"}},
+		{true, "z", []string{"e", "c"}},
+		{true, "b", nil},
+		{true, "a", nil},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%v %v", tc.reverse, tc.seek), func(t *testing.T) {
+			var itr tmdb.Iterator
+			if tc.reverse {
+				itr, err = db.ReverseIterator([]byte("b"), []byte("f"))
+			} else {
+				itr, err = db.Iterator([]byte("b"), []byte("f"))
+			}
+			require.NoError(t, err)
+			defer itr.Close()
+			sitr, ok := itr.(tmdb.SeekableIterator)
+			if !ok {
+				t.Skipf("%v iterators are not seekable", backend)
+			}
+
+			// exhaust the iterator first, since seeking should make it valid again
+			for ; sitr.Valid(); sitr.Next() {
+			}
+			sitr.Seek([]byte(tc.seek))
+			var keys []string
+			for ; sitr.Valid(); sitr.Next() {
+				keys = append(keys, string(sitr.Key()))
+				assert.Equal(t, sitr.Key(), sitr.Value())
+			}
+			require.NoError(t, sitr.Error())
+			assert.Equal(t, tc.expect, keys)
+		})
+	}
+}
+
 func TestDBBatch(t *testing.T) {
 	for dbType := range backends {
 		t.Run(fmt.Sprintf("%v", dbType), func(t *testing.T) {
@@ -465,6 +587,956 @@ func testDBBatch(t *testing.T, backend BackendType) {
 	require.Error(t, batch.WriteSync())
 }
 
+func TestDBDeleteRange(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBDeleteRange(t, dbType)
+		})
+	}
+}
+
+func testDBDeleteRange(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+
+	require.NoError(t, db.DeleteRange([]byte("b"), []byte("d")))
+	assertKeyValues(t, db, map[string][]byte{"a": []byte("a"), "d": []byte("d"), "e": []byte("e"),
+		"f": []byte("f")})
+
+	require.NoError(t, db.DeleteRange(nil, []byte("b")))
+	require.NoError(t, db.DeleteRange([]byte("e"), nil))
+	assertKeyValues(t, db, map[string][]byte{"d": []byte("d")})
+
+	// empty ranges, and ranges where start is after end, should do nothing
+	require.NoError(t, db.DeleteRange([]byte("d"), []byte("d")))
+	require.NoError(t, db.DeleteRange([]byte("e"), []byte("a")))
+	assertKeyValues(t, db, map[string][]byte{"d": []byte("d")})
+
+	require.Equal(t, tmdb.ErrKeyEmpty, db.DeleteRange([]byte{}, nil))
+	require.Equal(t, tmdb.ErrKeyEmpty, db.DeleteRange(nil, []byte{}))
+
+	// batch range deletes should apply in order with other batch operations
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte("a"), []byte{1}))
+	require.NoError(t, batch.Set([]byte("c"), []byte{3}))
+	require.NoError(t, batch.Set([]byte("z"), []byte{26}))
+	require.NoError(t, batch.DeleteRange([]byte("b"), nil))
+	require.NoError(t, batch.Set([]byte("e"), []byte{5}))
+	require.Equal(t, tmdb.ErrKeyEmpty, batch.DeleteRange(nil, []byte{}))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	assertKeyValues(t, db, map[string][]byte{"a": {1}, "e": {5}})
+
+	batch = db.NewBatch()
+	require.NoError(t, batch.DeleteRange(nil, nil))
+	require.NoError(t, batch.Write())
+	require.Error(t, batch.DeleteRange(nil, nil))
+	require.NoError(t, batch.Close())
+	assertKeyValues(t, db, map[string][]byte{})
+}
+
+func TestDBUpdate(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBUpdate(t, dbType)
+		})
+	}
+}
+
+func testDBUpdate(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	key := []byte("counter")
+	increment := func(value []byte) ([]byte, bool, error) {
+		var n int64
+		if value != nil {
+			n = dbtest.Bytes2Int64(value)
+		}
+		return dbtest.Int642Bytes(n + 1), false, nil
+	}
+
+	// concurrent updates of a key should not be lost
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				assert.NoError(t, db.Update(key, increment))
+			}
+		}()
+	}
+	wg.Wait()
+	value, err := db.Get(key)
+	require.NoError(t, err)
+	require.EqualValues(t, 200, dbtest.Bytes2Int64(value))
+
+	// errors should abort the update
+	errAbort := errors.New("abort")
+	err = db.Update(key, func(value []byte) ([]byte, bool, error) {
+		return []byte{1}, false, errAbort
+	})
+	require.Equal(t, errAbort, err)
+	require.Equal(t, tmdb.ErrValueNil, db.Update(key, func(value []byte) ([]byte, bool, error) {
+		return nil, false, nil
+	}))
+	value, err = db.Get(key)
+	require.NoError(t, err)
+	require.EqualValues(t, 200, dbtest.Bytes2Int64(value))
+
+	require.NoError(t, db.Update(key, func(value []byte) ([]byte, bool, error) {
+		return nil, true, nil
+	}))
+	assertKeyValues(t, db, map[string][]byte{})
+
+	require.NoError(t, db.Update([]byte("missing"), func(value []byte) ([]byte, bool, error) {
+		require.Nil(t, value)
+		return nil, true, nil
+	}))
+	require.Equal(t, tmdb.ErrKeyEmpty, db.Update([]byte{}, increment))
+	require.Equal(t, tmdb.ErrKeyEmpty, db.Update(nil, increment))
+}
+
+func TestDBCompareAndSwap(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBCompareAndSwap(t, dbType)
+		})
+	}
+}
+
+func testDBCompareAndSwap(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	key := []byte("lease")
+	testCases := []struct {
+		expected []byte
+		value    []byte
+		swapped  bool
+		current  []byte
+	}{
+		{[]byte{1}, []byte{2}, false, nil},
+		{[]byte{}, []byte{2}, false, nil},
+		{nil, []byte{1}, true, []byte{1}},
+		{nil, []byte{2}, false, []byte{1}},
+		{[]byte{2}, []byte{3}, false, []byte{1}},
+		{[]byte{1}, []byte{}, true, []byte{}},
+		{nil, []byte{3}, false, []byte{}},
+		{[]byte{}, []byte{3}, true, []byte{3}},
+		{[]byte{1}, nil, false, []byte{3}},
+		{[]byte{3}, nil, true, nil},
+		{nil, nil, true, nil},
+	}
+	for i, tc := range testCases {
+		swapped, err := db.CompareAndSwap(key, tc.expected, tc.value)
+		require.NoError(t, err, "case %v", i)
+		require.Equal(t, tc.swapped, swapped, "case %v", i)
+		current, err := db.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, tc.current, current, "case %v", i)
+	}
+
+	// only one of several concurrent claims should succeed
+	var (
+		wg     sync.WaitGroup
+		mtx    sync.Mutex
+		claims int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			swapped, err := db.CompareAndSwap(key, nil, []byte{byte(i)})
+			assert.NoError(t, err)
+			if swapped {
+				mtx.Lock()
+				claims++
+				mtx.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 1, claims)
+
+	_, err = db.CompareAndSwap([]byte{}, nil, []byte{1})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	_, err = db.CompareAndSwap(nil, nil, []byte{1})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+}
+
+func TestDBGetMany(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBGetMany(t, dbType)
+		})
+	}
+}
+
+func testDBGetMany(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("b"), []byte{2}))
+	require.NoError(t, db.Set([]byte("e"), []byte{}))
+
+	values, err := tmdb.GetMany(db, [][]byte{[]byte("b"), []byte("c"), []byte("a"), []byte("b")})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{2}, nil, {1}, {2}}, values)
+
+	// empty values must be distinguishable from missing keys
+	values, err = tmdb.GetMany(db, [][]byte{[]byte("e")})
+	require.NoError(t, err)
+	require.NotNil(t, values[0])
+	require.Empty(t, values[0])
+
+	values, err = tmdb.GetMany(db, [][]byte{})
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	_, err = tmdb.GetMany(db, [][]byte{[]byte("a"), {}})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	_, err = tmdb.GetMany(db, [][]byte{nil})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+}
+
+func TestDBDump(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBDump(t, dbType)
+		})
+	}
+}
+
+func testDBDump(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("b"), []byte{2, 3}))
+	require.NoError(t, db.Set([]byte("c"), []byte{4}))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, db.Dump(buf, tmdb.DumpOptions{Start: []byte("b"), MaxValueSize: 1}))
+	require.Equal(t, "[62]:\t[02...]\n[63]:\t[04]\n", buf.String())
+
+	require.NoError(t, db.Close())
+	require.Equal(t, tmdb.ErrClosed, db.Dump(buf, tmdb.DumpOptions{}))
+}
+
+func TestDBKeysIterator(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBKeysIterator(t, dbType)
+		})
+	}
+}
+
+func testDBKeysIterator(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, db.Set([]byte(key), []byte{1, 2, 3}))
+	}
+
+	keys := func(itr tmdb.Iterator) []string {
+		defer itr.Close()
+		keys := []string{}
+		for ; itr.Valid(); itr.Next() {
+			keys = append(keys, string(itr.Key()))
+			require.Nil(t, itr.Value())
+		}
+		require.NoError(t, itr.Error())
+		return keys
+	}
+
+	itr, err := tmdb.KeysIterator(db, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c", "d"}, keys(itr))
+
+	itr, err = tmdb.KeysIterator(db, []byte("b"), []byte("d"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, keys(itr))
+
+	itr, err = tmdb.ReverseKeysIterator(db, []byte("b"), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"d", "c", "b"}, keys(itr))
+
+	itr, err = tmdb.KeysIterator(db, nil, nil)
+	require.NoError(t, err)
+	sitr, ok := itr.(tmdb.SeekableIterator)
+	require.True(t, ok)
+	sitr.Seek([]byte("c"))
+	require.Equal(t, []string{"c", "d"}, keys(itr))
+
+	_, err = tmdb.KeysIterator(db, []byte{}, nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	_, err = tmdb.ReverseKeysIterator(db, nil, []byte{})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+}
+
+func TestDBIteratorWithOptions(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBIteratorWithOptions(t, dbType)
+		})
+	}
+}
+
+func testDBIteratorWithOptions(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, db.Set([]byte(key), []byte{byte(i)}))
+	}
+
+	testcases := map[string]struct {
+		opts   tmdb.IteratorOptions
+		keys   []string
+		values [][]byte
+	}{
+		"default":    {tmdb.IteratorOptions{}, []string{"b", "c"}, [][]byte{{1}, {2}}},
+		"reverse":    {tmdb.IteratorOptions{Reverse: true}, []string{"c", "b"}, [][]byte{{2}, {1}}},
+		"keys only":  {tmdb.IteratorOptions{KeysOnly: true}, []string{"b", "c"}, [][]byte{nil, nil}},
+		"fill cache": {tmdb.IteratorOptions{FillCache: true}, []string{"b", "c"}, [][]byte{{1}, {2}}},
+		"reverse keys only": {tmdb.IteratorOptions{Reverse: true, KeysOnly: true},
+			[]string{"c", "b"}, [][]byte{nil, nil}},
+		"prefetch and read-ahead": {tmdb.IteratorOptions{PrefetchSize: 1, ReadAheadSize: 1 << 20},
+			[]string{"b", "c"}, [][]byte{{1}, {2}}},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			itr, err := tmdb.IteratorWithOptions(db, []byte("b"), []byte("d"), tc.opts)
+			require.NoError(t, err)
+			defer itr.Close()
+
+			keys := []string{}
+			values := [][]byte{}
+			for ; itr.Valid(); itr.Next() {
+				keys = append(keys, string(itr.Key()))
+				values = append(values, itr.Value())
+			}
+			require.NoError(t, itr.Error())
+			require.Equal(t, tc.keys, keys)
+			require.Equal(t, tc.values, values)
+		})
+	}
+
+	_, err = tmdb.IteratorWithOptions(db, []byte{}, nil, tmdb.IteratorOptions{})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+}
+
+func TestDBApproximateSize(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBApproximateSize(t, dbType)
+		})
+	}
+}
+
+func testDBApproximateSize(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	batch := db.NewBatch()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, batch.Set([]byte(fmt.Sprintf("key%03d", i)), bytes.Repeat([]byte{byte(i)}, 10)))
+	}
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	// flush writes to disk, so that on-disk backends can measure them
+	if cdb, ok := db.(tmdb.CompactionDB); ok {
+		if err := cdb.Compact(nil, nil); err != tmdb.ErrNotSupported {
+			require.NoError(t, err)
+		}
+	}
+	// Badger flushes its memtables to tables when closed
+	if backend == BadgerDBBackend {
+		require.NoError(t, db.Close())
+		db, err = NewDB(name, backend, dir)
+		require.NoError(t, err)
+	}
+
+	// ranges with fewer keys than the estimate samples are counted exactly
+	count, err := tmdb.ApproximateCount(db, nil, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 100, count)
+	count, err = tmdb.ApproximateCount(db, []byte("key010"), []byte("key020"))
+	require.NoError(t, err)
+	require.EqualValues(t, 10, count)
+	count, err = tmdb.ApproximateCount(db, []byte("key090"), nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, count)
+	count, err = tmdb.ApproximateCount(db, []byte("x"), nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, count)
+
+	size, err := tmdb.ApproximateSize(db, []byte("key010"), []byte("key020"))
+	require.NoError(t, err)
+	total, err := tmdb.ApproximateSize(db, nil, nil)
+	require.NoError(t, err)
+	switch backend {
+	case MemDBBackend, BoltDBBackend:
+		require.EqualValues(t, 10*(6+10), size)
+		require.EqualValues(t, 100*(6+10), total)
+	case "prefixdb":
+		// the size includes the "test/" prefix
+		require.EqualValues(t, 10*(5+6+10), size)
+		require.EqualValues(t, 100*(5+6+10), total)
+	case BadgerDBBackend:
+		// Badger measures whole tables, including key versions and table overhead, so the size of
+		// a range includes the other keys of its tables.
+		require.GreaterOrEqual(t, total, int64(100*(6+10)))
+		require.LessOrEqual(t, total, int64(4*100*(6+10)))
+		require.GreaterOrEqual(t, size, int64(10*(6+10)))
+		require.LessOrEqual(t, size, total)
+	default:
+		require.Greater(t, total, int64(0))
+		require.GreaterOrEqual(t, total, size)
+	}
+
+	_, err = tmdb.ApproximateSize(db, []byte{}, nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	_, err = tmdb.ApproximateCount(db, nil, []byte{})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+}
+
+// descendingComparer orders keys by their first byte in descending order, and then by their bytes.
+type descendingComparer struct{}
+
+func (descendingComparer) Compare(a, b []byte) int {
+	switch {
+	case len(a) == 0 || len(b) == 0 || a[0] == b[0]:
+		return bytes.Compare(a, b)
+	case a[0] > b[0]:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func (descendingComparer) Name() string {
+	return "test.DescendingComparer"
+}
+
+func TestDBComparer(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBComparer(t, dbType)
+		})
+	}
+}
+
+func testDBComparer(t *testing.T, backend BackendType) {
+	if backend == "prefixdb" {
+		// the comparer orders the prefixed keys, which all have the same first byte
+		t.Skip("prefixdb keys are ordered by their bytes")
+	}
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDBWithOptions(name, backend, dir, Options{Comparer: descendingComparer{}})
+	if errors.Is(err, tmdb.ErrNotSupported) {
+		t.Skipf("%v does not support custom comparers", backend)
+	}
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	for _, key := range []string{"a1", "b1", "b2", "c1"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+	keys := func(itr tmdb.Iterator, err error) []string {
+		require.NoError(t, err)
+		defer itr.Close()
+		keys := []string{}
+		for ; itr.Valid(); itr.Next() {
+			keys = append(keys, string(itr.Key()))
+		}
+		require.NoError(t, itr.Error())
+		return keys
+	}
+	require.Equal(t, []string{"c1", "b1", "b2", "a1"}, keys(db.Iterator(nil, nil)))
+	require.Equal(t, []string{"a1", "b2", "b1", "c1"}, keys(db.ReverseIterator(nil, nil)))
+	require.Equal(t, []string{"c1", "b1", "b2"}, keys(db.Iterator([]byte("c"), []byte("a"))))
+	require.Equal(t, []string{"b2", "b1"}, keys(db.ReverseIterator([]byte("b"), []byte("a"))))
+
+	snapshot, err := db.NewSnapshot()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b1", "b2", "a1"}, keys(snapshot.Iterator([]byte("b"), nil)))
+	require.NoError(t, snapshot.Close())
+
+	expect := []string{"b1", "b2", "a1"}
+	if tdb, ok := db.(tmdb.TransactionDB); ok {
+		expect = []string{"d1", "b2", "a1"}
+		txn, err := tdb.NewTransaction()
+		require.NoError(t, err)
+		require.NoError(t, txn.Set([]byte("d1"), []byte("d1")))
+		require.NoError(t, txn.Delete([]byte("b1")))
+		require.Equal(t, []string{"d1", "c1", "b2", "a1"}, keys(txn.Iterator(nil, nil)))
+		require.NoError(t, txn.Commit())
+		require.Equal(t, []string{"d1", "c1", "b2", "a1"}, keys(db.Iterator(nil, nil)))
+	}
+
+	require.NoError(t, db.DeleteRange([]byte("c"), []byte("b")))
+	require.Equal(t, expect, keys(db.Iterator(nil, nil)))
+
+	if backend == GoLevelDBBackend || backend == RocksDBBackend {
+		// on-disk databases can not be reopened with a different comparer
+		require.NoError(t, db.Close())
+		_, err = NewDB(name, backend, dir)
+		require.Error(t, err)
+	}
+}
+
+func TestDBCompact(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBCompact(t, dbType)
+		})
+	}
+}
+
+func testDBCompact(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	cdb, ok := db.(tmdb.CompactionDB)
+	if !ok {
+		t.Skipf("%v does not support compaction", backend)
+	}
+	if err := cdb.Compact(nil, nil); err == tmdb.ErrNotSupported {
+		t.Skipf("%v does not support compaction", backend)
+	}
+
+	batch := db.NewBatch()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, batch.Set([]byte(fmt.Sprintf("key%03d", i)), []byte{byte(i)}))
+	}
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	require.NoError(t, db.DeleteRange([]byte("key001"), []byte("key099")))
+
+	require.NoError(t, cdb.Compact(nil, nil))
+	require.NoError(t, cdb.Compact([]byte("key000"), []byte("key050")))
+	require.NoError(t, cdb.Compact([]byte("key050"), nil))
+	assertKeyValues(t, db, map[string][]byte{"key000": {0}, "key099": {99}})
+
+	require.Equal(t, tmdb.ErrKeyEmpty, cdb.Compact([]byte{}, nil))
+	require.Equal(t, tmdb.ErrKeyEmpty, cdb.Compact(nil, []byte{}))
+
+	// the database should remain usable after compaction
+	require.NoError(t, db.Set([]byte("key050"), []byte{50}))
+	assertKeyValues(t, db, map[string][]byte{"key000": {0}, "key050": {50}, "key099": {99}})
+}
+
+func TestDBCheckpoint(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBCheckpoint(t, dbType)
+		})
+	}
+}
+
+func testDBCheckpoint(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	cdb, ok := db.(tmdb.CheckpointDB)
+	if !ok {
+		t.Skipf("%v does not support checkpoints", backend)
+	}
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("b"), []byte{2}))
+
+	checkpointDir := filepath.Join(dir, fmt.Sprintf("checkpoint_%x", dbtest.RandStr(12)))
+	defer os.RemoveAll(checkpointDir)
+	require.NoError(t, cdb.Checkpoint(checkpointDir))
+	require.Error(t, cdb.Checkpoint(checkpointDir))
+
+	// later writes should not be visible in the checkpoint
+	require.NoError(t, db.Set([]byte("a"), []byte{3}))
+	require.NoError(t, db.Delete([]byte("b")))
+	require.NoError(t, db.Set([]byte("c"), []byte{4}))
+
+	checkpoint, err := NewDB(name, backend, checkpointDir)
+	require.NoError(t, err)
+	defer checkpoint.Close()
+	assertKeyValues(t, checkpoint, map[string][]byte{"a": {1}, "b": {2}})
+	assertKeyValues(t, db, map[string][]byte{"a": {3}, "c": {4}})
+
+	// checkpoints of read-only databases should be writable
+	require.NoError(t, db.Close())
+	db, err = NewDBWithOptions(name, backend, dir, Options{ReadOnly: true})
+	if errors.Is(err, tmdb.ErrNotSupported) {
+		return
+	}
+	require.NoError(t, err)
+	defer db.Close()
+	readOnlyDir := filepath.Join(dir, fmt.Sprintf("checkpoint_%x", dbtest.RandStr(12)))
+	defer os.RemoveAll(readOnlyDir)
+	require.NoError(t, db.(tmdb.CheckpointDB).Checkpoint(readOnlyDir))
+
+	readOnly, err := NewDB(name, backend, readOnlyDir)
+	require.NoError(t, err)
+	defer readOnly.Close()
+	assertKeyValues(t, readOnly, map[string][]byte{"a": {3}, "c": {4}})
+	require.NoError(t, readOnly.Set([]byte("d"), []byte{5}))
+}
+
+func TestDBSetWithTTL(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBSetWithTTL(t, dbType)
+		})
+	}
+}
+
+func testDBSetWithTTL(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	tdb, ok := db.(tmdb.TTLDB)
+	if !ok {
+		t.Skipf("%v does not support TTLs", backend)
+	}
+	if err := tdb.SetWithTTL([]byte("a"), []byte{1}, time.Hour); err == tmdb.ErrNotSupported {
+		t.Skipf("%v does not support TTLs", backend)
+	}
+
+	require.NoError(t, tdb.SetWithTTL([]byte("b"), []byte{2}, time.Second))
+	require.NoError(t, db.Set([]byte("c"), []byte{3}))
+	assertKeyValues(t, db, map[string][]byte{"a": {1}, "b": {2}, "c": {3}})
+
+	require.Equal(t, tmdb.ErrKeyEmpty, tdb.SetWithTTL([]byte{}, []byte{1}, time.Second))
+	require.Equal(t, tmdb.ErrValueNil, tdb.SetWithTTL([]byte("d"), nil, time.Second))
+	require.Error(t, tdb.SetWithTTL([]byte("d"), []byte{4}, 0))
+
+	// backends may expire keys with a granularity of one second
+	time.Sleep(2 * time.Second)
+	assertKeyValues(t, db, map[string][]byte{"a": {1}, "c": {3}})
+}
+
+func TestDBSnapshot(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBSnapshot(t, dbType)
+		})
+	}
+}
+
+func testDBSnapshot(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("b"), []byte{2}))
+
+	snapshot, err := db.NewSnapshot()
+	require.NoError(t, err)
+
+	// writes are allowed while iterating over a snapshot, and should not be visible through it
+	write := func() {
+		require.NoError(t, db.Set([]byte("a"), []byte{9}))
+		require.NoError(t, db.Delete([]byte("b")))
+		require.NoError(t, db.Set([]byte("c"), []byte{3}))
+	}
+	itr, err := snapshot.Iterator(nil, nil)
+	require.NoError(t, err)
+	// BoltDB writes which grow the database file block until all snapshots are closed.
+	if backend != BoltDBBackend {
+		write()
+	}
+	actual := make(map[string][]byte)
+	for ; itr.Valid(); itr.Next() {
+		actual[string(itr.Key())] = itr.Value()
+	}
+	require.NoError(t, itr.Error())
+	require.NoError(t, itr.Close())
+	assert.Equal(t, map[string][]byte{"a": {1}, "b": {2}}, actual)
+
+	value, err := snapshot.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+	value, err = snapshot.Get([]byte("c"))
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	ok, err := snapshot.Has([]byte("b"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = snapshot.Has([]byte("c"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ritr, err := snapshot.ReverseIterator(nil, nil)
+	require.NoError(t, err)
+	var keys []string
+	for ; ritr.Valid(); ritr.Next() {
+		keys = append(keys, string(ritr.Key()))
+	}
+	require.NoError(t, ritr.Close())
+	assert.Equal(t, []string{"b", "a"}, keys)
+
+	_, err = snapshot.Get(nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	_, err = snapshot.Iterator([]byte{}, nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	require.NoError(t, snapshot.Close())
+	if backend == BoltDBBackend {
+		write()
+	}
+
+	// the database itself should see the new writes
+	assertKeyValues(t, db, map[string][]byte{"a": {9}, "c": {3}})
+}
+
+func TestDBTransaction(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBTransaction(t, dbType)
+		})
+	}
+}
+
+func testDBTransaction(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	tdb, ok := db.(tmdb.TransactionDB)
+	if !ok {
+		t.Skipf("%v does not support transactions", backend)
+	}
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("b"), []byte{2}))
+
+	// reads should see the transaction's own writes, but other readers should not
+	txn, err := tdb.NewTransaction()
+	require.NoError(t, err)
+	value, err := txn.Get([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, txn.Set([]byte("a"), append(value, 1)))
+	require.NoError(t, txn.Delete([]byte("b")))
+	require.NoError(t, txn.Set([]byte("c"), []byte{3}))
+
+	value, err = txn.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 1}, value)
+	ok, err = txn.Has([]byte("b"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	itr, err := txn.Iterator(nil, nil)
+	require.NoError(t, err)
+	actual := make(map[string][]byte)
+	for ; itr.Valid(); itr.Next() {
+		actual[string(itr.Key())] = itr.Value()
+	}
+	require.NoError(t, itr.Error())
+	require.NoError(t, itr.Close())
+	assert.Equal(t, map[string][]byte{"a": {1, 1}, "c": {3}}, actual)
+
+	ritr, err := txn.ReverseIterator(nil, nil)
+	require.NoError(t, err)
+	var keys []string
+	for ; ritr.Valid(); ritr.Next() {
+		keys = append(keys, string(ritr.Key()))
+	}
+	require.NoError(t, ritr.Close())
+	assert.Equal(t, []string{"c", "a"}, keys)
+
+	_, err = txn.Get(nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	require.Equal(t, tmdb.ErrValueNil, txn.Set([]byte("x"), nil))
+
+	assertKeyValues(t, db, map[string][]byte{"a": {1}, "b": {2}})
+	require.NoError(t, txn.Commit())
+	require.NoError(t, txn.Close())
+	assertKeyValues(t, db, map[string][]byte{"a": {1, 1}, "c": {3}})
+
+	_, err = txn.Get([]byte("a"))
+	require.Equal(t, tmdb.ErrTransactionClosed, err)
+	require.Equal(t, tmdb.ErrTransactionClosed, txn.Commit())
+
+	// a concurrent write to a key read by the transaction should cause a conflict
+	txn, err = tdb.NewTransaction()
+	require.NoError(t, err)
+	value, err = txn.Get([]byte("c"))
+	require.NoError(t, err)
+	require.NoError(t, txn.Set([]byte("d"), value))
+	require.NoError(t, db.Set([]byte("c"), []byte{4}))
+	require.Equal(t, tmdb.ErrConflict, txn.Commit())
+	require.NoError(t, txn.Close())
+	assertKeyValues(t, db, map[string][]byte{"a": {1, 1}, "c": {4}})
+
+	// a concurrent write to a key which was not read should not cause a conflict
+	txn, err = tdb.NewTransaction()
+	require.NoError(t, err)
+	_, err = txn.Get([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, txn.Set([]byte("e"), []byte{5}))
+	require.NoError(t, db.Set([]byte("c"), []byte{6}))
+	require.NoError(t, txn.Commit())
+	require.NoError(t, txn.Close())
+	assertKeyValues(t, db, map[string][]byte{"a": {1, 1}, "c": {6}, "e": {5}})
+
+	// closing a transaction should discard it
+	txn, err = tdb.NewTransaction()
+	require.NoError(t, err)
+	require.NoError(t, txn.Set([]byte("f"), []byte{7}))
+	require.NoError(t, txn.Close())
+	require.NoError(t, txn.Close())
+	assertKeyValues(t, db, map[string][]byte{"a": {1, 1}, "c": {6}, "e": {5}})
+}
+
+func TestDBStats(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBStats(t, dbType)
+		})
+	}
+}
+
+func testDBStats(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, db.Set([]byte{byte(i)}, []byte{1, 2, 3}))
+	}
+	require.NoError(t, db.Set([]byte{0}, []byte{1}))
+	require.NoError(t, db.Delete([]byte{1}))
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	switch backend {
+	case "prefixdb":
+		assert.Equal(t, "memdb", stats.Backend)
+		assert.Equal(t, "test/", stats.Properties["prefixdb.prefix.string"])
+	case "cachedb":
+		assert.Equal(t, "memdb", stats.Backend)
+		assert.Equal(t, "0", stats.Properties["cachedb.hits"])
+	case "metricsdb", "logdb", "encdb":
+		assert.Equal(t, "memdb", stats.Backend)
+	case "compressdb":
+		assert.Equal(t, "memdb", stats.Backend)
+		assert.NotEmpty(t, stats.Properties["compressdb.stored_bytes"])
+	default:
+		assert.Equal(t, string(backend), stats.Backend)
+	}
+	switch backend {
+	case MemDBBackend:
+		assert.EqualValues(t, 9, stats.KeyCount)
+		assert.EqualValues(t, 34, stats.MemTableSize)
+	case BoltDBBackend:
+		assert.EqualValues(t, 9, stats.KeyCount)
+		assert.NotZero(t, stats.DiskSize)
+	}
+	for _, value := range []int64{
+		stats.KeyCount, stats.DiskSize, stats.MemTableSize, stats.CacheSize, stats.OpenIterators,
+		stats.Compactions,
+	} {
+		assert.GreaterOrEqual(t, value, int64(0))
+	}
+
+	require.NoError(t, db.Close())
+	_, err = db.Stats()
+	require.Equal(t, tmdb.ErrClosed, err)
+}
+
+func TestDBClosed(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBClosed(t, dbType)
+		})
+	}
+}
+
+func testDBClosed(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	batch := db.NewBatch()
+	defer batch.Close()
+	require.NoError(t, batch.Set([]byte("b"), []byte{2}))
+	require.NoError(t, db.Close())
+
+	_, err = db.Get([]byte("a"))
+	require.Equal(t, tmdb.ErrClosed, err)
+	_, err = db.Has([]byte("a"))
+	require.Equal(t, tmdb.ErrClosed, err)
+	require.Equal(t, tmdb.ErrClosed, db.Set([]byte("a"), []byte{2}))
+	require.Equal(t, tmdb.ErrClosed, db.SetSync([]byte("a"), []byte{2}))
+	require.Equal(t, tmdb.ErrClosed, db.Delete([]byte("a")))
+	require.Equal(t, tmdb.ErrClosed, db.DeleteSync([]byte("a")))
+	require.Equal(t, tmdb.ErrClosed, db.DeleteRange(nil, nil))
+	_, err = db.CompareAndSwap([]byte("a"), []byte{1}, []byte{2})
+	require.Equal(t, tmdb.ErrClosed, err)
+	_, err = db.Iterator(nil, nil)
+	require.Equal(t, tmdb.ErrClosed, err)
+	_, err = db.ReverseIterator(nil, nil)
+	require.Equal(t, tmdb.ErrClosed, err)
+	_, err = db.NewSnapshot()
+	require.Equal(t, tmdb.ErrClosed, err)
+	require.Equal(t, tmdb.ErrClosed, batch.Write())
+	require.Equal(t, tmdb.ErrClosed, db.Close())
+}
+
 func assertKeyValues(t *testing.T, db tmdb.DB, expect map[string][]byte) {
 	iter, err := db.Iterator(nil, nil)
 	require.NoError(t, err)
@@ -478,3 +1550,99 @@ func assertKeyValues(t *testing.T, db tmdb.DB, expect map[string][]byte) {
 
 	assert.Equal(t, expect, actual)
 }
+
+func TestDBReadOnly(t *testing.T) {
+	for dbType := range backends {
+		t.Run(string(dbType), func(t *testing.T) {
+			testDBReadOnly(t, dbType)
+		})
+	}
+}
+
+func testDBReadOnly(t *testing.T, backend BackendType) {
+	name := fmt.Sprintf("test_%x", dbtest.RandStr(12))
+	dir := os.TempDir()
+	db, err := NewDB(name, backend, dir)
+	require.NoError(t, err)
+	defer dbtest.CleanupDBDir(dir, name)
+	require.NoError(t, db.Set([]byte("a"), []byte{1}))
+	require.NoError(t, db.Set([]byte("b"), []byte{2}))
+	require.NoError(t, db.Close())
+	files := hashFiles(t, dir, name)
+
+	db, err = NewDBWithOptions(name, backend, dir, Options{ReadOnly: true})
+	if errors.Is(err, tmdb.ErrNotSupported) {
+		t.Skipf("%v does not support read-only mode", backend)
+	}
+	require.NoError(t, err)
+
+	value, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, value)
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	dbtest.Item(t, itr, []byte("a"), []byte{1})
+	require.NoError(t, itr.Close())
+
+	require.Equal(t, tmdb.ErrReadOnly, db.Set([]byte("a"), []byte{3}))
+	require.Equal(t, tmdb.ErrReadOnly, db.SetSync([]byte("c"), []byte{3}))
+	require.Equal(t, tmdb.ErrReadOnly, db.Delete([]byte("a")))
+	require.Equal(t, tmdb.ErrReadOnly, db.DeleteSync([]byte("a")))
+	require.Equal(t, tmdb.ErrReadOnly, db.DeleteRange(nil, nil))
+	require.Equal(t, tmdb.ErrReadOnly, db.Update([]byte("a"), func(value []byte) ([]byte, bool, error) {
+		return []byte{3}, false, nil
+	}))
+	_, err = db.CompareAndSwap([]byte("a"), []byte{1}, []byte{3})
+	require.Equal(t, tmdb.ErrReadOnly, err)
+
+	// batches may fail when adding writes, or when written
+	batch := db.NewBatch()
+	err = batch.Set([]byte("c"), []byte{3})
+	if err == nil {
+		err = batch.Write()
+	}
+	require.Equal(t, tmdb.ErrReadOnly, err)
+	require.NoError(t, batch.Close())
+
+	if cdb, ok := db.(tmdb.CompactionDB); ok {
+		require.Equal(t, tmdb.ErrReadOnly, cdb.Compact(nil, nil))
+	}
+	if tdb, ok := db.(tmdb.TransactionDB); ok {
+		_, err = tdb.NewTransaction()
+		require.Equal(t, tmdb.ErrReadOnly, err)
+	}
+	require.NoError(t, db.Close())
+	require.Equal(t, files, hashFiles(t, dir, name))
+
+	// the database can still be opened for writing
+	db, err = NewDB(name, backend, dir)
+	require.NoError(t, err)
+	value, err = db.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, value)
+	require.NoError(t, db.Set([]byte("c"), []byte{3}))
+	require.NoError(t, db.Close())
+}
+
+// hashFiles returns the SHA-256 hashes of the files of a database, by path.
+func hashFiles(t *testing.T, dir, name string) map[string][]byte {
+	paths, err := filepath.Glob(filepath.Join(dir, name+"*"))
+	require.NoError(t, err)
+	hashes := make(map[string][]byte)
+	for _, path := range paths {
+		err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			hash := sha256.Sum256(data)
+			hashes[path] = hash[:]
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	return hashes
+}
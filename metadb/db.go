@@ -43,7 +43,20 @@ const (
 	BadgerDBBackend BackendType = "badgerdb"
 )
 
-type dbCreator func(name string, dir string) (tmdb.DB, error)
+// Options configures a database opened with NewDBWithOptions.
+type Options struct {
+	// Comparer orders keys, if not nil. MemDB, GoLevelDB and RocksDB support custom comparers,
+	// while other backends only support byte order and fail to open with ErrNotSupported. The
+	// database must always be opened with a comparer of the same name.
+	Comparer tmdb.Comparer
+	// ReadOnly opens an existing database without modifying its files, such that all writes fail
+	// with ErrReadOnly. LevelDB's C library can not open databases without modifying them, so
+	// CLevelDB databases are opened with GoLevelDB, which reads the same format. MemDB does not
+	// support it, and fails with ErrNotSupported.
+	ReadOnly bool
+}
+
+type dbCreator func(name string, dir string, opts Options) (tmdb.DB, error)
 
 var backends = map[BackendType]dbCreator{}
 
@@ -57,6 +70,11 @@ func registerDBCreator(backend BackendType, creator dbCreator, force bool) {
 
 // NewDB creates a new database of type backend with the given name.
 func NewDB(name string, backend BackendType, dir string) (tmdb.DB, error) {
+	return NewDBWithOptions(name, backend, dir, Options{})
+}
+
+// NewDBWithOptions creates a new database of type backend with the given name and options.
+func NewDBWithOptions(name string, backend BackendType, dir string, opts Options) (tmdb.DB, error) {
 	dbCreator, ok := backends[backend]
 	if !ok {
 		keys := make([]string, 0, len(backends))
@@ -67,9 +85,27 @@ func NewDB(name string, backend BackendType, dir string) (tmdb.DB, error) {
 			backend, strings.Join(keys, ","))
 	}
 
-	db, err := dbCreator(name, dir)
+	db, err := dbCreator(name, dir, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 	return db, nil
 }
+
+// requireBytesComparer returns ErrNotSupported if the options have a comparer other than byte
+// order, for backends which do not support custom comparers.
+func requireBytesComparer(backend BackendType, opts Options) error {
+	if !tmdb.IsBytesComparer(opts.Comparer) {
+		return fmt.Errorf("%v does not support custom comparers: %w", backend, tmdb.ErrNotSupported)
+	}
+	return nil
+}
+
+// requireWritable returns ErrNotSupported if the options are read-only, for backends which can
+// not be opened read-only.
+func requireWritable(backend BackendType, opts Options) error {
+	if opts.ReadOnly {
+		return fmt.Errorf("%v does not support read-only mode: %w", backend, tmdb.ErrNotSupported)
+	}
+	return nil
+}
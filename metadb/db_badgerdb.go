@@ -7,7 +7,13 @@ import (
 	"github.com/tendermint/tm-db/badgerdb"
 )
 
-func badgerDBCreator(name, dir string) (tmdb.DB, error) {
+func badgerDBCreator(name, dir string, opts Options) (tmdb.DB, error) {
+	if err := requireBytesComparer(BadgerDBBackend, opts); err != nil {
+		return nil, err
+	}
+	if opts.ReadOnly {
+		return badgerdb.NewReadOnlyDB(name, dir)
+	}
 	return badgerdb.NewDB(name, dir)
 }
 
@@ -7,7 +7,13 @@ import (
 	"github.com/tendermint/tm-db/boltdb"
 )
 
-func boltDBCreator(name, dir string) (tmdb.DB, error) {
+func boltDBCreator(name, dir string, opts Options) (tmdb.DB, error) {
+	if err := requireBytesComparer(BoltDBBackend, opts); err != nil {
+		return nil, err
+	}
+	if opts.ReadOnly {
+		return boltdb.NewReadOnlyDB(name, dir)
+	}
 	return boltdb.NewDB(name, dir)
 }
 
@@ -5,9 +5,16 @@ package metadb
 import (
 	tmdb "github.com/tendermint/tm-db"
 	"github.com/tendermint/tm-db/cleveldb"
+	"github.com/tendermint/tm-db/goleveldb"
 )
 
-func clevelDBCreator(name string, dir string) (tmdb.DB, error) {
+func clevelDBCreator(name string, dir string, opts Options) (tmdb.DB, error) {
+	if err := requireBytesComparer(CLevelDBBackend, opts); err != nil {
+		return nil, err
+	}
+	if opts.ReadOnly {
+		return goleveldb.NewReadOnlyDB(name, dir, nil)
+	}
 	return cleveldb.NewDB(name, dir)
 }
 
@@ -7,8 +7,11 @@ import (
 	"github.com/tendermint/tm-db/goleveldb"
 )
 
-func golevelDBCreator(name, dir string) (tmdb.DB, error) {
-	return goleveldb.NewDB(name, dir)
+func golevelDBCreator(name, dir string, opts Options) (tmdb.DB, error) {
+	if opts.ReadOnly {
+		return goleveldb.NewReadOnlyDB(name, dir, opts.Comparer)
+	}
+	return goleveldb.NewDBWithComparer(name, dir, opts.Comparer)
 }
 
 func init() { registerDBCreator(GoLevelDBBackend, golevelDBCreator, true) }
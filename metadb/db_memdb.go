@@ -7,8 +7,11 @@ import (
 	"github.com/tendermint/tm-db/memdb"
 )
 
-func memdbDBCreator(name, dir string) (tmdb.DB, error) {
-	return memdb.NewDB(), nil
+func memdbDBCreator(name, dir string, opts Options) (tmdb.DB, error) {
+	if err := requireWritable(MemDBBackend, opts); err != nil {
+		return nil, err
+	}
+	return memdb.NewDBWithComparer(opts.Comparer), nil
 }
 
 func init() { registerDBCreator(MemDBBackend, memdbDBCreator, false) }
@@ -7,8 +7,11 @@ import (
 	"github.com/tendermint/tm-db/rocksdb"
 )
 
-func rocksDBCreator(name, dir string) (tmdb.DB, error) {
-	return rocksdb.NewDB(name, dir)
+func rocksDBCreator(name, dir string, opts Options) (tmdb.DB, error) {
+	if opts.ReadOnly {
+		return rocksdb.NewReadOnlyDB(name, dir, nil, opts.Comparer)
+	}
+	return rocksdb.NewDBWithComparer(name, dir, nil, opts.Comparer)
 }
 
 func init() { registerDBCreator(RocksDBBackend, rocksDBCreator, true) }
@@ -0,0 +1,77 @@
+package metricsdb
+
+import (
+	"time"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// metricsDBBatch records batch operations, and the size of the batch once it is written.
+type metricsDBBatch struct {
+	metrics    *dbMetrics
+	source     tmdb.Batch
+	operations int
+	size       int
+}
+
+var _ tmdb.Batch = (*metricsDBBatch)(nil)
+
+func newMetricsDBBatch(metrics *dbMetrics, source tmdb.Batch) *metricsDBBatch {
+	return &metricsDBBatch{metrics: metrics, source: source}
+}
+
+// Set implements Batch.
+func (b *metricsDBBatch) Set(key, value []byte) (err error) {
+	defer b.metrics.observe("batch_set", time.Now(), &err)
+	return b.add(b.source.Set(key, value), len(key)+len(value))
+}
+
+// Delete implements Batch.
+func (b *metricsDBBatch) Delete(key []byte) (err error) {
+	defer b.metrics.observe("batch_delete", time.Now(), &err)
+	return b.add(b.source.Delete(key), len(key))
+}
+
+// DeleteRange implements Batch.
+func (b *metricsDBBatch) DeleteRange(start, end []byte) (err error) {
+	defer b.metrics.observe("batch_delete_range", time.Now(), &err)
+	return b.add(b.source.DeleteRange(start, end), 0)
+}
+
+// add records an operation added to the batch, unless it failed.
+func (b *metricsDBBatch) add(err error, n int) error {
+	if err == nil {
+		b.operations++
+		b.size += n
+	}
+	return err
+}
+
+// Write implements Batch.
+func (b *metricsDBBatch) Write() (err error) {
+	defer b.metrics.observe("batch_write", time.Now(), &err)
+	return b.write(b.source.Write())
+}
+
+// WriteSync implements Batch.
+func (b *metricsDBBatch) WriteSync() (err error) {
+	defer b.metrics.observe("batch_write_sync", time.Now(), &err)
+	return b.write(b.source.WriteSync())
+}
+
+// write records the written batch, unless the write failed.
+func (b *metricsDBBatch) write(err error) error {
+	if err != nil {
+		return err
+	}
+	b.metrics.batchWritten(b.operations, b.size)
+	b.operations = 0
+	b.size = 0
+	return nil
+}
+
+// Close implements Batch.
+func (b *metricsDBBatch) Close() (err error) {
+	defer b.metrics.observe("batch_close", time.Now(), &err)
+	return b.source.Close()
+}
@@ -0,0 +1,220 @@
+// Package metricsdb provides a database wrapper which records metrics about database, batch and
+// iterator operations, and serves them in the Prometheus text exposition format.
+package metricsdb
+
+import (
+	"io"
+	"time"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// MetricsDB wraps a database to record the count, errors and latency of every database, batch and
+// iterator operation, the number of bytes read and written, the sizes of written batches, and the
+// number and lifetimes of iterators. The metrics are recorded in a Metrics registry, labelled
+// with the backend and name given when wrapping the database.
+//
+// Bytes read are the keys and values returned by reads and iterators, and bytes written are the
+// keys and values of writes, where deletes only count their keys. Snapshots are not instrumented,
+// and MetricsDB does not implement TransactionDB.
+type MetricsDB struct {
+	source  tmdb.DB
+	metrics *dbMetrics
+}
+
+var (
+	_ tmdb.MultiGetDB        = (*MetricsDB)(nil)
+	_ tmdb.KeysIteratorDB    = (*MetricsDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*MetricsDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*MetricsDB)(nil)
+	_ tmdb.ComparerDB        = (*MetricsDB)(nil)
+)
+
+// NewDB wraps a database, recording its metrics in the given registry with the given backend and
+// name labels.
+func NewDB(db tmdb.DB, metrics *Metrics, backend, name string) *MetricsDB {
+	return &MetricsDB{
+		source:  db,
+		metrics: metrics.db(backend, name),
+	}
+}
+
+// Get implements DB.
+func (db *MetricsDB) Get(key []byte) (value []byte, err error) {
+	defer db.metrics.observe("get", time.Now(), &err)
+	value, err = db.source.Get(key)
+	if err == nil {
+		db.metrics.read(len(value))
+	}
+	return value, err
+}
+
+// Has implements DB.
+func (db *MetricsDB) Has(key []byte) (exists bool, err error) {
+	defer db.metrics.observe("has", time.Now(), &err)
+	return db.source.Has(key)
+}
+
+// GetMany implements MultiGetDB.
+func (db *MetricsDB) GetMany(keys [][]byte) (values [][]byte, err error) {
+	defer db.metrics.observe("get_many", time.Now(), &err)
+	values, err = tmdb.GetMany(db.source, keys)
+	if err == nil {
+		n := 0
+		for _, value := range values {
+			n += len(value)
+		}
+		db.metrics.read(n)
+	}
+	return values, err
+}
+
+// Iterator implements DB.
+func (db *MetricsDB) Iterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	defer db.metrics.observe("iterator", time.Now(), &err)
+	return db.newIterator(db.source.Iterator(start, end))
+}
+
+// ReverseIterator implements DB.
+func (db *MetricsDB) ReverseIterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	defer db.metrics.observe("reverse_iterator", time.Now(), &err)
+	return db.newIterator(db.source.ReverseIterator(start, end))
+}
+
+// KeysIterator implements KeysIteratorDB.
+func (db *MetricsDB) KeysIterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	defer db.metrics.observe("keys_iterator", time.Now(), &err)
+	return db.newIterator(tmdb.KeysIterator(db.source, start, end))
+}
+
+// ReverseKeysIterator implements KeysIteratorDB.
+func (db *MetricsDB) ReverseKeysIterator(start, end []byte) (itr tmdb.Iterator, err error) {
+	defer db.metrics.observe("reverse_keys_iterator", time.Now(), &err)
+	return db.newIterator(tmdb.ReverseKeysIterator(db.source, start, end))
+}
+
+// IteratorWithOptions implements IteratorOptionsDB.
+func (db *MetricsDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (itr tmdb.Iterator, err error) {
+	defer db.metrics.observe("iterator_with_options", time.Now(), &err)
+	return db.newIterator(tmdb.IteratorWithOptions(db.source, start, end, opts))
+}
+
+// newIterator wraps an iterator returned by the wrapped database.
+func (db *MetricsDB) newIterator(source tmdb.Iterator, err error) (tmdb.Iterator, error) {
+	if err != nil {
+		return nil, err
+	}
+	return newMetricsDBIterator(db.metrics, source), nil
+}
+
+// Comparer implements ComparerDB.
+func (db *MetricsDB) Comparer() tmdb.Comparer {
+	return tmdb.KeyComparer(db.source)
+}
+
+// ApproximateSize implements ApproximateSizeDB.
+func (db *MetricsDB) ApproximateSize(start, end []byte) (size int64, err error) {
+	defer db.metrics.observe("approximate_size", time.Now(), &err)
+	return tmdb.ApproximateSize(db.source, start, end)
+}
+
+// ApproximateCount implements ApproximateSizeDB.
+func (db *MetricsDB) ApproximateCount(start, end []byte) (count int64, err error) {
+	defer db.metrics.observe("approximate_count", time.Now(), &err)
+	return tmdb.ApproximateCount(db.source, start, end)
+}
+
+// Set implements DB.
+func (db *MetricsDB) Set(key, value []byte) (err error) {
+	defer db.metrics.observe("set", time.Now(), &err)
+	return db.write(db.source.Set(key, value), len(key)+len(value))
+}
+
+// SetSync implements DB.
+func (db *MetricsDB) SetSync(key, value []byte) (err error) {
+	defer db.metrics.observe("set_sync", time.Now(), &err)
+	return db.write(db.source.SetSync(key, value), len(key)+len(value))
+}
+
+// Delete implements DB.
+func (db *MetricsDB) Delete(key []byte) (err error) {
+	defer db.metrics.observe("delete", time.Now(), &err)
+	return db.write(db.source.Delete(key), len(key))
+}
+
+// DeleteSync implements DB.
+func (db *MetricsDB) DeleteSync(key []byte) (err error) {
+	defer db.metrics.observe("delete_sync", time.Now(), &err)
+	return db.write(db.source.DeleteSync(key), len(key))
+}
+
+// write records the bytes written by a write, unless it failed.
+func (db *MetricsDB) write(err error, n int) error {
+	if err == nil {
+		db.metrics.written(n)
+	}
+	return err
+}
+
+// DeleteRange implements DB.
+func (db *MetricsDB) DeleteRange(start, end []byte) (err error) {
+	defer db.metrics.observe("delete_range", time.Now(), &err)
+	return db.source.DeleteRange(start, end)
+}
+
+// Update implements DB. The bytes read and written are those of the last call to the update
+// function, which may be retried by the wrapped database.
+func (db *MetricsDB) Update(key []byte, fn tmdb.UpdateFunc) (err error) {
+	defer db.metrics.observe("update", time.Now(), &err)
+	var read, written int
+	err = db.source.Update(key, func(value []byte) ([]byte, bool, error) {
+		newValue, del, err := fn(value)
+		read, written = len(value), len(key)+len(newValue)
+		return newValue, del, err
+	})
+	if err == nil {
+		db.metrics.read(read)
+		db.metrics.written(written)
+	}
+	return err
+}
+
+// CompareAndSwap implements DB.
+func (db *MetricsDB) CompareAndSwap(key, expected, value []byte) (swapped bool, err error) {
+	defer db.metrics.observe("compare_and_swap", time.Now(), &err)
+	swapped, err = db.source.CompareAndSwap(key, expected, value)
+	if swapped {
+		db.metrics.written(len(key) + len(value))
+	}
+	return swapped, err
+}
+
+// NewSnapshot implements DB. The snapshot is not instrumented.
+func (db *MetricsDB) NewSnapshot() (snapshot tmdb.Snapshot, err error) {
+	defer db.metrics.observe("new_snapshot", time.Now(), &err)
+	return db.source.NewSnapshot()
+}
+
+// NewBatch implements DB.
+func (db *MetricsDB) NewBatch() tmdb.Batch {
+	defer db.metrics.observe("new_batch", time.Now(), nil)
+	return newMetricsDBBatch(db.metrics, db.source.NewBatch())
+}
+
+// Close implements DB.
+func (db *MetricsDB) Close() (err error) {
+	defer db.metrics.observe("close", time.Now(), &err)
+	return db.source.Close()
+}
+
+// Dump implements DB.
+func (db *MetricsDB) Dump(w io.Writer, opts tmdb.DumpOptions) (err error) {
+	defer db.metrics.observe("dump", time.Now(), &err)
+	return db.source.Dump(w, opts)
+}
+
+// Stats implements DB.
+func (db *MetricsDB) Stats() (stats *tmdb.Stats, err error) {
+	defer db.metrics.observe("stats", time.Now(), &err)
+	return db.source.Stats()
+}
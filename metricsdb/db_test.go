@@ -0,0 +1,97 @@
+package metricsdb
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+func TestMetricsDB(t *testing.T) {
+	metrics := NewMetrics()
+	db := NewDB(memdb.NewDB(), metrics, "memdb", "test")
+
+	require.NoError(t, db.Set([]byte("a"), []byte{1, 2}))
+	require.NoError(t, db.Delete([]byte("b")))
+	value, err := db.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2}, value)
+	_, err = db.Get(nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte("c"), []byte{3}))
+	require.NoError(t, batch.Set([]byte("d"), []byte{4}))
+	require.NoError(t, batch.Delete([]byte("a")))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	for ; itr.Valid(); itr.Next() {
+		itr.Key()
+		itr.Value()
+	}
+	require.NoError(t, itr.Error())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, metrics.Write(buf))
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE tmdb_operations_total counter",
+		`tmdb_operations_total{backend="memdb",name="test",op="get"} 2`,
+		`tmdb_operations_total{backend="memdb",name="test",op="batch_set"} 2`,
+		`tmdb_operations_total{backend="memdb",name="test",op="iterator_next"} 2`,
+		`tmdb_operation_errors_total{backend="memdb",name="test",op="get"} 1`,
+		`tmdb_operation_errors_total{backend="memdb",name="test",op="set"} 0`,
+		`tmdb_operation_duration_seconds_count{backend="memdb",name="test",op="set"} 1`,
+		`tmdb_operation_duration_seconds_bucket{backend="memdb",name="test",op="set",le="+Inf"} 1`,
+		`tmdb_read_bytes_total{backend="memdb",name="test"} 6`,
+		`tmdb_written_bytes_total{backend="memdb",name="test"} 9`,
+		`tmdb_batch_operations_bucket{backend="memdb",name="test",le="1"} 0`,
+		`tmdb_batch_operations_bucket{backend="memdb",name="test",le="10"} 1`,
+		`tmdb_batch_operations_sum{backend="memdb",name="test"} 3`,
+		`tmdb_batch_bytes_sum{backend="memdb",name="test"} 5`,
+		`tmdb_open_iterators{backend="memdb",name="test"} 1`,
+		`tmdb_iterator_duration_seconds_count{backend="memdb",name="test"} 0`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+
+	// closing the iterator twice only records its lifetime once
+	require.NoError(t, itr.Close())
+	require.NoError(t, itr.Close())
+	buf.Reset()
+	require.NoError(t, metrics.Write(buf))
+	out = buf.String()
+	assert.Contains(t, out, `tmdb_open_iterators{backend="memdb",name="test"} 0`+"\n")
+	assert.Contains(t, out, `tmdb_iterator_duration_seconds_count{backend="memdb",name="test"} 1`+"\n")
+	assert.Contains(t, out, `tmdb_operations_total{backend="memdb",name="test",op="iterator_close"} 2`+"\n")
+}
+
+func TestMetricsHTTP(t *testing.T) {
+	metrics := NewMetrics()
+	a := NewDB(memdb.NewDB(), metrics, "memdb", "b")
+	b := NewDB(memdb.NewDB(), metrics, "memdb", "a \"quoted\"\nname")
+	require.NoError(t, a.Set([]byte("a"), []byte{1}))
+	require.NoError(t, b.Set([]byte("a"), []byte{1}))
+
+	// reopening a database shares its metrics
+	a = NewDB(memdb.NewDB(), metrics, "memdb", "b")
+	require.NoError(t, a.Set([]byte("a"), []byte{1}))
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), ""+
+		"# HELP tmdb_written_bytes_total Number of key and value bytes written, including batches.\n"+
+		"# TYPE tmdb_written_bytes_total counter\n"+
+		`tmdb_written_bytes_total{backend="memdb",name="a \"quoted\"\nname"} 2`+"\n"+
+		`tmdb_written_bytes_total{backend="memdb",name="b"} 4`+"\n")
+}
@@ -0,0 +1,92 @@
+package metricsdb
+
+import (
+	"time"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// metricsDBIterator records iterator operations, the bytes returned and the iterator lifetime.
+type metricsDBIterator struct {
+	metrics *dbMetrics
+	source  tmdb.Iterator
+	opened  time.Time
+	closed  bool
+	err     error
+}
+
+var _ tmdb.SeekableIterator = (*metricsDBIterator)(nil)
+
+func newMetricsDBIterator(metrics *dbMetrics, source tmdb.Iterator) *metricsDBIterator {
+	metrics.iteratorOpened()
+	return &metricsDBIterator{
+		metrics: metrics,
+		source:  source,
+		opened:  time.Now(),
+	}
+}
+
+// Domain implements Iterator.
+func (itr *metricsDBIterator) Domain() (start, end []byte) {
+	defer itr.metrics.observe("iterator_domain", time.Now(), nil)
+	return itr.source.Domain()
+}
+
+// Valid implements Iterator.
+func (itr *metricsDBIterator) Valid() bool {
+	defer itr.metrics.observe("iterator_valid", time.Now(), nil)
+	return itr.err == nil && itr.source.Valid()
+}
+
+// Next implements Iterator.
+func (itr *metricsDBIterator) Next() {
+	defer itr.metrics.observe("iterator_next", time.Now(), nil)
+	itr.source.Next()
+}
+
+// Seek implements SeekableIterator. It fails with ErrNotSupported if the source iterator does not
+// implement SeekableIterator.
+func (itr *metricsDBIterator) Seek(key []byte) {
+	defer itr.metrics.observe("iterator_seek", time.Now(), &itr.err)
+	source, ok := itr.source.(tmdb.SeekableIterator)
+	if !ok {
+		itr.err = tmdb.ErrNotSupported
+		return
+	}
+	source.Seek(key)
+}
+
+// Key implements Iterator.
+func (itr *metricsDBIterator) Key() []byte {
+	defer itr.metrics.observe("iterator_key", time.Now(), nil)
+	key := itr.source.Key()
+	itr.metrics.read(len(key))
+	return key
+}
+
+// Value implements Iterator.
+func (itr *metricsDBIterator) Value() []byte {
+	defer itr.metrics.observe("iterator_value", time.Now(), nil)
+	value := itr.source.Value()
+	itr.metrics.read(len(value))
+	return value
+}
+
+// Error implements Iterator.
+func (itr *metricsDBIterator) Error() (err error) {
+	defer itr.metrics.observe("iterator_error", time.Now(), &err)
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *metricsDBIterator) Close() (err error) {
+	defer itr.metrics.observe("iterator_close", time.Now(), &err)
+	if !itr.closed {
+		itr.closed = true
+		itr.metrics.iteratorClosed(itr.opened)
+	}
+	return itr.source.Close()
+}
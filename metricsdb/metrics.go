@@ -0,0 +1,316 @@
+package metricsdb
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The upper bounds of the histogram buckets, in seconds for durations.
+var (
+	durationBuckets = []float64{
+		0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05,
+		0.1, 0.25, 0.5, 1, 2.5, 5, 10,
+	}
+	iteratorDurationBuckets = []float64{0.0001, 0.001, 0.01, 0.1, 1, 10, 60, 600, 3600}
+	batchOperationsBuckets  = []float64{1, 10, 100, 1000, 10000, 100000, 1000000}
+	batchBytesBuckets       = []float64{
+		256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864, 268435456,
+	}
+)
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metrics is a registry of metrics for one or more wrapped databases. It implements
+// http.Handler, serving the metrics in the Prometheus text exposition format with backend and
+// name labels for each database. Databases wrapped with the same backend and name share their
+// metrics, e.g. when a database is reopened.
+type Metrics struct {
+	mtx sync.Mutex
+	dbs map[dbLabels]*dbMetrics
+}
+
+var _ http.Handler = (*Metrics)(nil)
+
+// dbLabels are the labels identifying a database.
+type dbLabels struct {
+	backend string
+	name    string
+}
+
+// NewMetrics creates a new, empty metrics registry.
+func NewMetrics() *Metrics {
+	return &Metrics{dbs: make(map[dbLabels]*dbMetrics)}
+}
+
+// db returns the metrics of the database with the given labels, registering it if necessary.
+func (m *Metrics) db(backend, name string) *dbMetrics {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	labels := dbLabels{backend: backend, name: name}
+	dbm, ok := m.dbs[labels]
+	if !ok {
+		dbm = newDBMetrics(labels)
+		m.dbs[labels] = dbm
+	}
+	return dbm
+}
+
+// ServeHTTP implements http.Handler.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	_ = m.Write(w)
+}
+
+// Write writes the metrics to a writer in the Prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) error {
+	m.mtx.Lock()
+	dbs := make([]*dbMetrics, 0, len(m.dbs))
+	for _, dbm := range m.dbs {
+		dbs = append(dbs, dbm)
+	}
+	m.mtx.Unlock()
+	sort.Slice(dbs, func(i, j int) bool {
+		if dbs[i].labels.backend != dbs[j].labels.backend {
+			return dbs[i].labels.backend < dbs[j].labels.backend
+		}
+		return dbs[i].labels.name < dbs[j].labels.name
+	})
+
+	buf := &bytes.Buffer{}
+	writeFamily(buf, dbs, "tmdb_operations_total", "counter",
+		"Number of database, batch and iterator operations.",
+		func(dbm *dbMetrics) {
+			for _, op := range dbm.opNames() {
+				writeSample(buf, "tmdb_operations_total", dbm.opLabels(op), float64(dbm.ops[op].count))
+			}
+		})
+	writeFamily(buf, dbs, "tmdb_operation_errors_total", "counter",
+		"Number of database, batch and iterator operations which failed.",
+		func(dbm *dbMetrics) {
+			for _, op := range dbm.opNames() {
+				writeSample(buf, "tmdb_operation_errors_total", dbm.opLabels(op), float64(dbm.ops[op].errors))
+			}
+		})
+	writeFamily(buf, dbs, "tmdb_operation_duration_seconds", "histogram",
+		"Latency of database, batch and iterator operations.",
+		func(dbm *dbMetrics) {
+			for _, op := range dbm.opNames() {
+				writeHistogram(buf, "tmdb_operation_duration_seconds", dbm.opLabels(op), dbm.ops[op].duration)
+			}
+		})
+	writeFamily(buf, dbs, "tmdb_read_bytes_total", "counter",
+		"Number of key and value bytes read.",
+		func(dbm *dbMetrics) {
+			writeSample(buf, "tmdb_read_bytes_total", dbm.labels.String(), float64(dbm.bytesRead))
+		})
+	writeFamily(buf, dbs, "tmdb_written_bytes_total", "counter",
+		"Number of key and value bytes written, including batches.",
+		func(dbm *dbMetrics) {
+			writeSample(buf, "tmdb_written_bytes_total", dbm.labels.String(), float64(dbm.bytesWritten))
+		})
+	writeFamily(buf, dbs, "tmdb_batch_operations", "histogram",
+		"Number of operations in written batches.",
+		func(dbm *dbMetrics) {
+			writeHistogram(buf, "tmdb_batch_operations", dbm.labels.String(), dbm.batchOperations)
+		})
+	writeFamily(buf, dbs, "tmdb_batch_bytes", "histogram",
+		"Number of key and value bytes in written batches.",
+		func(dbm *dbMetrics) {
+			writeHistogram(buf, "tmdb_batch_bytes", dbm.labels.String(), dbm.batchBytes)
+		})
+	writeFamily(buf, dbs, "tmdb_open_iterators", "gauge",
+		"Number of open iterators.",
+		func(dbm *dbMetrics) {
+			writeSample(buf, "tmdb_open_iterators", dbm.labels.String(), float64(dbm.openIterators))
+		})
+	writeFamily(buf, dbs, "tmdb_iterator_duration_seconds", "histogram",
+		"Lifetime of closed iterators.",
+		func(dbm *dbMetrics) {
+			writeHistogram(buf, "tmdb_iterator_duration_seconds", dbm.labels.String(), dbm.iteratorDuration)
+		})
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeFamily writes the header of a metric family, followed by the samples of each database
+// written by the given function while holding the database's lock.
+func writeFamily(buf *bytes.Buffer, dbs []*dbMetrics, metric, typ, help string, fn func(*dbMetrics)) {
+	buf.WriteString("# HELP " + metric + " " + help + "\n")
+	buf.WriteString("# TYPE " + metric + " " + typ + "\n")
+	for _, dbm := range dbs {
+		dbm.mtx.Lock()
+		fn(dbm)
+		dbm.mtx.Unlock()
+	}
+}
+
+// writeSample writes a single sample, with labels formatted as a comma-separated list.
+func writeSample(buf *bytes.Buffer, metric, labels string, value float64) {
+	buf.WriteString(metric + "{" + labels + "} " + formatFloat(value) + "\n")
+}
+
+// writeHistogram writes the cumulative buckets, sum and count of a histogram.
+func writeHistogram(buf *bytes.Buffer, metric, labels string, h *histogram) {
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		writeSample(buf, metric+"_bucket", labels+`,le="`+formatFloat(bound)+`"`, float64(cumulative))
+	}
+	writeSample(buf, metric+"_bucket", labels+`,le="+Inf"`, float64(h.count))
+	writeSample(buf, metric+"_sum", labels, h.sum)
+	writeSample(buf, metric+"_count", labels, float64(h.count))
+}
+
+// formatFloat formats a sample value.
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelEscaper escapes label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabel formats a label pair.
+func formatLabel(name, value string) string {
+	return name + `="` + labelEscaper.Replace(value) + `"`
+}
+
+// String formats the labels as a comma-separated list.
+func (l dbLabels) String() string {
+	return formatLabel("backend", l.backend) + "," + formatLabel("name", l.name)
+}
+
+// histogram counts observed values in buckets.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] is the number of values in (bounds[i-1], bounds[i]]
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// observe adds a value to the histogram.
+func (h *histogram) observe(value float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, value)]++
+	h.sum += value
+	h.count++
+}
+
+// opMetrics are the metrics of an operation.
+type opMetrics struct {
+	count    uint64
+	errors   uint64
+	duration *histogram
+}
+
+// dbMetrics are the metrics of a database.
+type dbMetrics struct {
+	labels dbLabels
+
+	mtx              sync.Mutex // guards the fields below
+	ops              map[string]*opMetrics
+	bytesRead        uint64
+	bytesWritten     uint64
+	batchOperations  *histogram
+	batchBytes       *histogram
+	openIterators    int64
+	iteratorDuration *histogram
+}
+
+func newDBMetrics(labels dbLabels) *dbMetrics {
+	return &dbMetrics{
+		labels:           labels,
+		ops:              make(map[string]*opMetrics),
+		batchOperations:  newHistogram(batchOperationsBuckets),
+		batchBytes:       newHistogram(batchBytesBuckets),
+		iteratorDuration: newHistogram(iteratorDurationBuckets),
+	}
+}
+
+// opNames returns the sorted names of the operations which have been observed. The caller must
+// hold the lock.
+func (m *dbMetrics) opNames() []string {
+	names := make([]string, 0, len(m.ops))
+	for name := range m.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// opLabels formats the labels of an operation.
+func (m *dbMetrics) opLabels(op string) string {
+	return m.labels.String() + "," + formatLabel("op", op)
+}
+
+// observe records an operation which started at the given time, and failed if err is non-nil
+// and points to a non-nil error. It is meant to be deferred.
+func (m *dbMetrics) observe(op string, start time.Time, err *error) {
+	duration := time.Since(start).Seconds()
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	opm, ok := m.ops[op]
+	if !ok {
+		opm = &opMetrics{duration: newHistogram(durationBuckets)}
+		m.ops[op] = opm
+	}
+	opm.count++
+	if err != nil && *err != nil {
+		opm.errors++
+	}
+	opm.duration.observe(duration)
+}
+
+// read records the number of bytes read.
+func (m *dbMetrics) read(n int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.bytesRead += uint64(n)
+}
+
+// written records the number of bytes written.
+func (m *dbMetrics) written(n int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.bytesWritten += uint64(n)
+}
+
+// batchWritten records a written batch.
+func (m *dbMetrics) batchWritten(operations, n int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.bytesWritten += uint64(n)
+	m.batchOperations.observe(float64(operations))
+	m.batchBytes.observe(float64(n))
+}
+
+// iteratorOpened records an opened iterator.
+func (m *dbMetrics) iteratorOpened() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.openIterators++
+}
+
+// iteratorClosed records a closed iterator, which was opened at the given time.
+func (m *dbMetrics) iteratorClosed(opened time.Time) {
+	duration := time.Since(opened).Seconds()
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.openIterators--
+	m.iteratorDuration.observe(duration)
+}
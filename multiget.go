@@ -0,0 +1,33 @@
+package db
+
+// GetMany fetches the values of the given keys from a reader, in the same order as the keys, with
+// nil values for missing keys. It uses MultiGetDB.GetMany if the reader implements it, and
+// otherwise calls Get for each key, in which case the keys are only read from a consistent view
+// if the reader is a Snapshot or Transaction.
+func GetMany(r Reader, keys [][]byte) ([][]byte, error) {
+	if mdb, ok := r.(MultiGetDB); ok {
+		return mdb.GetMany(keys)
+	}
+	if err := validateKeys(keys); err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, err := r.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// validateKeys returns ErrKeyEmpty if any of the keys is empty.
+func validateKeys(keys [][]byte) error {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return ErrKeyEmpty
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package mvccdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// versionBatch is a batch of writes at a version.
+type versionBatch struct {
+	store   *Store
+	source  tmdb.Batch
+	version uint64
+}
+
+var _ tmdb.Batch = (*versionBatch)(nil)
+
+func newVersionBatch(store *Store, source tmdb.Batch, version uint64) *versionBatch {
+	return &versionBatch{store: store, source: source, version: version}
+}
+
+// Set implements Batch.
+func (b *versionBatch) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return b.source.Set(encodeKey(key, b.version), append([]byte{kindSet}, value...))
+}
+
+// Delete implements Batch. The deletion is stored as a new version of the key.
+func (b *versionBatch) Delete(key []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	return b.source.Set(encodeKey(key, b.version), []byte{kindDelete})
+}
+
+// DeleteRange implements Batch. It always fails with ErrNotSupported, use Store.DeleteRange
+// instead.
+func (b *versionBatch) DeleteRange(start, end []byte) error {
+	return tmdb.ErrNotSupported
+}
+
+// Write implements Batch. It fails with ErrPruned if the batch version has been pruned.
+func (b *versionBatch) Write() error {
+	if err := b.store.checkVersion(b.version); err != nil {
+		return err
+	}
+	return b.source.Write()
+}
+
+// WriteSync implements Batch. It fails with ErrPruned if the batch version has been pruned.
+func (b *versionBatch) WriteSync() error {
+	if err := b.store.checkVersion(b.version); err != nil {
+		return err
+	}
+	return b.source.WriteSync()
+}
+
+// Close implements Batch.
+func (b *versionBatch) Close() error {
+	return b.source.Close()
+}
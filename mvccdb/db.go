@@ -0,0 +1,364 @@
+// Package mvccdb provides a multi-version store over any database, which keeps the history of keys
+// by version, e.g. by block height, such that past states can be read.
+package mvccdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// pruneBatchSize is the maximum number of storage keys read at a time while pruning, and of keys
+// deleted at a time by DeleteRange.
+const pruneBatchSize = 1000
+
+var (
+	// ErrPruned is returned when reading or writing a version which has been pruned.
+	ErrPruned = errors.New("version has been pruned")
+
+	// dataPrefix namespaces the versions of keys.
+	dataPrefix = []byte{'d'}
+	// metaPrefix namespaces the metadata of the store.
+	metaPrefix = []byte{'m'}
+	// prunedKey is the metadata key of the pruned version.
+	prunedKey = []byte("pruned")
+)
+
+// Store wraps a database to keep the history of keys by version. Every write is tagged with a
+// version, and reads return the state as of a version: the value written by the write of the key
+// with the highest version at or below it, unless that write was a deletion. Writes at a version
+// change the state as of that version and later ones, until the next write of the key, so callers
+// typically write each version once, in increasing order. The wrapped database must only be used
+// through the Store, and must order keys by their bytes. It is closed along with the Store.
+//
+// Versions are stored as separate entries, so history grows with every write until it is removed
+// with Prune. Iterators read all versions of the keys in their domain, and follow the contract of
+// the wrapped database's iterators: no writes may happen within their domain while they exist, at
+// any version.
+type Store struct {
+	db   tmdb.DB
+	data *tmdb.PrefixDB
+	meta *tmdb.PrefixDB
+
+	mtx    sync.RWMutex // guards pruned
+	pruned uint64
+}
+
+// NewStore wraps a database, which fails with ErrNotSupported if it does not order keys by their
+// bytes.
+func NewStore(db tmdb.DB) (*Store, error) {
+	if !tmdb.IsBytesComparer(tmdb.KeyComparer(db)) {
+		return nil, fmt.Errorf("mvccdb requires keys ordered by their bytes: %w", tmdb.ErrNotSupported)
+	}
+	s := &Store{
+		db:   db,
+		data: tmdb.NewPrefixDB(db, dataPrefix),
+		meta: tmdb.NewPrefixDB(db, metaPrefix),
+	}
+	pruned, err := s.meta.Get(prunedKey)
+	if err != nil {
+		return nil, err
+	}
+	if pruned != nil {
+		if len(pruned) != versionLen {
+			return nil, fmt.Errorf("invalid pruned version %X", pruned)
+		}
+		s.pruned = binary.BigEndian.Uint64(pruned)
+	}
+	return s, nil
+}
+
+// PrunedVersion returns the version which the history has been pruned before, as the lowest
+// version which can be read and written.
+func (s *Store) PrunedVersion() uint64 {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.pruned
+}
+
+// checkVersion returns ErrPruned if a version has been pruned.
+func (s *Store) checkVersion(version uint64) error {
+	if version < s.PrunedVersion() {
+		return ErrPruned
+	}
+	return nil
+}
+
+// GetAt fetches the value of a key as of a version, or nil if it did not exist.
+func (s *Store) GetAt(key []byte, version uint64) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	if err := s.checkVersion(version); err != nil {
+		return nil, err
+	}
+	itr, err := s.data.Iterator(encodeKey(key, version), keyEnd(key))
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+	if !itr.Valid() {
+		return nil, itr.Error()
+	}
+	value := itr.Value()
+	if len(value) == 0 || value[0] != kindSet {
+		return nil, nil
+	}
+	return append([]byte{}, value[1:]...), nil
+}
+
+// HasAt checks if a key existed as of a version.
+func (s *Store) HasAt(key []byte, version uint64) (bool, error) {
+	value, err := s.GetAt(key, version)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+// IteratorAt returns an iterator over a domain of keys as of a version, in ascending order. The
+// domain is given as for DB.Iterator.
+func (s *Store) IteratorAt(start, end []byte, version uint64) (tmdb.Iterator, error) {
+	return s.iteratorAt(s.data, start, end, version, false)
+}
+
+// ReverseIteratorAt returns an iterator over a domain of keys as of a version, in descending
+// order. The domain is given as for DB.ReverseIterator.
+func (s *Store) ReverseIteratorAt(start, end []byte, version uint64) (tmdb.Iterator, error) {
+	return s.iteratorAt(s.data, start, end, version, true)
+}
+
+// iteratorAt returns an iterator as of a version over a reader of the data namespace.
+func (s *Store) iteratorAt(data tmdb.Reader, start, end []byte, version uint64, reverse bool) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	if err := s.checkVersion(version); err != nil {
+		return nil, err
+	}
+	var (
+		source tmdb.Iterator
+		err    error
+	)
+	if reverse {
+		source, err = data.ReverseIterator(rangeKey(start), rangeKey(end))
+	} else {
+		source, err = data.Iterator(rangeKey(start), rangeKey(end))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newVersionIterator(source, start, end, version, reverse), nil
+}
+
+// ReaderAt returns a Reader of the state as of a version, e.g. for use with Copy or Dump.
+func (s *Store) ReaderAt(version uint64) tmdb.Reader {
+	return versionReader{store: s, version: version}
+}
+
+// Set sets the value of a key at a version.
+func (s *Store) Set(version uint64, key, value []byte) error {
+	batch := s.NewBatch(version)
+	defer batch.Close()
+	if err := batch.Set(key, value); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Delete deletes a key at a version.
+func (s *Store) Delete(version uint64, key []byte) error {
+	batch := s.NewBatch(version)
+	defer batch.Close()
+	if err := batch.Delete(key); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// DeleteRange deletes the keys in a range at a version, which existed as of the version. The
+// range is given as for DB.DeleteRange. Keys are deleted in batches, so the deletion is not
+// atomic, and keys written by other callers while it is in progress may or may not be deleted.
+func (s *Store) DeleteRange(version uint64, start, end []byte) error {
+	for {
+		// Keys cannot be written while iterating over them, so we collect a batch of them from a
+		// snapshot first, which allows other callers to keep writing.
+		snapshot, err := s.data.NewSnapshot()
+		if err != nil {
+			return err
+		}
+		itr, err := s.iteratorAt(snapshot, start, end, version, false)
+		if err != nil {
+			snapshot.Close()
+			return err
+		}
+		var keys [][]byte
+		for ; itr.Valid() && len(keys) < pruneBatchSize; itr.Next() {
+			keys = append(keys, itr.Key())
+		}
+		err = itr.Error()
+		itr.Close()
+		snapshot.Close()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		batch := s.NewBatch(version)
+		for _, key := range keys {
+			if err := batch.Delete(key); err != nil {
+				batch.Close()
+				return err
+			}
+		}
+		err = batch.Write()
+		batch.Close()
+		if err != nil || len(keys) < pruneBatchSize {
+			return err
+		}
+		start = append(keys[len(keys)-1], 0x00)
+	}
+}
+
+// NewBatch creates a batch of writes at a version. Batches do not support DeleteRange.
+func (s *Store) NewBatch(version uint64) tmdb.Batch {
+	return newVersionBatch(s, s.data.NewBatch(), version)
+}
+
+// Prune removes the history before a version, such that versions below it can no longer be read
+// or written, while the state as of the version and later ones is kept. It can be called while
+// the store is in use, since history is read from snapshots of the wrapped database, but reads of
+// pruned versions which are in progress may see partial state, and writes of pruned versions which
+// are in progress may be lost.
+func (s *Store) Prune(before uint64) error {
+	s.mtx.Lock()
+	if before <= s.pruned {
+		s.mtx.Unlock()
+		return nil
+	}
+	// The pruned version is stored first, so that pruned versions are never read after a crash.
+	err := s.meta.SetSync(prunedKey, encodeVersion(before))
+	if err == nil {
+		s.pruned = before
+	}
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var (
+		start   []byte
+		lastKey []byte
+		kept    bool // whether the state of lastKey as of the version was found
+	)
+	for {
+		// Keys cannot be written while iterating over them, so we collect a batch of them from a
+		// snapshot first, which allows other callers to keep writing.
+		snapshot, err := s.data.NewSnapshot()
+		if err != nil {
+			return err
+		}
+		itr, err := snapshot.Iterator(start, nil)
+		if err != nil {
+			snapshot.Close()
+			return err
+		}
+		var (
+			stale [][]byte
+			count int
+		)
+		for ; itr.Valid() && count < pruneBatchSize; itr.Next() {
+			count++
+			encoded := itr.Key()
+			key, version, err := decodeKey(encoded)
+			if err != nil {
+				itr.Close()
+				snapshot.Close()
+				return err
+			}
+			if !bytes.Equal(key, lastKey) {
+				lastKey, kept = key, false
+			}
+			switch {
+			case version > before:
+			case kept:
+				stale = append(stale, encoded)
+			default:
+				// This is the state as of the version, which is only needed if the key exists.
+				kept = true
+				if value := itr.Value(); version < before && (len(value) == 0 || value[0] != kindSet) {
+					stale = append(stale, encoded)
+				}
+			}
+			start = append(append(start[:0:0], encoded...), 0x00)
+		}
+		err = itr.Error()
+		itr.Close()
+		snapshot.Close()
+		if err != nil {
+			return err
+		}
+
+		if len(stale) > 0 {
+			batch := s.data.NewBatch()
+			for _, encoded := range stale {
+				if err := batch.Delete(encoded); err != nil {
+					batch.Close()
+					return err
+				}
+			}
+			err = batch.Write()
+			batch.Close()
+			if err != nil {
+				return err
+			}
+		}
+		if count < pruneBatchSize {
+			return nil
+		}
+	}
+}
+
+// Close closes the wrapped database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Stats returns the statistics of the wrapped database, whose key count includes all versions.
+func (s *Store) Stats() (*tmdb.Stats, error) {
+	return s.db.Stats()
+}
+
+// versionReader reads a Store as of a version.
+type versionReader struct {
+	store   *Store
+	version uint64
+}
+
+var _ tmdb.Reader = versionReader{}
+
+// Get implements Reader.
+func (r versionReader) Get(key []byte) ([]byte, error) {
+	return r.store.GetAt(key, r.version)
+}
+
+// Has implements Reader.
+func (r versionReader) Has(key []byte) (bool, error) {
+	return r.store.HasAt(key, r.version)
+}
+
+// Iterator implements Reader.
+func (r versionReader) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	return r.store.IteratorAt(start, end, r.version)
+}
+
+// ReverseIterator implements Reader.
+func (r versionReader) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	return r.store.ReverseIteratorAt(start, end, r.version)
+}
@@ -0,0 +1,242 @@
+package mvccdb
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+// testKeys contain 0x00 bytes and prefixes of each other, to exercise the key encoding. They are
+// in ascending order.
+var testKeys = []string{"a", "a\x00", "a\x00\x00", "a\x00b", "a\x01", "ab", "b", "b\xff", "c"}
+
+// writeHistory writes random sets and deletes of the test keys at versions 1 to n, and returns
+// the expected state as of each version, indexed by version.
+func writeHistory(t *testing.T, store *Store, n uint64) []map[string]string {
+	rng := rand.New(rand.NewSource(1)) // nolint: gosec
+	states := []map[string]string{{}}
+	for version := uint64(1); version <= n; version++ {
+		state := map[string]string{}
+		for key, value := range states[version-1] {
+			state[key] = value
+		}
+		batch := store.NewBatch(version)
+		for _, key := range testKeys {
+			switch rng.Intn(3) {
+			case 0:
+				value := string(rune('a'+version)) + key
+				require.NoError(t, batch.Set([]byte(key), []byte(value)))
+				state[key] = value
+			case 1:
+				require.NoError(t, batch.Delete([]byte(key)))
+				delete(state, key)
+			}
+		}
+		require.NoError(t, batch.Write())
+		require.NoError(t, batch.Close())
+		states = append(states, state)
+	}
+	return states
+}
+
+// assertState checks the state of a store as of a version, using all read methods.
+func assertState(t *testing.T, store *Store, version uint64, state map[string]string) {
+	expected := []string{}
+	for _, key := range testKeys {
+		value, err := store.GetAt([]byte(key), version)
+		require.NoError(t, err)
+		if expect, ok := state[key]; ok {
+			require.Equal(t, []byte(expect), value, "version %v key %q", version, key)
+			expected = append(expected, key+"="+expect)
+		} else {
+			require.Nil(t, value, "version %v key %q", version, key)
+		}
+	}
+
+	require.Equal(t, expected, iterate(t, store.ReaderAt(version), nil, nil, false), "version %v", version)
+	reversed := make([]string, len(expected))
+	for i, entry := range expected {
+		reversed[len(expected)-1-i] = entry
+	}
+	require.Equal(t, reversed, iterate(t, store.ReaderAt(version), nil, nil, true), "version %v", version)
+}
+
+func iterate(t *testing.T, r tmdb.Reader, start, end []byte, reverse bool) []string {
+	var (
+		itr tmdb.Iterator
+		err error
+	)
+	if reverse {
+		itr, err = r.ReverseIterator(start, end)
+	} else {
+		itr, err = r.Iterator(start, end)
+	}
+	require.NoError(t, err)
+	defer itr.Close()
+	entries := []string{}
+	for ; itr.Valid(); itr.Next() {
+		entries = append(entries, string(itr.Key())+"="+string(itr.Value()))
+	}
+	require.NoError(t, itr.Error())
+	return entries
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore(memdb.NewDB())
+	require.NoError(t, err)
+	defer store.Close()
+
+	states := writeHistory(t, store, 20)
+	for version, state := range states {
+		assertState(t, store, uint64(version), state)
+	}
+	assertState(t, store, 100, states[20])
+
+	require.NoError(t, store.Set(21, []byte("a\x00"), []byte("x")))
+	require.NoError(t, store.Set(21, []byte("b"), []byte("y")))
+	require.Equal(t, []string{"a\x00=x"}, iterate(t, store.ReaderAt(21), []byte("a\x00"), []byte("a\x00\x00"), false))
+	require.Equal(t, []string{"b=y"}, iterate(t, store.ReaderAt(21), []byte("a\x02"), []byte("b\x00"), true))
+
+	require.NoError(t, store.DeleteRange(22, []byte("a\x00"), []byte("b")))
+	has, err := store.HasAt([]byte("a\x00"), 22)
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = store.HasAt([]byte("a\x00"), 21)
+	require.NoError(t, err)
+	require.True(t, has)
+
+	_, err = store.GetAt([]byte{}, 1)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	require.Equal(t, tmdb.ErrValueNil, store.Set(1, []byte("a"), nil))
+	require.Equal(t, tmdb.ErrNotSupported, store.NewBatch(1).DeleteRange(nil, nil))
+}
+
+func TestStorePrune(t *testing.T) {
+	db := memdb.NewDB()
+	store, err := NewStore(db)
+	require.NoError(t, err)
+
+	states := writeHistory(t, store, 20)
+	before, err := db.Stats()
+	require.NoError(t, err)
+
+	require.NoError(t, store.Prune(10))
+	require.EqualValues(t, 10, store.PrunedVersion())
+	for version := uint64(10); version <= 20; version++ {
+		assertState(t, store, version, states[version])
+	}
+	_, err = store.GetAt([]byte("a"), 9)
+	require.Equal(t, ErrPruned, err)
+	_, err = store.IteratorAt(nil, nil, 9)
+	require.Equal(t, ErrPruned, err)
+	require.Equal(t, ErrPruned, store.Set(9, []byte("a"), []byte("a")))
+
+	// Only the state as of version 10 remains of the history before it.
+	after, err := db.Stats()
+	require.NoError(t, err)
+	require.Less(t, after.KeyCount, before.KeyCount)
+	itr, err := store.data.Iterator(nil, nil)
+	require.NoError(t, err)
+	for ; itr.Valid(); itr.Next() {
+		key, version, err := decodeKey(itr.Key())
+		require.NoError(t, err)
+		if version < 10 {
+			_, ok := states[10][string(key)]
+			require.True(t, ok, "key %q version %v", key, version)
+		}
+	}
+	require.NoError(t, itr.Error())
+	require.NoError(t, itr.Close())
+
+	// The pruned version is persisted, and pruning is idempotent.
+	store, err = NewStore(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, store.PrunedVersion())
+	require.NoError(t, store.Prune(5))
+	require.NoError(t, store.Prune(10))
+	require.EqualValues(t, 10, store.PrunedVersion())
+	require.NoError(t, store.Prune(25))
+	assertState(t, store, 25, states[20])
+	require.NoError(t, store.Close())
+
+	_, err = NewStore(memdb.NewDBWithComparer(reverseComparer{}))
+	require.ErrorIs(t, err, tmdb.ErrNotSupported)
+}
+
+// reverseComparer orders keys in reverse byte order.
+type reverseComparer struct{}
+
+func (reverseComparer) Compare(a, b []byte) int {
+	return tmdb.BytesComparer.Compare(b, a)
+}
+
+func (reverseComparer) Name() string {
+	return "reverse"
+}
+
+func TestStoreBatches(t *testing.T) {
+	db := memdb.NewDB()
+	store, err := NewStore(db)
+	require.NoError(t, err)
+	defer store.Close()
+
+	// Pruning and range deletions span several batches of keys.
+	for version := uint64(1); version <= 2500; version++ {
+		require.NoError(t, store.Set(version, []byte("a"), encodeVersion(version)))
+		require.NoError(t, store.Set(version, []byte{'b', byte(version >> 8), byte(version)}, []byte{1}))
+	}
+	require.NoError(t, store.Prune(2000))
+	value, err := store.GetAt([]byte("a"), 2000)
+	require.NoError(t, err)
+	require.Equal(t, encodeVersion(2000), value)
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	require.EqualValues(t, 501+2500+1, stats.KeyCount)
+
+	require.NoError(t, store.DeleteRange(2500, []byte("b"), nil))
+	require.Equal(t, []string{"a=" + string(encodeVersion(2500))}, iterate(t, store.ReaderAt(2500), nil, nil, false))
+	require.Len(t, iterate(t, store.ReaderAt(2499), []byte("b"), nil, true), 2499)
+}
+
+func TestStorePruneConcurrent(t *testing.T) {
+	store, err := NewStore(memdb.NewDB())
+	require.NoError(t, err)
+	defer store.Close()
+
+	for version := uint64(1); version <= 3000; version++ {
+		require.NoError(t, store.Set(version, []byte(fmt.Sprintf("key%04d", version%1500)), encodeVersion(version)))
+	}
+
+	// Writes of later versions, including of keys being pruned, continue while pruning.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for version := uint64(3001); version <= 4500; version++ {
+			require.NoError(t, store.Set(version, []byte(fmt.Sprintf("key%04d", version%1500)), encodeVersion(version)))
+		}
+	}()
+	require.NoError(t, store.Prune(2000))
+	wg.Wait()
+
+	for version := uint64(2000); version <= 4500; version += 250 {
+		for i := uint64(0); i < 1500; i++ {
+			value, err := store.GetAt([]byte(fmt.Sprintf("key%04d", i)), version)
+			require.NoError(t, err)
+			expect := i
+			for expect+1500 <= version {
+				expect += 1500
+			}
+			if expect == 0 {
+				require.Nil(t, value)
+			} else {
+				require.Equal(t, encodeVersion(expect), value, "version %v key %v", version, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package mvccdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// versionLen is the length of the version suffixed to storage keys.
+	versionLen = 8
+
+	// kindDelete and kindSet prefix the stored values of deletions and writes.
+	kindDelete byte = 0
+	kindSet    byte = 1
+)
+
+var (
+	// terminator follows the escaped key in storage keys, and sorts before an escaped 0x00 byte.
+	terminator = []byte{0x00, 0x01}
+	// afterVersions follows the escaped key after all of its storage keys.
+	afterVersions = []byte{0x00, 0x02}
+)
+
+// escapeKey escapes 0x00 bytes of a key as 0x00 0xFF, such that no escaped key is followed by the
+// terminator within another escaped key, while preserving the order of keys.
+func escapeKey(key []byte) []byte {
+	escaped := make([]byte, 0, len(key)+len(terminator)+versionLen)
+	for _, b := range key {
+		escaped = append(escaped, b)
+		if b == 0x00 {
+			escaped = append(escaped, 0xFF)
+		}
+	}
+	return escaped
+}
+
+// encodeKey returns the storage key of a key at a version: the escaped key, the terminator and
+// the bitwise inverse of the version as a big-endian uint64. The versions of a key are thus stored
+// contiguously from newest to oldest, ordered among other keys as the key is.
+func encodeKey(key []byte, version uint64) []byte {
+	encoded := append(escapeKey(key), terminator...)
+	return append(encoded, encodeVersion(^version)...)
+}
+
+// decodeKey returns the key and version of a storage key.
+func decodeKey(encoded []byte) ([]byte, uint64, error) {
+	n := len(encoded) - len(terminator) - versionLen
+	if n < 0 || !bytes.Equal(encoded[n:n+len(terminator)], terminator) {
+		return nil, 0, fmt.Errorf("invalid storage key %X", encoded)
+	}
+	key := make([]byte, 0, n)
+	for i := 0; i < n; i++ {
+		key = append(key, encoded[i])
+		if encoded[i] == 0x00 {
+			if i+1 >= n || encoded[i+1] != 0xFF {
+				return nil, 0, fmt.Errorf("invalid storage key %X", encoded)
+			}
+			i++
+		}
+	}
+	return key, ^binary.BigEndian.Uint64(encoded[n+len(terminator):]), nil
+}
+
+// keyEnd returns the storage key after all versions of a key.
+func keyEnd(key []byte) []byte {
+	return append(escapeKey(key), afterVersions...)
+}
+
+// rangeKey returns the storage key bounding a range of keys, which is before all versions of the
+// key and after those of all smaller keys, or nil if the key is nil.
+func rangeKey(key []byte) []byte {
+	if key == nil {
+		return nil
+	}
+	return escapeKey(key)
+}
+
+// encodeVersion encodes a version as a big-endian uint64.
+func encodeVersion(version uint64) []byte {
+	b := make([]byte, versionLen)
+	binary.BigEndian.PutUint64(b, version)
+	return b
+}
@@ -0,0 +1,144 @@
+package mvccdb
+
+import (
+	"bytes"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// versionIterator iterates over the state as of a version, given an iterator over the versions of
+// the keys in its domain.
+type versionIterator struct {
+	source     tmdb.Iterator
+	start, end []byte
+	version    uint64
+	reverse    bool
+
+	valid bool
+	key   []byte // the current key, or the last key visited if invalid
+	value []byte
+	err   error
+}
+
+var _ tmdb.Iterator = (*versionIterator)(nil)
+
+func newVersionIterator(source tmdb.Iterator, start, end []byte, version uint64, reverse bool) *versionIterator {
+	itr := &versionIterator{
+		source:  source,
+		start:   start,
+		end:     end,
+		version: version,
+		reverse: reverse,
+	}
+	itr.find()
+	return itr
+}
+
+// find moves to the next key which existed as of the version, starting at the current position
+// of the source iterator.
+func (itr *versionIterator) find() {
+	if itr.reverse {
+		itr.findReverse()
+	} else {
+		itr.findForward()
+	}
+}
+
+// findForward moves the source iterator to the entry of the next existing key. Versions of a key
+// are visited from newest to oldest, so the first one at or below the version is the key's state.
+func (itr *versionIterator) findForward() {
+	itr.valid = false
+	for ; itr.source.Valid(); itr.source.Next() {
+		key, version, err := decodeKey(itr.source.Key())
+		if err != nil {
+			itr.err = err
+			return
+		}
+		if version > itr.version || (itr.key != nil && bytes.Equal(key, itr.key)) {
+			continue
+		}
+		itr.key = key
+		if value := itr.source.Value(); len(value) > 0 && value[0] == kindSet {
+			itr.value, itr.valid = value[1:], true
+			return
+		}
+	}
+}
+
+// findReverse moves the source iterator past the entry of the next existing key. Versions of a
+// key are visited from oldest to newest, so the last one at or below the version is the key's
+// state.
+func (itr *versionIterator) findReverse() {
+	itr.valid = false
+	var value []byte
+	for ; itr.source.Valid(); itr.source.Next() {
+		key, version, err := decodeKey(itr.source.Key())
+		if err != nil {
+			itr.err = err
+			return
+		}
+		if itr.key == nil || !bytes.Equal(key, itr.key) {
+			if len(value) > 0 && value[0] == kindSet {
+				itr.value, itr.valid = value[1:], true
+				return
+			}
+			itr.key, value = key, nil
+		}
+		if version <= itr.version {
+			value = append(value[:0], itr.source.Value()...)
+		}
+	}
+	if itr.err = itr.source.Error(); itr.err == nil && len(value) > 0 && value[0] == kindSet {
+		itr.value, itr.valid = value[1:], true
+	}
+}
+
+// Domain implements Iterator.
+func (itr *versionIterator) Domain() ([]byte, []byte) {
+	return itr.start, itr.end
+}
+
+// Valid implements Iterator.
+func (itr *versionIterator) Valid() bool {
+	return itr.err == nil && itr.valid
+}
+
+// Next implements Iterator.
+func (itr *versionIterator) Next() {
+	itr.assertIsValid()
+	if !itr.reverse {
+		itr.source.Next()
+	}
+	itr.find()
+}
+
+// Key implements Iterator.
+func (itr *versionIterator) Key() []byte {
+	itr.assertIsValid()
+	return itr.key
+}
+
+// Value implements Iterator.
+func (itr *versionIterator) Value() []byte {
+	itr.assertIsValid()
+	return itr.value
+}
+
+// Error implements Iterator.
+func (itr *versionIterator) Error() error {
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *versionIterator) Close() error {
+	return itr.source.Close()
+}
+
+func (itr *versionIterator) assertIsValid() {
+	if !itr.Valid() {
+		panic("iterator is invalid")
+	}
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"fmt"
+	"io"
 	"sync"
+	"time"
 )
 
 // PrefixDB wraps a namespace of another database as a logical database.
@@ -12,7 +14,17 @@ type PrefixDB struct {
 	db     DB
 }
 
-var _ DB = (*PrefixDB)(nil)
+var (
+	_ TransactionDB     = (*PrefixDB)(nil)
+	_ CompactionDB      = (*PrefixDB)(nil)
+	_ MultiGetDB        = (*PrefixDB)(nil)
+	_ TTLDB             = (*PrefixDB)(nil)
+	_ WatchableDB       = (*PrefixDB)(nil)
+	_ KeysIteratorDB    = (*PrefixDB)(nil)
+	_ IteratorOptionsDB = (*PrefixDB)(nil)
+	_ ApproximateSizeDB = (*PrefixDB)(nil)
+	_ ComparerDB        = (*PrefixDB)(nil)
+)
 
 // NewPrefixDB lets you namespace multiple DBs within a single DB.
 func NewPrefixDB(db DB, prefix []byte) *PrefixDB {
@@ -38,6 +50,22 @@ func (pdb *PrefixDB) Get(key []byte) ([]byte, error) {
 	return value, nil
 }
 
+// GetMany implements MultiGetDB, using GetMany on the underlying database. The keys are only read
+// from a consistent view if the underlying database implements MultiGetDB.
+func (pdb *PrefixDB) GetMany(keys [][]byte) ([][]byte, error) {
+	if err := validateKeys(keys); err != nil {
+		return nil, err
+	}
+	pkeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		pkeys[i] = pdb.prefixed(key)
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	return GetMany(pdb.db, pkeys)
+}
+
 // Has implements DB.
 func (pdb *PrefixDB) Has(key []byte) (bool, error) {
 	if len(key) == 0 {
@@ -72,6 +100,39 @@ func (pdb *PrefixDB) Set(key []byte, value []byte) error {
 	return nil
 }
 
+// SetWithTTL implements TTLDB. It returns ErrNotSupported if the underlying database does not
+// implement TTLDB.
+func (pdb *PrefixDB) SetWithTTL(key, value []byte, ttl time.Duration) error {
+	if len(key) == 0 {
+		return ErrKeyEmpty
+	}
+	tdb, ok := pdb.db.(TTLDB)
+	if !ok {
+		return ErrNotSupported
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	return tdb.SetWithTTL(pdb.prefixed(key), value, ttl)
+}
+
+// Watch implements WatchableDB, watching the prefixed keys of the underlying database. It returns
+// ErrNotSupported if the underlying database does not implement WatchableDB.
+func (pdb *PrefixDB) Watch(prefix []byte) (Watcher, error) {
+	wdb, ok := pdb.db.(WatchableDB)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	source, err := wdb.Watch(pdb.prefixed(prefix))
+	if err != nil {
+		return nil, err
+	}
+	return newPrefixDBWatcher(pdb.prefix, source), nil
+}
+
 // SetSync implements DB.
 func (pdb *PrefixDB) SetSync(key []byte, value []byte) error {
 	if len(key) == 0 {
@@ -108,6 +169,55 @@ func (pdb *PrefixDB) DeleteSync(key []byte) error {
 	return pdb.db.DeleteSync(pdb.prefixed(key))
 }
 
+// DeleteRange implements DB.
+func (pdb *PrefixDB) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	pstart, pend := prefixedDeleteRange(pdb.prefix, start, end)
+	return pdb.db.DeleteRange(pstart, pend)
+}
+
+// Update implements DB.
+func (pdb *PrefixDB) Update(key []byte, fn UpdateFunc) error {
+	if len(key) == 0 {
+		return ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	return pdb.db.Update(pdb.prefixed(key), fn)
+}
+
+// CompareAndSwap implements DB.
+func (pdb *PrefixDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	return pdb.db.CompareAndSwap(pdb.prefixed(key), expected, value)
+}
+
+// Compact implements CompactionDB, compacting the prefixed range of the underlying database. It
+// returns ErrNotSupported if the underlying database does not implement CompactionDB.
+func (pdb *PrefixDB) Compact(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return ErrKeyEmpty
+	}
+	cdb, ok := pdb.db.(CompactionDB)
+	if !ok {
+		return ErrNotSupported
+	}
+	// The mutex is not held, since compaction can take a long time and does not modify any keys.
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
+	return cdb.Compact(pstart, pend)
+}
+
 // Iterator implements DB.
 func (pdb *PrefixDB) Iterator(start, end []byte) (Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
@@ -116,13 +226,7 @@ func (pdb *PrefixDB) Iterator(start, end []byte) (Iterator, error) {
 	pdb.mtx.Lock()
 	defer pdb.mtx.Unlock()
 
-	var pstart, pend []byte
-	pstart = append(cp(pdb.prefix), start...)
-	if end == nil {
-		pend = cpIncr(pdb.prefix)
-	} else {
-		pend = append(cp(pdb.prefix), end...)
-	}
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
 	itr, err := pdb.db.Iterator(pstart, pend)
 	if err != nil {
 		return nil, err
@@ -139,13 +243,7 @@ func (pdb *PrefixDB) ReverseIterator(start, end []byte) (Iterator, error) {
 	pdb.mtx.Lock()
 	defer pdb.mtx.Unlock()
 
-	var pstart, pend []byte
-	pstart = append(cp(pdb.prefix), start...)
-	if end == nil {
-		pend = cpIncr(pdb.prefix)
-	} else {
-		pend = append(cp(pdb.prefix), end...)
-	}
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
 	ritr, err := pdb.db.ReverseIterator(pstart, pend)
 	if err != nil {
 		return nil, err
@@ -154,6 +252,113 @@ func (pdb *PrefixDB) ReverseIterator(start, end []byte) (Iterator, error) {
 	return newPrefixIterator(pdb.prefix, start, end, ritr)
 }
 
+// KeysIterator implements KeysIteratorDB, using KeysIterator on the underlying database.
+func (pdb *PrefixDB) KeysIterator(start, end []byte) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
+	itr, err := KeysIterator(pdb.db, pstart, pend)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPrefixIterator(pdb.prefix, start, end, itr)
+}
+
+// ReverseKeysIterator implements KeysIteratorDB, using ReverseKeysIterator on the underlying
+// database.
+func (pdb *PrefixDB) ReverseKeysIterator(start, end []byte) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
+	ritr, err := ReverseKeysIterator(pdb.db, pstart, pend)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPrefixIterator(pdb.prefix, start, end, ritr)
+}
+
+// IteratorWithOptions implements IteratorOptionsDB, using IteratorWithOptions on the underlying
+// database.
+func (pdb *PrefixDB) IteratorWithOptions(start, end []byte, opts IteratorOptions) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
+	itr, err := IteratorWithOptions(pdb.db, pstart, pend, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPrefixIterator(pdb.prefix, start, end, itr)
+}
+
+// ApproximateSize implements ApproximateSizeDB, using ApproximateSize on the underlying database.
+// The size includes the prefixes of the keys.
+func (pdb *PrefixDB) ApproximateSize(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
+	return ApproximateSize(pdb.db, pstart, pend)
+}
+
+// ApproximateCount implements ApproximateSizeDB, using ApproximateCount on the underlying database.
+func (pdb *PrefixDB) ApproximateCount(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, ErrKeyEmpty
+	}
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	pstart, pend := prefixedRange(pdb.prefix, start, end)
+	return ApproximateCount(pdb.db, pstart, pend)
+}
+
+// NewSnapshot implements DB.
+func (pdb *PrefixDB) NewSnapshot() (Snapshot, error) {
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	snapshot, err := pdb.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return newPrefixSnapshot(pdb.prefix, snapshot), nil
+}
+
+// NewTransaction implements TransactionDB. It returns ErrNotSupported if the source database does
+// not support transactions.
+func (pdb *PrefixDB) NewTransaction() (Transaction, error) {
+	pdb.mtx.Lock()
+	defer pdb.mtx.Unlock()
+
+	tdb, ok := pdb.db.(TransactionDB)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	txn, err := tdb.NewTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return newPrefixTransaction(pdb.prefix, txn), nil
+}
+
 // NewBatch implements DB.
 func (pdb *PrefixDB) NewBatch() Batch {
 	pdb.mtx.Lock()
@@ -170,35 +375,77 @@ func (pdb *PrefixDB) Close() error {
 	return pdb.db.Close()
 }
 
-// Print implements DB.
-func (pdb *PrefixDB) Print() error {
-	fmt.Printf("prefix: %X\n", pdb.prefix)
+// Dump implements DB.
+func (pdb *PrefixDB) Dump(w io.Writer, opts DumpOptions) error {
+	return Dump(w, pdb, opts)
+}
 
-	itr, err := pdb.Iterator(nil, nil)
+// Stats implements DB. The statistics are those of the underlying database, with properties
+// prefixed by "prefixdb.source.".
+func (pdb *PrefixDB) Stats() (*Stats, error) {
+	source, err := pdb.db.Stats()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer itr.Close()
-	for ; itr.Valid(); itr.Next() {
-		key := itr.Key()
-		value := itr.Value()
-		fmt.Printf("[%X]:\t[%X]\n", key, value)
+	stats := *source
+	stats.Properties = make(map[string]string, len(source.Properties)+2)
+	stats.Properties["prefixdb.prefix.string"] = string(pdb.prefix)
+	stats.Properties["prefixdb.prefix.hex"] = fmt.Sprintf("%X", pdb.prefix)
+	for key, value := range source.Properties {
+		stats.Properties["prefixdb.source."+key] = value
 	}
-	return nil
+	return &stats, nil
 }
 
-// Stats implements DB.
-func (pdb *PrefixDB) Stats() map[string]string {
-	stats := make(map[string]string)
-	stats["prefixdb.prefix.string"] = string(pdb.prefix)
-	stats["prefixdb.prefix.hex"] = fmt.Sprintf("%X", pdb.prefix)
-	source := pdb.db.Stats()
-	for key, value := range source {
-		stats["prefixdb.source."+key] = value
+// Comparer implements ComparerDB. Keys are ordered as the prefixed keys are in the underlying
+// database.
+func (pdb *PrefixDB) Comparer() Comparer {
+	cmp := KeyComparer(pdb.db)
+	if IsBytesComparer(cmp) {
+		return BytesComparer
 	}
-	return stats
+	return prefixComparer{prefix: pdb.prefix, source: cmp}
 }
 
 func (pdb *PrefixDB) prefixed(key []byte) []byte {
 	return append(cp(pdb.prefix), key...)
 }
+
+// prefixComparer orders keys as a comparer orders them with a prefix.
+type prefixComparer struct {
+	prefix []byte
+	source Comparer
+}
+
+// Compare implements Comparer.
+func (c prefixComparer) Compare(a, b []byte) int {
+	return c.source.Compare(append(cp(c.prefix), a...), append(cp(c.prefix), b...))
+}
+
+// Name implements Comparer. The order is named after the underlying order, since it is the same
+// order of the prefixed keys.
+func (c prefixComparer) Name() string {
+	return c.source.Name()
+}
+
+// prefixedRange translates an iterator domain into the corresponding domain of the source
+// database, such that a nil end stops at the end of the prefix.
+func prefixedRange(prefix, start, end []byte) (pstart, pend []byte) {
+	pstart = append(cp(prefix), start...)
+	if end == nil {
+		pend = cpIncr(prefix)
+	} else {
+		pend = append(cp(prefix), end...)
+	}
+	return pstart, pend
+}
+
+// prefixedDeleteRange is like prefixedRange, but a nil start begins after the key which exactly
+// matches the prefix, since it is not visible through the PrefixDB and must not be deleted.
+func prefixedDeleteRange(prefix, start, end []byte) (pstart, pend []byte) {
+	pstart, pend = prefixedRange(prefix, start, end)
+	if start == nil {
+		pstart = append(pstart, 0)
+	}
+	return pstart, pend
+}
@@ -35,6 +35,15 @@ func (pb prefixDBBatch) Delete(key []byte) error {
 	return pb.source.Delete(pkey)
 }
 
+// DeleteRange implements Batch.
+func (pb prefixDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return ErrKeyEmpty
+	}
+	pstart, pend := prefixedDeleteRange(pb.prefix, start, end)
+	return pb.source.DeleteRange(pstart, pend)
+}
+
 // Write implements Batch.
 func (pb prefixDBBatch) Write() error {
 	return pb.source.Write()
@@ -33,7 +33,7 @@ type prefixDBIterator struct {
 	err    error
 }
 
-var _ Iterator = (*prefixDBIterator)(nil)
+var _ SeekableIterator = (*prefixDBIterator)(nil)
 
 func newPrefixIterator(prefix, start, end []byte, source Iterator) (*prefixDBIterator, error) {
 	pitrInvalid := &prefixDBIterator{
@@ -99,6 +99,24 @@ func (itr *prefixDBIterator) Next() {
 	}
 }
 
+// Seek implements SeekableIterator. It fails with ErrNotSupported if the source iterator does not
+// implement SeekableIterator.
+func (itr *prefixDBIterator) Seek(key []byte) {
+	source, ok := itr.source.(SeekableIterator)
+	if !ok {
+		itr.err = ErrNotSupported
+		return
+	}
+	source.Seek(append(cp(itr.prefix), key...))
+
+	itr.valid = source.Valid() && bytes.HasPrefix(source.Key(), itr.prefix)
+	if itr.valid && bytes.Equal(source.Key(), itr.prefix) {
+		// Empty keys are not allowed, so if a key exists in the database that exactly matches the
+		// prefix we need to skip it.
+		itr.Next()
+	}
+}
+
 // Next implements Iterator.
 func (itr *prefixDBIterator) Key() []byte {
 	itr.assertIsValid()
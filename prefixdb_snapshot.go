@@ -0,0 +1,72 @@
+package db
+
+// prefixDBReader namespaces a Reader of the source database, and is shared by PrefixDB snapshots
+// and transactions.
+type prefixDBReader struct {
+	prefix []byte
+	source Reader
+}
+
+var _ Reader = prefixDBReader{}
+
+// prefixDBSnapshot is a snapshot of a PrefixDB, which namespaces a snapshot of the source database.
+type prefixDBSnapshot struct {
+	prefixDBReader
+	source Snapshot
+}
+
+var _ Snapshot = (*prefixDBSnapshot)(nil)
+
+func newPrefixSnapshot(prefix []byte, source Snapshot) *prefixDBSnapshot {
+	return &prefixDBSnapshot{
+		prefixDBReader: prefixDBReader{prefix: prefix, source: source},
+		source:         source,
+	}
+}
+
+// Get implements Reader.
+func (pr prefixDBReader) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrKeyEmpty
+	}
+	return pr.source.Get(append(cp(pr.prefix), key...))
+}
+
+// Has implements Reader.
+func (pr prefixDBReader) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, ErrKeyEmpty
+	}
+	return pr.source.Has(append(cp(pr.prefix), key...))
+}
+
+// Iterator implements Reader.
+func (pr prefixDBReader) Iterator(start, end []byte) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, ErrKeyEmpty
+	}
+	pstart, pend := prefixedRange(pr.prefix, start, end)
+	itr, err := pr.source.Iterator(pstart, pend)
+	if err != nil {
+		return nil, err
+	}
+	return newPrefixIterator(pr.prefix, start, end, itr)
+}
+
+// ReverseIterator implements Reader.
+func (pr prefixDBReader) ReverseIterator(start, end []byte) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, ErrKeyEmpty
+	}
+	pstart, pend := prefixedRange(pr.prefix, start, end)
+	ritr, err := pr.source.ReverseIterator(pstart, pend)
+	if err != nil {
+		return nil, err
+	}
+	return newPrefixIterator(pr.prefix, start, end, ritr)
+}
+
+// Close implements Snapshot.
+func (ps *prefixDBSnapshot) Close() error {
+	return ps.source.Close()
+}
@@ -7,6 +7,7 @@ import (
 	tmdb "github.com/tendermint/tm-db"
 	"github.com/tendermint/tm-db/internal/dbtest"
 	"github.com/tendermint/tm-db/memdb"
+	"github.com/tendermint/tm-db/watchdb"
 )
 
 func mockDBWithStuff(t *testing.T) tmdb.DB {
@@ -40,6 +41,49 @@ func TestPrefixDBSimple(t *testing.T) {
 	dbtest.Value(t, pdb, []byte("kee"), nil)
 }
 
+func TestPrefixDBDeleteRange(t *testing.T) {
+	db := mockDBWithStuff(t)
+	pdb := tmdb.NewPrefixDB(db, []byte("key"))
+
+	require.NoError(t, pdb.DeleteRange([]byte("2"), nil))
+	dbtest.Value(t, pdb, []byte("1"), []byte("value1"))
+	dbtest.Value(t, pdb, []byte("2"), nil)
+	dbtest.Value(t, pdb, []byte("3"), nil)
+
+	// the key matching the prefix exactly is not visible through the prefixdb, and must be kept
+	require.NoError(t, pdb.DeleteRange(nil, nil))
+	dbtest.Value(t, pdb, []byte("1"), nil)
+	dbtest.Value(t, db, []byte("key"), []byte("value"))
+	dbtest.Value(t, db, []byte("kee"), []byte("valuu"))
+	dbtest.Value(t, db, []byte("something"), []byte("else"))
+}
+
+// compactionRecorder is a CompactionDB which records the ranges it was asked to compact.
+type compactionRecorder struct {
+	tmdb.DB
+	ranges [][2][]byte
+}
+
+func (db *compactionRecorder) Compact(start, end []byte) error {
+	db.ranges = append(db.ranges, [2][]byte{start, end})
+	return nil
+}
+
+func TestPrefixDBCompact(t *testing.T) {
+	pdb := tmdb.NewPrefixDB(mockDBWithStuff(t), []byte("key"))
+	require.Equal(t, tmdb.ErrNotSupported, pdb.Compact(nil, nil))
+
+	db := &compactionRecorder{DB: mockDBWithStuff(t)}
+	pdb = tmdb.NewPrefixDB(db, []byte("key"))
+	require.NoError(t, pdb.Compact(nil, nil))
+	require.NoError(t, pdb.Compact([]byte("1"), []byte("2")))
+	require.Equal(t, tmdb.ErrKeyEmpty, pdb.Compact([]byte{}, nil))
+	require.Equal(t, [][2][]byte{
+		{[]byte("key"), []byte("kez")},
+		{[]byte("key1"), []byte("key2")},
+	}, db.ranges)
+}
+
 func TestPrefixDBIterator1(t *testing.T) {
 	db := mockDBWithStuff(t)
 	pdb := tmdb.NewPrefixDB(db, []byte("key"))
@@ -118,3 +162,90 @@ func TestPrefixDBReverseIterator7(t *testing.T) {
 	dbtest.Invalid(t, itr)
 	itr.Close()
 }
+
+func TestPrefixDBWatch(t *testing.T) {
+	db := watchdb.NewDB(mockDBWithStuff(t))
+	pdb := tmdb.NewPrefixDB(db, []byte("key"))
+	defer pdb.Close()
+
+	w, err := pdb.Watch([]byte("1"))
+	require.NoError(t, err)
+	require.NoError(t, pdb.Set([]byte("1"), []byte("value")))
+	require.NoError(t, pdb.Set([]byte("2"), []byte("value2")))
+	require.NoError(t, db.Set([]byte("k1"), []byte("value3")))
+	require.NoError(t, pdb.Delete([]byte("1")))
+	require.Equal(t, tmdb.Event{Type: tmdb.EventSet, Key: []byte("1"), Value: []byte("value")}, <-w.Events())
+	require.Equal(t, tmdb.Event{Type: tmdb.EventDelete, Key: []byte("1")}, <-w.Events())
+
+	require.NoError(t, w.Close())
+	_, ok := <-w.Events()
+	require.False(t, ok)
+
+	_, err = tmdb.NewPrefixDB(memdb.NewDB(), []byte("key")).Watch(nil)
+	require.Equal(t, tmdb.ErrNotSupported, err)
+}
+
+// invertedComparer orders keys by their bytes, except that nonzero bytes after the first one are
+// in descending order.
+type invertedComparer struct{}
+
+func (invertedComparer) Compare(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := int(a[i]), int(b[i])
+		if i > 0 && x != 0 {
+			x = 256 - x
+		}
+		if i > 0 && y != 0 {
+			y = 256 - y
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+func (invertedComparer) Name() string {
+	return "test.InvertedComparer"
+}
+
+func TestPrefixDBComparer(t *testing.T) {
+	pdb := tmdb.NewPrefixDB(memdb.NewDBWithComparer(invertedComparer{}), []byte("p"))
+	require.Equal(t, tmdb.BytesComparer, tmdb.KeyComparer(tmdb.NewPrefixDB(memdb.NewDB(), []byte("p"))))
+
+	// the first byte of keys in the PrefixDB is not the first byte of the prefixed keys
+	cmp := tmdb.KeyComparer(pdb)
+	require.Equal(t, "test.InvertedComparer", cmp.Name())
+	require.Equal(t, 1, cmp.Compare([]byte("a"), []byte("b")))
+	require.Equal(t, -1, cmp.Compare([]byte("a"), []byte("a\x00")))
+
+	// wrappers interpret ranges of the PrefixDB in its order
+	db := watchdb.NewDB(pdb)
+	defer db.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+	w, err := db.Watch(nil)
+	require.NoError(t, err)
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte("d"), []byte("d")))
+	require.NoError(t, batch.DeleteRange([]byte("b"), nil))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	require.Equal(t, tmdb.Event{Type: tmdb.EventSet, Key: []byte("d"), Value: []byte("d")}, <-w.Events())
+	require.Equal(t, tmdb.Event{Type: tmdb.EventDelete, Key: []byte("b")}, <-w.Events())
+	require.Equal(t, tmdb.Event{Type: tmdb.EventDelete, Key: []byte("a")}, <-w.Events())
+
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	defer itr.Close()
+	keys := []string{}
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	require.NoError(t, itr.Error())
+	require.Equal(t, []string{"d", "c"}, keys)
+}
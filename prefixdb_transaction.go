@@ -0,0 +1,46 @@
+package db
+
+// prefixDBTransaction is a transaction on a PrefixDB, which namespaces a transaction on the source
+// database.
+type prefixDBTransaction struct {
+	prefixDBReader
+	source Transaction
+}
+
+var _ Transaction = (*prefixDBTransaction)(nil)
+
+func newPrefixTransaction(prefix []byte, source Transaction) *prefixDBTransaction {
+	return &prefixDBTransaction{
+		prefixDBReader: prefixDBReader{prefix: prefix, source: source},
+		source:         source,
+	}
+}
+
+// Set implements Transaction.
+func (pt *prefixDBTransaction) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrKeyEmpty
+	}
+	if value == nil {
+		return ErrValueNil
+	}
+	return pt.source.Set(append(cp(pt.prefix), key...), value)
+}
+
+// Delete implements Transaction.
+func (pt *prefixDBTransaction) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrKeyEmpty
+	}
+	return pt.source.Delete(append(cp(pt.prefix), key...))
+}
+
+// Commit implements Transaction.
+func (pt *prefixDBTransaction) Commit() error {
+	return pt.source.Commit()
+}
+
+// Close implements Transaction.
+func (pt *prefixDBTransaction) Close() error {
+	return pt.source.Close()
+}
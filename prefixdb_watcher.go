@@ -0,0 +1,57 @@
+package db
+
+import "sync"
+
+// prefixDBWatcher strips the prefix from the keys of events delivered by a watcher of the
+// underlying database.
+type prefixDBWatcher struct {
+	prefix    []byte
+	source    Watcher
+	events    chan Event
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+var _ Watcher = (*prefixDBWatcher)(nil)
+
+func newPrefixDBWatcher(prefix []byte, source Watcher) *prefixDBWatcher {
+	w := &prefixDBWatcher{
+		prefix: prefix,
+		source: source,
+		events: make(chan Event),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// run forwards events from the underlying watcher until it or this watcher is closed.
+func (w *prefixDBWatcher) run() {
+	defer close(w.events)
+	for event := range w.source.Events() {
+		event.Key = event.Key[len(w.prefix):]
+		select {
+		case w.events <- event:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Events implements Watcher.
+func (w *prefixDBWatcher) Events() <-chan Event {
+	return w.events
+}
+
+// Err implements Watcher.
+func (w *prefixDBWatcher) Err() error {
+	return w.source.Err()
+}
+
+// Close implements Watcher.
+func (w *prefixDBWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
+	return w.source.Close()
+}
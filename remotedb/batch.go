@@ -47,11 +47,31 @@ func (b *batch) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange implements Batch.
+func (b *batch) DeleteRange(start, end []byte) error {
+	// Empty keys must be rejected here, since gRPC does not distinguish them from nil keys.
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.ops == nil {
+		return tmdb.ErrBatchClosed
+	}
+	op := &protodb.Operation{
+		Entity: &protodb.Entity{Start: start, End: end},
+		Type:   protodb.Operation_DELETE_RANGE,
+	}
+	b.ops = append(b.ops, op)
+	return nil
+}
+
 // Write implements Batch.
 func (b *batch) Write() error {
 	if b.ops == nil {
 		return tmdb.ErrBatchClosed
 	}
+	if b.db.closed() {
+		return tmdb.ErrClosed
+	}
 	_, err := b.db.dc.BatchWrite(b.db.ctx, &protodb.Batch{Ops: b.ops})
 	if err != nil {
 		return fmt.Errorf("remoteDB.BatchWrite: %w", err)
@@ -66,6 +86,9 @@ func (b *batch) WriteSync() error {
 	if b.ops == nil {
 		return tmdb.ErrBatchClosed
 	}
+	if b.db.closed() {
+		return tmdb.ErrClosed
+	}
 	_, err := b.db.dc.BatchWriteSync(b.db.ctx, &protodb.Batch{Ops: b.ops})
 	if err != nil {
 		return fmt.Errorf("RemoteDB.BatchWriteSync: %w", err)
@@ -2,8 +2,8 @@ package remotedb
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io"
 
 	tmdb "github.com/tendermint/tm-db"
 	"github.com/tendermint/tm-db/remotedb/grpcdb"
@@ -11,8 +11,9 @@ import (
 )
 
 type RemoteDB struct {
-	ctx context.Context
-	dc  protodb.DBClient
+	ctx    context.Context
+	cancel context.CancelFunc // cancels ctx on Close, aborting any open streams
+	dc     protodb.DBClient
 }
 
 func NewDB(serverAddr string, serverKey string) (*RemoteDB, error) {
@@ -23,7 +24,8 @@ func newDB(gdc protodb.DBClient, err error) (*RemoteDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &RemoteDB{dc: gdc, ctx: context.Background()}, nil
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RemoteDB{dc: gdc, ctx: ctx, cancel: cancel}, nil
 }
 
 type Init struct {
@@ -37,14 +39,31 @@ func (rd *RemoteDB) InitRemote(in *Init) error {
 	return err
 }
 
-var _ tmdb.DB = (*RemoteDB)(nil)
+var (
+	_ tmdb.MultiGetDB     = (*RemoteDB)(nil)
+	_ tmdb.WatchableDB    = (*RemoteDB)(nil)
+	_ tmdb.KeysIteratorDB = (*RemoteDB)(nil)
+)
 
-// Close is a noop currently
+// Close implements DB. It aborts open iterators and watchers, but leaves the server's database
+// open, since it may be shared with other clients.
 func (rd *RemoteDB) Close() error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
+	rd.cancel()
 	return nil
 }
 
+// closed returns whether the database has been closed.
+func (rd *RemoteDB) closed() bool {
+	return rd.ctx.Err() != nil
+}
+
 func (rd *RemoteDB) Delete(key []byte) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
 	if _, err := rd.dc.Delete(rd.ctx, &protodb.Entity{Key: key}); err != nil {
 		return fmt.Errorf("remoteDB.Delete: %w", err)
 	}
@@ -52,13 +71,94 @@ func (rd *RemoteDB) Delete(key []byte) error {
 }
 
 func (rd *RemoteDB) DeleteSync(key []byte) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
 	if _, err := rd.dc.DeleteSync(rd.ctx, &protodb.Entity{Key: key}); err != nil {
 		return fmt.Errorf("remoteDB.DeleteSync: %w", err)
 	}
 	return nil
 }
 
+func (rd *RemoteDB) DeleteRange(start, end []byte) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
+	// Empty keys must be rejected here, since gRPC does not distinguish them from nil keys.
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if _, err := rd.dc.DeleteRange(rd.ctx, &protodb.Entity{Start: start, End: end}); err != nil {
+		return fmt.Errorf("remoteDB.DeleteRange: %w", err)
+	}
+	return nil
+}
+
+// Update implements DB. The update function cannot run on the server, so the key is read and then
+// written with compareAndSwap, retrying until the key was not changed in between.
+func (rd *RemoteDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	for {
+		current, err := rd.Get(key)
+		if err != nil {
+			return err
+		}
+		// Get returns nil for empty values, which must be distinguished from missing keys.
+		if current == nil {
+			exists, err := rd.Has(key)
+			if err != nil {
+				return err
+			}
+			if exists {
+				current = []byte{}
+			}
+		}
+		value, del, err := fn(current)
+		switch {
+		case err != nil:
+			return err
+		case del:
+			value = nil
+		case value == nil:
+			return tmdb.ErrValueNil
+		}
+		swapped, err := rd.CompareAndSwap(key, current, value)
+		if err != nil || swapped {
+			return err
+		}
+	}
+}
+
+func (rd *RemoteDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if rd.closed() {
+		return false, tmdb.ErrClosed
+	}
+	// Empty keys must be rejected here, since gRPC does not distinguish them from nil keys.
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	res, err := rd.dc.CompareAndSwap(rd.ctx, &protodb.Swap{
+		Key:           key,
+		Expected:      expected,
+		ExpectMissing: expected == nil,
+		Value:         value,
+		Delete:        value == nil,
+	})
+	if err != nil {
+		return false, fmt.Errorf("remoteDB.CompareAndSwap: %w", err)
+	}
+	return res.Exists, nil
+}
+
 func (rd *RemoteDB) Set(key, value []byte) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
 	if _, err := rd.dc.Set(rd.ctx, &protodb.Entity{Key: key, Value: value}); err != nil {
 		return fmt.Errorf("remoteDB.Set: %w", err)
 	}
@@ -66,6 +166,9 @@ func (rd *RemoteDB) Set(key, value []byte) error {
 }
 
 func (rd *RemoteDB) SetSync(key, value []byte) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
 	if _, err := rd.dc.SetSync(rd.ctx, &protodb.Entity{Key: key, Value: value}); err != nil {
 		return fmt.Errorf("remoteDB.SetSync: %w", err)
 	}
@@ -73,14 +176,79 @@ func (rd *RemoteDB) SetSync(key, value []byte) error {
 }
 
 func (rd *RemoteDB) Get(key []byte) ([]byte, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
 	res, err := rd.dc.Get(rd.ctx, &protodb.Entity{Key: key})
 	if err != nil {
 		return nil, fmt.Errorf("remoteDB.Get error: %w", err)
 	}
+	if res.Exists && res.Value == nil {
+		return []byte{}, nil
+	}
 	return res.Value, nil
 }
 
+// GetMany implements MultiGetDB, fetching all keys over a single getStream call rather than making
+// a call per key. The keys are not read from a consistent view of the database.
+func (rd *RemoteDB) GetMany(keys [][]byte) ([][]byte, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, tmdb.ErrKeyEmpty
+		}
+	}
+	values := make([][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	// Cancelling the context aborts the stream if we return early.
+	ctx, cancel := context.WithCancel(rd.ctx)
+	defer cancel()
+	stream, err := rd.dc.GetStream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("remoteDB.GetMany: %w", err)
+	}
+
+	// The server stops receiving keys while its responses are not received, so keys must be sent
+	// concurrently with receiving values.
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, key := range keys {
+			if err := stream.Send(&protodb.Entity{Key: key}); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	for i := range keys {
+		res, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("remoteDB.GetMany: %w", err)
+		}
+		if res.Err != "" {
+			return nil, fmt.Errorf("remoteDB.GetMany: %v", res.Err)
+		}
+		values[i] = res.Value
+		if res.Exists && res.Value == nil {
+			values[i] = []byte{}
+		}
+	}
+	if err := <-sendErr; err != nil {
+		return nil, fmt.Errorf("remoteDB.GetMany: %w", err)
+	}
+	return values, nil
+}
+
 func (rd *RemoteDB) Has(key []byte) (bool, error) {
+	if rd.closed() {
+		return false, tmdb.ErrClosed
+	}
 	res, err := rd.dc.Has(rd.ctx, &protodb.Entity{Key: key})
 	if err != nil {
 		return false, err
@@ -89,6 +257,9 @@ func (rd *RemoteDB) Has(key []byte) (bool, error) {
 }
 
 func (rd *RemoteDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
 	dic, err := rd.dc.ReverseIterator(rd.ctx, &protodb.Entity{Start: start, End: end})
 	if err != nil {
 		return nil, fmt.Errorf("RemoteDB.Iterator error: %w", err)
@@ -96,28 +267,107 @@ func (rd *RemoteDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	return makeReverseIterator(dic), nil
 }
 
+func (rd *RemoteDB) NewSnapshot() (tmdb.Snapshot, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
+	res, err := rd.dc.NewSnapshot(rd.ctx, &protodb.Nothing{})
+	if err != nil {
+		return nil, fmt.Errorf("remoteDB.NewSnapshot: %w", err)
+	}
+	return newSnapshot(rd, res.Id), nil
+}
+
 func (rd *RemoteDB) NewBatch() tmdb.Batch {
 	return newBatch(rd)
 }
 
-// TODO: Implement Print when tmdb.DB implements a method
-// to print to a string and not db.Print to stdout.
-func (rd *RemoteDB) Print() error {
-	return errors.New("remoteDB.Print: unimplemented")
+// Dump implements DB, using the dump RPC.
+func (rd *RemoteDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	if rd.closed() {
+		return tmdb.ErrClosed
+	}
+	// Empty keys must be rejected here, since gRPC does not distinguish them from nil keys.
+	if (opts.Start != nil && len(opts.Start) == 0) || (opts.End != nil && len(opts.End) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	// Cancelling the context aborts the stream if we return early.
+	ctx, cancel := context.WithCancel(rd.ctx)
+	defer cancel()
+	stream, err := rd.dc.Dump(ctx, &protodb.DumpOptions{
+		Start:        opts.Start,
+		End:          opts.End,
+		Format:       int32(opts.Format),
+		MaxValueSize: int64(opts.MaxValueSize),
+	})
+	if err != nil {
+		return fmt.Errorf("remoteDB.Dump: %w", err)
+	}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("remoteDB.Dump: %w", err)
+		}
+		if _, err := w.Write(res.Value); err != nil {
+			return err
+		}
+	}
 }
 
-func (rd *RemoteDB) Stats() map[string]string {
-	stats, err := rd.dc.Stats(rd.ctx, &protodb.Nothing{})
-	if err != nil || stats == nil {
-		return nil
+func (rd *RemoteDB) Stats() (*tmdb.Stats, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
+	res, err := rd.dc.Stats(rd.ctx, &protodb.Nothing{})
+	if err != nil {
+		return nil, fmt.Errorf("remoteDB.Stats: %w", err)
 	}
-	return stats.Data
+	return &tmdb.Stats{
+		Backend:       res.Backend,
+		KeyCount:      res.KeyCount,
+		DiskSize:      res.DiskSize,
+		MemTableSize:  res.MemTableSize,
+		CacheSize:     res.CacheSize,
+		OpenIterators: res.OpenIterators,
+		Compactions:   res.Compactions,
+		Properties:    res.Data,
+	}, nil
 }
 
 func (rd *RemoteDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
 	dic, err := rd.dc.Iterator(rd.ctx, &protodb.Entity{Start: start, End: end})
 	if err != nil {
 		return nil, fmt.Errorf("RemoteDB.Iterator error: %w", err)
 	}
 	return makeIterator(dic), nil
 }
+
+// KeysIterator implements KeysIteratorDB, using the iterator RPC without sending values.
+func (rd *RemoteDB) KeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
+	dic, err := rd.dc.Iterator(rd.ctx, &protodb.Entity{Start: start, End: end, KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("RemoteDB.KeysIterator error: %w", err)
+	}
+	return makeIterator(dic), nil
+}
+
+// ReverseKeysIterator implements KeysIteratorDB, using the reverseIterator RPC without sending
+// values.
+func (rd *RemoteDB) ReverseKeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
+	dic, err := rd.dc.ReverseIterator(rd.ctx, &protodb.Entity{Start: start, End: end, KeysOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("RemoteDB.ReverseKeysIterator error: %w", err)
+	}
+	return makeReverseIterator(dic), nil
+}
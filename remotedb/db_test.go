@@ -1,6 +1,8 @@
 package remotedb_test
 
 import (
+	"bytes"
+	"fmt"
 	"net"
 	"os"
 	"testing"
@@ -8,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	tmdb "github.com/tendermint/tm-db"
 	"github.com/tendermint/tm-db/remotedb"
 	"github.com/tendermint/tm-db/remotedb/grpcdb"
 )
@@ -164,4 +167,166 @@ func TestRemoteDB(t *testing.T) {
 	rv5, err := client.Get(k5)
 	require.NoError(t, err)
 	require.Equal(t, rv5, v5, "expecting k5 to have been stored")
+
+	// Range deletes
+	err = client.Set(k1, vv1)
+	require.NoError(t, err)
+	bat = client.NewBatch()
+	err = bat.DeleteRange(k4, nil)
+	require.NoError(t, err)
+	err = bat.Set(k4, v4)
+	require.NoError(t, err)
+	err = bat.Write()
+	require.NoError(t, err)
+	rv5, err = client.Get(k5)
+	require.NoError(t, err)
+	require.Nil(t, rv5, "expecting k5 to have been deleted")
+	err = client.DeleteRange(nil, k5)
+	require.NoError(t, err)
+	gv1, err = client.Get(k1)
+	require.NoError(t, err)
+	require.Nil(t, gv1, "expecting k1 to have been deleted")
+	err = client.DeleteRange([]byte{}, nil)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	err = client.Set(k5, v5)
+	require.NoError(t, err)
+
+	// Multi-key gets
+	values, err := client.GetMany([][]byte{k5, k1, k5})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{v5, nil, v5}, values)
+	_, err = client.GetMany([][]byte{k5, {}})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	// Compare-and-swap and updates
+	swapped, err := client.CompareAndSwap(k1, nil, vv1)
+	require.NoError(t, err)
+	require.True(t, swapped, "expecting k1 to be claimed")
+	swapped, err = client.CompareAndSwap(k1, nil, v5)
+	require.NoError(t, err)
+	require.False(t, swapped, "expecting k1 to already exist")
+	swapped, err = client.CompareAndSwap(k1, vv1, []byte{})
+	require.NoError(t, err)
+	require.True(t, swapped, "expecting k1 to be swapped")
+	err = client.Update(k1, func(value []byte) ([]byte, bool, error) {
+		require.Equal(t, []byte{}, value, "expecting k1 to be empty")
+		return v5, false, nil
+	})
+	require.NoError(t, err)
+	gv1, err = client.Get(k1)
+	require.NoError(t, err)
+	require.Equal(t, v5, gv1, "expecting k1 to have been updated")
+	swapped, err = client.CompareAndSwap(k1, v5, nil)
+	require.NoError(t, err)
+	require.True(t, swapped, "expecting k1 to be deleted")
+	has, err = client.Has(k1)
+	require.NoError(t, err)
+	require.False(t, has, "expecting k1 to have been deleted")
+	_, err = client.CompareAndSwap([]byte{}, nil, v5)
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	// Snapshots
+	snapshot, err := client.NewSnapshot()
+	require.NoError(t, err)
+	err = client.Set(k5, v3)
+	require.NoError(t, err)
+	err = client.Set(k4, v4)
+	require.NoError(t, err)
+
+	sv5, err := snapshot.Get(k5)
+	require.NoError(t, err)
+	require.Equal(t, sv5, v5, "expecting snapshot to have the old k5")
+	has, err = snapshot.Has(k4)
+	require.NoError(t, err)
+	require.False(t, has, "expecting snapshot not to have k4")
+
+	itr, err = snapshot.Iterator(nil, nil)
+	require.NoError(t, err)
+	require.True(t, itr.Valid())
+	require.Equal(t, k5, itr.Key())
+	require.Equal(t, v5, itr.Value())
+	itr.Next()
+	require.False(t, itr.Valid())
+	itr.Close()
+
+	err = snapshot.Close()
+	require.NoError(t, err)
+	_, err = snapshot.Get(k5)
+	require.Error(t, err, "expecting a released snapshot to be unknown")
+
+	// Watches
+	watcher, err := client.Watch([]byte("key-"))
+	require.NoError(t, err)
+	err = client.Set(k1, vv1)
+	require.NoError(t, err)
+	err = client.Set([]byte("other"), v5)
+	require.NoError(t, err)
+	bat = client.NewBatch()
+	err = bat.Delete(k1)
+	require.NoError(t, err)
+	err = bat.Set(k2, v4)
+	require.NoError(t, err)
+	err = bat.Write()
+	require.NoError(t, err)
+	for _, expect := range []tmdb.Event{
+		{Type: tmdb.EventSet, Key: k1, Value: vv1},
+		{Type: tmdb.EventDelete, Key: k1},
+		{Type: tmdb.EventSet, Key: k2, Value: v4},
+	} {
+		require.Equal(t, expect, <-watcher.Events())
+	}
+	err = watcher.Close()
+	require.NoError(t, err)
+	_, ok := <-watcher.Events()
+	require.False(t, ok, "expecting the events channel to be closed")
+	require.NoError(t, watcher.Err())
+
+	// Stats
+	stats, err := client.Stats()
+	require.NoError(t, err)
+	require.Equal(t, "goleveldb", stats.Backend)
+	require.NotEmpty(t, stats.Properties["leveldb.stats"], "expecting backend-specific stats")
+
+	// Key iteration
+	itr, err = tmdb.KeysIterator(client, k1, k3)
+	require.NoError(t, err)
+	require.True(t, itr.Valid())
+	require.Equal(t, k2, itr.Key())
+	require.Nil(t, itr.Value(), "expecting no values")
+	itr.Next()
+	require.False(t, itr.Valid())
+	itr.Close()
+
+	// Dumps
+	buf := &bytes.Buffer{}
+	err = client.Dump(buf, tmdb.DumpOptions{Start: k2, End: k3, Format: tmdb.DumpRaw})
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("%q\t%q\n", k2, v4), buf.String())
+	err = client.Dump(buf, tmdb.DumpOptions{Start: []byte{}})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	// Strict reads
+	err = client.Update(k3, func([]byte) ([]byte, bool, error) {
+		return []byte{}, false, nil
+	})
+	require.NoError(t, err)
+	sv3, err := tmdb.GetStrict(client, k3)
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, sv3, "expecting k3 to exist but be empty")
+	_, err = tmdb.GetStrict(client, []byte("missing"))
+	require.Equal(t, tmdb.ErrNotFound, err)
+
+	// Closing
+	err = client.Close()
+	require.NoError(t, err)
+	_, err = client.Get(k2)
+	require.Equal(t, tmdb.ErrClosed, err)
+	err = client.Set(k2, v2)
+	require.Equal(t, tmdb.ErrClosed, err)
+	_, err = client.Iterator(nil, nil)
+	require.Equal(t, tmdb.ErrClosed, err)
+	_, err = client.Stats()
+	require.Equal(t, tmdb.ErrClosed, err)
+	err = client.Close()
+	require.Equal(t, tmdb.ErrClosed, err)
 }
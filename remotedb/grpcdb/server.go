@@ -2,16 +2,19 @@ package grpcdb
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 
 	db "github.com/tendermint/tm-db"
 	"github.com/tendermint/tm-db/metadb"
 	protodb "github.com/tendermint/tm-db/remotedb/proto"
+	"github.com/tendermint/tm-db/watchdb"
 )
 
 // ListenAndServe is a blocking function that sets up a gRPC based
@@ -43,6 +46,9 @@ func NewServer(cert, key string, opts ...grpc.ServerOption) (*grpc.Server, error
 type server struct {
 	mu sync.Mutex
 	db db.DB
+
+	snapshots      map[int32]db.Snapshot
+	lastSnapshotID int32
 }
 
 var _ protodb.DBServer = (*server)(nil)
@@ -64,11 +70,12 @@ func (s *server) Init(ctx context.Context, in *protodb.Init) (*protodb.Entity, e
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	var err error
-	s.db, err = metadb.NewDB(in.Name, metadb.BackendType(in.Type), in.Dir)
+	database, err := metadb.NewDB(in.Name, metadb.BackendType(in.Type), in.Dir)
 	if err != nil {
 		return nil, err
 	}
+	// Writes go through a WatchDB, so that clients can watch changes.
+	s.db = watchdb.NewDB(database)
 	return &protodb.Entity{CreatedAt: time.Now().Unix()}, nil
 }
 
@@ -90,12 +97,41 @@ func (s *server) DeleteSync(ctx context.Context, in *protodb.Entity) (*protodb.N
 	return nothing, nil
 }
 
+func (s *server) DeleteRange(ctx context.Context, in *protodb.Entity) (*protodb.Nothing, error) {
+	err := s.db.DeleteRange(in.Start, in.End)
+	if err != nil {
+		return nil, err
+	}
+	return nothing, nil
+}
+
+func (s *server) CompareAndSwap(ctx context.Context, in *protodb.Swap) (*protodb.Entity, error) {
+	// Empty and missing bytes are indistinguishable in protobuf, so nil values are flagged instead.
+	expected, value := in.Expected, in.Value
+	if in.ExpectMissing {
+		expected = nil
+	} else if expected == nil {
+		expected = []byte{}
+	}
+	if in.Delete {
+		value = nil
+	} else if value == nil {
+		value = []byte{}
+	}
+	swapped, err := s.db.CompareAndSwap(in.Key, expected, value)
+	if err != nil {
+		return nil, err
+	}
+	return &protodb.Entity{Exists: swapped}, nil
+}
+
 func (s *server) Get(ctx context.Context, in *protodb.Entity) (*protodb.Entity, error) {
 	value, err := s.db.Get(in.Key)
 	if err != nil {
 		return nil, err
 	}
-	return &protodb.Entity{Value: value}, nil
+	// Empty values are sent as nil, so existence is flagged separately.
+	return &protodb.Entity{Value: value, Exists: value != nil}, nil
 }
 
 func (s *server) GetStream(ds protodb.DB_GetStreamServer) error {
@@ -160,7 +196,13 @@ func (s *server) SetSync(ctx context.Context, in *protodb.Entity) (*protodb.Noth
 }
 
 func (s *server) Iterator(query *protodb.Entity, dis protodb.DB_IteratorServer) error {
-	it, err := s.db.Iterator(query.Start, query.End)
+	iterator := s.db.Iterator
+	if query.KeysOnly {
+		iterator = func(start, end []byte) (db.Iterator, error) {
+			return db.KeysIterator(s.db, start, end)
+		}
+	}
+	it, err := iterator(query.Start, query.End)
 	if err != nil {
 		return err
 	}
@@ -192,7 +234,13 @@ func (s *server) handleIterator(it db.Iterator, sendFunc func(*protodb.Iterator)
 }
 
 func (s *server) ReverseIterator(query *protodb.Entity, dis protodb.DB_ReverseIteratorServer) error {
-	it, err := s.db.ReverseIterator(query.Start, query.End)
+	iterator := s.db.ReverseIterator
+	if query.KeysOnly {
+		iterator = func(start, end []byte) (db.Iterator, error) {
+			return db.ReverseKeysIterator(s.db, start, end)
+		}
+	}
+	it, err := iterator(query.Start, query.End)
 	if err != nil {
 		return err
 	}
@@ -200,9 +248,180 @@ func (s *server) ReverseIterator(query *protodb.Entity, dis protodb.DB_ReverseIt
 	return s.handleIterator(it, dis.Send)
 }
 
+// Watch streams changes to keys with the prefix given as the key, until the client cancels the
+// call. Headers are sent once the watcher is in place, so that clients can wait for them to know
+// that later changes will be delivered.
+func (s *server) Watch(in *protodb.Entity, ws protodb.DB_WatchServer) error {
+	wdb, ok := s.db.(db.WatchableDB)
+	if !ok {
+		return db.ErrNotSupported
+	}
+	watcher, err := wdb.Watch(in.Key)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := ws.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ws.Context().Done():
+			return ws.Context().Err()
+		case event, ok := <-watcher.Events():
+			if !ok {
+				return watcher.Err()
+			}
+			op := &protodb.Operation{
+				Entity: &protodb.Entity{Key: event.Key, Value: event.Value},
+				Type:   protodb.Operation_SET,
+			}
+			if event.Type == db.EventDelete {
+				op.Type = protodb.Operation_DELETE
+			}
+			if err := ws.Send(op); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// NewSnapshot takes a snapshot of the database, which is identified by the returned Id until it
+// is released with ReleaseSnapshot.
+func (s *server) NewSnapshot(ctx context.Context, in *protodb.Nothing) (*protodb.Entity, error) {
+	snapshot, err := s.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshots == nil {
+		s.snapshots = make(map[int32]db.Snapshot)
+	}
+	s.lastSnapshotID++
+	s.snapshots[s.lastSnapshotID] = snapshot
+	return &protodb.Entity{Id: s.lastSnapshotID, CreatedAt: time.Now().Unix()}, nil
+}
+
+func (s *server) snapshot(id int32) (db.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, ok := s.snapshots[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown snapshot %v", id)
+	}
+	return snapshot, nil
+}
+
+func (s *server) SnapshotGet(ctx context.Context, in *protodb.Entity) (*protodb.Entity, error) {
+	snapshot, err := s.snapshot(in.Id)
+	if err != nil {
+		return nil, err
+	}
+	value, err := snapshot.Get(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &protodb.Entity{Value: value, Exists: value != nil}, nil
+}
+
+func (s *server) SnapshotHas(ctx context.Context, in *protodb.Entity) (*protodb.Entity, error) {
+	snapshot, err := s.snapshot(in.Id)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := snapshot.Has(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &protodb.Entity{Exists: exists}, nil
+}
+
+func (s *server) SnapshotIterator(query *protodb.Entity, dis protodb.DB_SnapshotIteratorServer) error {
+	snapshot, err := s.snapshot(query.Id)
+	if err != nil {
+		return err
+	}
+	it, err := snapshot.Iterator(query.Start, query.End)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	return s.handleIterator(it, dis.Send)
+}
+
+func (s *server) SnapshotReverseIterator(query *protodb.Entity, dis protodb.DB_SnapshotReverseIteratorServer) error {
+	snapshot, err := s.snapshot(query.Id)
+	if err != nil {
+		return err
+	}
+	it, err := snapshot.ReverseIterator(query.Start, query.End)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	return s.handleIterator(it, dis.Send)
+}
+
+func (s *server) ReleaseSnapshot(ctx context.Context, in *protodb.Entity) (*protodb.Nothing, error) {
+	s.mu.Lock()
+	snapshot, ok := s.snapshots[in.Id]
+	delete(s.snapshots, in.Id)
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown snapshot %v", in.Id)
+	}
+	if err := snapshot.Close(); err != nil {
+		return nil, err
+	}
+	return nothing, nil
+}
+
+// Dump streams the output of the database's Dump to the client, in chunks.
+func (s *server) Dump(in *protodb.DumpOptions, ds protodb.DB_DumpServer) error {
+	opts := db.DumpOptions{
+		Start:        in.Start,
+		End:          in.End,
+		Format:       db.DumpFormat(in.Format),
+		MaxValueSize: int(in.MaxValueSize),
+	}
+	return s.db.Dump(dumpWriter{ds}, opts)
+}
+
+// dumpWriter is an io.Writer which sends each write as the value of an Entity.
+type dumpWriter struct {
+	ds protodb.DB_DumpServer
+}
+
+// Write implements io.Writer. The message is serialized before Send returns, so the buffer
+// is not retained.
+func (w dumpWriter) Write(p []byte) (int, error) {
+	if err := w.ds.Send(&protodb.Entity{Value: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 func (s *server) Stats(context.Context, *protodb.Nothing) (*protodb.Stats, error) {
-	stats := s.db.Stats()
-	return &protodb.Stats{Data: stats, TimeAt: time.Now().Unix()}, nil
+	stats, err := s.db.Stats()
+	if err != nil {
+		return nil, err
+	}
+	return &protodb.Stats{
+		Data:          stats.Properties,
+		TimeAt:        time.Now().Unix(),
+		Backend:       stats.Backend,
+		KeyCount:      stats.KeyCount,
+		DiskSize:      stats.DiskSize,
+		MemTableSize:  stats.MemTableSize,
+		CacheSize:     stats.CacheSize,
+		OpenIterators: stats.OpenIterators,
+		Compactions:   stats.Compactions,
+	}, nil
 }
 
 func (s *server) BatchWrite(c context.Context, b *protodb.Batch) (*protodb.Nothing, error) {
@@ -228,6 +447,11 @@ func (s *server) batchWrite(c context.Context, b *protodb.Batch, sync bool) (*pr
 			if err != nil {
 				return nil, err
 			}
+		case protodb.Operation_DELETE_RANGE:
+			err := bat.DeleteRange(op.Entity.Start, op.Entity.End)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	if sync {
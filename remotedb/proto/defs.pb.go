@@ -31,16 +31,20 @@ type Operation_Type int32
 const (
 	Operation_SET    Operation_Type = 0
 	Operation_DELETE Operation_Type = 1
+	// DELETE_RANGE uses the start and end of the entity.
+	Operation_DELETE_RANGE Operation_Type = 2
 )
 
 var Operation_Type_name = map[int32]string{
 	0: "SET",
 	1: "DELETE",
+	2: "DELETE_RANGE",
 }
 
 var Operation_Type_value = map[string]int32{
-	"SET":    0,
-	"DELETE": 1,
+	"SET":          0,
+	"DELETE":       1,
+	"DELETE_RANGE": 2,
 }
 
 func (x Operation_Type) String() string {
@@ -136,14 +140,16 @@ func (m *Operation) GetType() Operation_Type {
 }
 
 type Entity struct {
-	Id                   int32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Key                  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
-	Exists               bool     `protobuf:"varint,4,opt,name=exists,proto3" json:"exists,omitempty"`
-	Start                []byte   `protobuf:"bytes,5,opt,name=start,proto3" json:"start,omitempty"`
-	End                  []byte   `protobuf:"bytes,6,opt,name=end,proto3" json:"end,omitempty"`
-	Err                  string   `protobuf:"bytes,7,opt,name=err,proto3" json:"err,omitempty"`
-	CreatedAt            int64    `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Id        int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key       []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value     []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Exists    bool   `protobuf:"varint,4,opt,name=exists,proto3" json:"exists,omitempty"`
+	Start     []byte `protobuf:"bytes,5,opt,name=start,proto3" json:"start,omitempty"`
+	End       []byte `protobuf:"bytes,6,opt,name=end,proto3" json:"end,omitempty"`
+	Err       string `protobuf:"bytes,7,opt,name=err,proto3" json:"err,omitempty"`
+	CreatedAt int64  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// keys_only requests iterators without values.
+	KeysOnly             bool     `protobuf:"varint,9,opt,name=keys_only,json=keysOnly,proto3" json:"keys_only,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -229,6 +235,13 @@ func (m *Entity) GetCreatedAt() int64 {
 	return 0
 }
 
+func (m *Entity) GetKeysOnly() bool {
+	if m != nil {
+		return m.KeysOnly
+	}
+	return false
+}
+
 type Nothing struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -259,6 +272,78 @@ func (m *Nothing) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Nothing proto.InternalMessageInfo
 
+// Swap is a compare-and-swap request. Since empty bytes cannot be distinguished from missing ones,
+// expect_missing requires the key not to exist, and delete deletes it.
+type Swap struct {
+	Key                  []byte   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Expected             []byte   `protobuf:"bytes,2,opt,name=expected,proto3" json:"expected,omitempty"`
+	ExpectMissing        bool     `protobuf:"varint,3,opt,name=expect_missing,json=expectMissing,proto3" json:"expect_missing,omitempty"`
+	Value                []byte   `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Delete               bool     `protobuf:"varint,5,opt,name=delete,proto3" json:"delete,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Swap) Reset()         { *m = Swap{} }
+func (m *Swap) String() string { return proto.CompactTextString(m) }
+func (*Swap) ProtoMessage()    {}
+func (*Swap) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef1eada6618d0075, []int{4}
+}
+func (m *Swap) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Swap.Unmarshal(m, b)
+}
+func (m *Swap) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Swap.Marshal(b, m, deterministic)
+}
+func (m *Swap) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Swap.Merge(m, src)
+}
+func (m *Swap) XXX_Size() int {
+	return xxx_messageInfo_Swap.Size(m)
+}
+func (m *Swap) XXX_DiscardUnknown() {
+	xxx_messageInfo_Swap.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Swap proto.InternalMessageInfo
+
+func (m *Swap) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *Swap) GetExpected() []byte {
+	if m != nil {
+		return m.Expected
+	}
+	return nil
+}
+
+func (m *Swap) GetExpectMissing() bool {
+	if m != nil {
+		return m.ExpectMissing
+	}
+	return false
+}
+
+func (m *Swap) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Swap) GetDelete() bool {
+	if m != nil {
+		return m.Delete
+	}
+	return false
+}
+
 type Domain struct {
 	Start                []byte   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
 	End                  []byte   `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
@@ -271,7 +356,7 @@ func (m *Domain) Reset()         { *m = Domain{} }
 func (m *Domain) String() string { return proto.CompactTextString(m) }
 func (*Domain) ProtoMessage()    {}
 func (*Domain) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ef1eada6618d0075, []int{4}
+	return fileDescriptor_ef1eada6618d0075, []int{5}
 }
 func (m *Domain) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Domain.Unmarshal(m, b)
@@ -319,7 +404,7 @@ func (m *Iterator) Reset()         { *m = Iterator{} }
 func (m *Iterator) String() string { return proto.CompactTextString(m) }
 func (*Iterator) ProtoMessage()    {}
 func (*Iterator) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ef1eada6618d0075, []int{5}
+	return fileDescriptor_ef1eada6618d0075, []int{6}
 }
 func (m *Iterator) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Iterator.Unmarshal(m, b)
@@ -370,6 +455,13 @@ func (m *Iterator) GetValue() []byte {
 type Stats struct {
 	Data                 map[string]string `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	TimeAt               int64             `protobuf:"varint,2,opt,name=time_at,json=timeAt,proto3" json:"time_at,omitempty"`
+	Backend              string            `protobuf:"bytes,3,opt,name=backend,proto3" json:"backend,omitempty"`
+	KeyCount             int64             `protobuf:"varint,4,opt,name=key_count,json=keyCount,proto3" json:"key_count,omitempty"`
+	DiskSize             int64             `protobuf:"varint,5,opt,name=disk_size,json=diskSize,proto3" json:"disk_size,omitempty"`
+	MemTableSize         int64             `protobuf:"varint,6,opt,name=mem_table_size,json=memTableSize,proto3" json:"mem_table_size,omitempty"`
+	CacheSize            int64             `protobuf:"varint,7,opt,name=cache_size,json=cacheSize,proto3" json:"cache_size,omitempty"`
+	OpenIterators        int64             `protobuf:"varint,8,opt,name=open_iterators,json=openIterators,proto3" json:"open_iterators,omitempty"`
+	Compactions          int64             `protobuf:"varint,9,opt,name=compactions,proto3" json:"compactions,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
 	XXX_sizecache        int32             `json:"-"`
@@ -379,7 +471,7 @@ func (m *Stats) Reset()         { *m = Stats{} }
 func (m *Stats) String() string { return proto.CompactTextString(m) }
 func (*Stats) ProtoMessage()    {}
 func (*Stats) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ef1eada6618d0075, []int{6}
+	return fileDescriptor_ef1eada6618d0075, []int{7}
 }
 func (m *Stats) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Stats.Unmarshal(m, b)
@@ -413,6 +505,117 @@ func (m *Stats) GetTimeAt() int64 {
 	return 0
 }
 
+func (m *Stats) GetBackend() string {
+	if m != nil {
+		return m.Backend
+	}
+	return ""
+}
+
+func (m *Stats) GetKeyCount() int64 {
+	if m != nil {
+		return m.KeyCount
+	}
+	return 0
+}
+
+func (m *Stats) GetDiskSize() int64 {
+	if m != nil {
+		return m.DiskSize
+	}
+	return 0
+}
+
+func (m *Stats) GetMemTableSize() int64 {
+	if m != nil {
+		return m.MemTableSize
+	}
+	return 0
+}
+
+func (m *Stats) GetCacheSize() int64 {
+	if m != nil {
+		return m.CacheSize
+	}
+	return 0
+}
+
+func (m *Stats) GetOpenIterators() int64 {
+	if m != nil {
+		return m.OpenIterators
+	}
+	return 0
+}
+
+func (m *Stats) GetCompactions() int64 {
+	if m != nil {
+		return m.Compactions
+	}
+	return 0
+}
+
+type DumpOptions struct {
+	Start                []byte   `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End                  []byte   `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	Format               int32    `protobuf:"varint,3,opt,name=format,proto3" json:"format,omitempty"`
+	MaxValueSize         int64    `protobuf:"varint,4,opt,name=max_value_size,json=maxValueSize,proto3" json:"max_value_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DumpOptions) Reset()         { *m = DumpOptions{} }
+func (m *DumpOptions) String() string { return proto.CompactTextString(m) }
+func (*DumpOptions) ProtoMessage()    {}
+func (*DumpOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ef1eada6618d0075, []int{8}
+}
+func (m *DumpOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DumpOptions.Unmarshal(m, b)
+}
+func (m *DumpOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DumpOptions.Marshal(b, m, deterministic)
+}
+func (m *DumpOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DumpOptions.Merge(m, src)
+}
+func (m *DumpOptions) XXX_Size() int {
+	return xxx_messageInfo_DumpOptions.Size(m)
+}
+func (m *DumpOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_DumpOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DumpOptions proto.InternalMessageInfo
+
+func (m *DumpOptions) GetStart() []byte {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *DumpOptions) GetEnd() []byte {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+func (m *DumpOptions) GetFormat() int32 {
+	if m != nil {
+		return m.Format
+	}
+	return 0
+}
+
+func (m *DumpOptions) GetMaxValueSize() int64 {
+	if m != nil {
+		return m.MaxValueSize
+	}
+	return 0
+}
+
 type Init struct {
 	Type                 string   `protobuf:"bytes,1,opt,name=Type,proto3" json:"Type,omitempty"`
 	Name                 string   `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
@@ -426,7 +629,7 @@ func (m *Init) Reset()         { *m = Init{} }
 func (m *Init) String() string { return proto.CompactTextString(m) }
 func (*Init) ProtoMessage()    {}
 func (*Init) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ef1eada6618d0075, []int{7}
+	return fileDescriptor_ef1eada6618d0075, []int{9}
 }
 func (m *Init) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Init.Unmarshal(m, b)
@@ -473,59 +676,83 @@ func init() {
 	proto.RegisterType((*Operation)(nil), "protodb.Operation")
 	proto.RegisterType((*Entity)(nil), "protodb.Entity")
 	proto.RegisterType((*Nothing)(nil), "protodb.Nothing")
+	proto.RegisterType((*Swap)(nil), "protodb.Swap")
 	proto.RegisterType((*Domain)(nil), "protodb.Domain")
 	proto.RegisterType((*Iterator)(nil), "protodb.Iterator")
 	proto.RegisterType((*Stats)(nil), "protodb.Stats")
 	proto.RegisterMapType((map[string]string)(nil), "protodb.Stats.DataEntry")
+	proto.RegisterType((*DumpOptions)(nil), "protodb.DumpOptions")
 	proto.RegisterType((*Init)(nil), "protodb.Init")
 }
 
 func init() { proto.RegisterFile("remotedb/proto/defs.proto", fileDescriptor_ef1eada6618d0075) }
 
 var fileDescriptor_ef1eada6618d0075 = []byte{
-	// 662 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x4d, 0x6f, 0xd3, 0x4a,
-	0x14, 0xcd, 0xd8, 0x8e, 0x13, 0xdf, 0xf6, 0xa5, 0x79, 0xa3, 0xa7, 0x57, 0xbf, 0x3c, 0x51, 0x45,
-	0x16, 0x12, 0x86, 0x52, 0x37, 0xa4, 0x48, 0x7c, 0xac, 0x68, 0x95, 0x2c, 0x2a, 0xa1, 0x22, 0x39,
-	0x95, 0x58, 0xa2, 0x49, 0x3c, 0x4d, 0x46, 0x34, 0x76, 0x18, 0xdf, 0x56, 0x64, 0xc3, 0x96, 0xbf,
-	0xc2, 0x96, 0x1d, 0x7f, 0x87, 0xfe, 0x0a, 0x24, 0x36, 0x68, 0x66, 0x1c, 0x87, 0x36, 0x59, 0x84,
-	0x55, 0xee, 0xc7, 0x39, 0xe7, 0xce, 0x9c, 0x5c, 0x0f, 0xfc, 0x27, 0xf9, 0x34, 0x43, 0x9e, 0x0c,
-	0x0f, 0x67, 0x32, 0xc3, 0xec, 0x30, 0xe1, 0x17, 0x79, 0xa4, 0x43, 0x5a, 0xd3, 0x3f, 0xc9, 0xb0,
-	0x75, 0x30, 0x16, 0x38, 0xb9, 0x1a, 0x46, 0xa3, 0x6c, 0x7a, 0x38, 0xce, 0xc6, 0x99, 0x81, 0x0e,
-	0xaf, 0x2e, 0x74, 0x66, 0x78, 0x2a, 0x32, 0xbc, 0xe0, 0x00, 0xaa, 0x27, 0x0c, 0x47, 0x13, 0x7a,
-	0x1f, 0xec, 0x6c, 0x96, 0xfb, 0xa4, 0x6d, 0x87, 0x5b, 0x5d, 0x1a, 0x15, 0x72, 0xd1, 0x9b, 0x19,
-	0x97, 0x0c, 0x45, 0x96, 0xc6, 0xaa, 0x1d, 0x7c, 0x02, 0xaf, 0xac, 0xd0, 0x07, 0xe0, 0xf2, 0x14,
-	0x05, 0xce, 0x7d, 0xd2, 0x26, 0xe1, 0x56, 0x77, 0xa7, 0x64, 0xf5, 0x75, 0x39, 0x2e, 0xda, 0x74,
-	0x1f, 0x1c, 0x9c, 0xcf, 0xb8, 0x6f, 0xb5, 0x49, 0xd8, 0xe8, 0xee, 0xae, 0x8a, 0x47, 0xe7, 0xf3,
-	0x19, 0x8f, 0x35, 0x28, 0xf8, 0x1f, 0x1c, 0x95, 0xd1, 0x1a, 0xd8, 0x83, 0xfe, 0x79, 0xb3, 0x42,
-	0x01, 0xdc, 0x5e, 0xff, 0x75, 0xff, 0xbc, 0xdf, 0x24, 0xc1, 0x57, 0x02, 0xae, 0x11, 0xa7, 0x0d,
-	0xb0, 0x44, 0xa2, 0x27, 0x57, 0x63, 0x4b, 0x24, 0xb4, 0x09, 0xf6, 0x7b, 0x3e, 0xd7, 0x33, 0xb6,
-	0x63, 0x15, 0xd2, 0x7f, 0xa0, 0x7a, 0xcd, 0x2e, 0xaf, 0xb8, 0x6f, 0xeb, 0x9a, 0x49, 0xe8, 0xbf,
-	0xe0, 0xf2, 0x8f, 0x22, 0xc7, 0xdc, 0x77, 0xda, 0x24, 0xac, 0xc7, 0x45, 0xa6, 0xd0, 0x39, 0x32,
-	0x89, 0x7e, 0xd5, 0xa0, 0x75, 0xa2, 0x54, 0x79, 0x9a, 0xf8, 0xae, 0x51, 0xe5, 0xa9, 0x9e, 0xc3,
-	0xa5, 0xf4, 0x6b, 0x6d, 0x12, 0x7a, 0xb1, 0x0a, 0xe9, 0x3d, 0x80, 0x91, 0xe4, 0x0c, 0x79, 0xf2,
-	0x8e, 0xa1, 0x5f, 0x6f, 0x93, 0xd0, 0x8e, 0xbd, 0xa2, 0x72, 0x8c, 0x81, 0x07, 0xb5, 0xb3, 0x0c,
-	0x27, 0x22, 0x1d, 0x07, 0x1d, 0x70, 0x7b, 0xd9, 0x94, 0x89, 0x74, 0x39, 0x8d, 0xac, 0x99, 0x66,
-	0x95, 0xd3, 0x82, 0x0f, 0x50, 0x3f, 0x45, 0xe5, 0x52, 0x26, 0x95, 0xdf, 0x89, 0x66, 0xaf, 0xf8,
-	0x6d, 0x44, 0xe3, 0xa2, 0x5d, 0x5c, 0x5c, 0x18, 0xa1, 0x7a, 0x6c, 0x92, 0x85, 0x41, 0xf6, 0x1a,
-	0x83, 0x9c, 0xdf, 0x0c, 0x0a, 0x3e, 0x13, 0xa8, 0x0e, 0x90, 0x61, 0x4e, 0x1f, 0x83, 0x93, 0x30,
-	0x64, 0xc5, 0x52, 0xf8, 0xe5, 0x38, 0xdd, 0x8d, 0x7a, 0x0c, 0x59, 0x3f, 0x45, 0x39, 0x8f, 0x35,
-	0x8a, 0xee, 0x42, 0x0d, 0xc5, 0x94, 0x2b, 0x0f, 0x2c, 0xed, 0x81, 0xab, 0xd2, 0x63, 0x6c, 0x3d,
-	0x03, 0xaf, 0xc4, 0x2e, 0x4e, 0x41, 0x8c, 0x7d, 0xb7, 0x4e, 0x61, 0xe9, 0x9a, 0x49, 0x5e, 0x5a,
-	0xcf, 0x49, 0xf0, 0x0a, 0x9c, 0xd3, 0x54, 0x20, 0xa5, 0x66, 0x25, 0x0a, 0x92, 0x59, 0x0f, 0x0a,
-	0xce, 0x19, 0x9b, 0x2e, 0x48, 0x3a, 0x56, 0xda, 0x3d, 0x21, 0xf5, 0x0d, 0xbd, 0x58, 0x85, 0xdd,
-	0x9f, 0x0e, 0x58, 0xbd, 0x13, 0x1a, 0x82, 0x23, 0x94, 0xd0, 0x5f, 0xe5, 0x15, 0x94, 0x6e, 0xeb,
-	0xee, 0xc2, 0x06, 0x15, 0xfa, 0x10, 0xec, 0x31, 0x47, 0x7a, 0xb7, 0xb3, 0x0e, 0x7a, 0x04, 0xde,
-	0x98, 0xe3, 0x00, 0x25, 0x67, 0xd3, 0x4d, 0x08, 0x21, 0xe9, 0x10, 0xa5, 0x3f, 0x61, 0xf9, 0x46,
-	0xfa, 0x8f, 0xc0, 0xce, 0xd7, 0x1d, 0xa5, 0x59, 0x16, 0x16, 0x6b, 0x55, 0xa1, 0x11, 0xd4, 0x72,
-	0x8e, 0x83, 0x79, 0x3a, 0xda, 0x0c, 0x7f, 0x00, 0x6e, 0xc2, 0x2f, 0x39, 0xf2, 0xcd, 0xe0, 0x4f,
-	0x00, 0x0c, 0x7c, 0xf3, 0x09, 0x5d, 0xa8, 0x8b, 0xc5, 0xe2, 0xae, 0x10, 0xfe, 0x5e, 0xfe, 0x0f,
-	0x05, 0x26, 0xa8, 0x74, 0x08, 0x7d, 0x01, 0x3b, 0x92, 0x5f, 0x73, 0x99, 0xf3, 0xd3, 0x3f, 0xa5,
-	0xee, 0xeb, 0xef, 0x09, 0x73, 0xba, 0x72, 0x96, 0x56, 0xe3, 0xf6, 0xde, 0x06, 0x15, 0xda, 0x01,
-	0x18, 0xaa, 0x47, 0xef, 0xad, 0x14, 0xc8, 0xe9, 0xb2, 0xaf, 0x5f, 0xc2, 0xb5, 0xb7, 0x79, 0x0a,
-	0x8d, 0x25, 0x43, 0x9b, 0xb0, 0x01, 0xeb, 0x64, 0xfb, 0xc7, 0xf7, 0x3d, 0xf2, 0xe5, 0x66, 0x8f,
-	0x7c, 0xbb, 0xd9, 0x23, 0x43, 0x57, 0x03, 0x8e, 0x7e, 0x05, 0x00, 0x00, 0xff, 0xff, 0xc5, 0x88,
-	0xfd, 0x5e, 0xc6, 0x05, 0x00, 0x00,
+	// 1016 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0xcd, 0x6e, 0x23, 0x45,
+	0x10, 0x4e, 0x7b, 0xfc, 0x5b, 0xce, 0x7a, 0x4d, 0x6b, 0x45, 0x86, 0x20, 0x22, 0x6b, 0xb4, 0x2b,
+	0x0c, 0x4b, 0x1c, 0xaf, 0x17, 0xf1, 0xb3, 0x27, 0x12, 0x6c, 0x2d, 0x91, 0x20, 0x91, 0xc6, 0x11,
+	0x1c, 0xad, 0xf6, 0x4c, 0xc5, 0x6e, 0xc5, 0xf3, 0xc3, 0x74, 0x3b, 0x89, 0xf7, 0x0d, 0xb8, 0x71,
+	0xe5, 0x0d, 0x78, 0x04, 0x9e, 0x82, 0x77, 0x60, 0x6f, 0xbc, 0x01, 0x47, 0xd4, 0xdd, 0x33, 0xe3,
+	0x90, 0xcc, 0x61, 0x96, 0x93, 0xab, 0xbe, 0xae, 0xaf, 0xba, 0xeb, 0xab, 0x9a, 0x6e, 0xc3, 0x07,
+	0x09, 0x06, 0x91, 0x44, 0x7f, 0x7e, 0x14, 0x27, 0x91, 0x8c, 0x8e, 0x7c, 0xbc, 0x14, 0x03, 0x6d,
+	0xd2, 0x86, 0xfe, 0xf1, 0xe7, 0xfb, 0x87, 0x0b, 0x2e, 0x97, 0xeb, 0xf9, 0xc0, 0x8b, 0x82, 0xa3,
+	0x45, 0xb4, 0x88, 0x4c, 0xe8, 0x7c, 0x7d, 0xa9, 0x3d, 0xc3, 0x53, 0x96, 0xe1, 0x39, 0x87, 0x50,
+	0x3b, 0x61, 0xd2, 0x5b, 0xd2, 0xa7, 0x60, 0x45, 0xb1, 0xb0, 0x49, 0xcf, 0xea, 0xb7, 0x47, 0x74,
+	0x90, 0xa6, 0x1b, 0x9c, 0xc7, 0x98, 0x30, 0xc9, 0xa3, 0xd0, 0x55, 0xcb, 0xce, 0xaf, 0x04, 0x5a,
+	0x39, 0x44, 0x3f, 0x86, 0x3a, 0x86, 0x92, 0xcb, 0x8d, 0x4d, 0x7a, 0xa4, 0xdf, 0x1e, 0x3d, 0xce,
+	0x69, 0x13, 0x0d, 0xbb, 0xe9, 0x32, 0x7d, 0x0e, 0x55, 0xb9, 0x89, 0xd1, 0xae, 0xf4, 0x48, 0xbf,
+	0x33, 0xda, 0x7b, 0x98, 0x7d, 0x70, 0xb1, 0x89, 0xd1, 0xd5, 0x41, 0xce, 0x21, 0x54, 0x95, 0x47,
+	0x1b, 0x60, 0x4d, 0x27, 0x17, 0xdd, 0x1d, 0x0a, 0x50, 0x1f, 0x4f, 0xbe, 0x9f, 0x5c, 0x4c, 0xba,
+	0x84, 0x76, 0x61, 0xd7, 0xd8, 0x33, 0xf7, 0xf8, 0xec, 0xf5, 0xa4, 0x5b, 0x71, 0xfe, 0x24, 0x50,
+	0x37, 0xdb, 0xd1, 0x0e, 0x54, 0xb8, 0xaf, 0xcf, 0x52, 0x73, 0x2b, 0xdc, 0xa7, 0x5d, 0xb0, 0xae,
+	0x70, 0xa3, 0x77, 0xdd, 0x75, 0x95, 0x49, 0x9f, 0x40, 0xed, 0x9a, 0xad, 0xd6, 0x68, 0x5b, 0x1a,
+	0x33, 0x0e, 0x7d, 0x1f, 0xea, 0x78, 0xcb, 0x85, 0x14, 0x76, 0xb5, 0x47, 0xfa, 0x4d, 0x37, 0xf5,
+	0x54, 0xb4, 0x90, 0x2c, 0x91, 0x76, 0xcd, 0x44, 0x6b, 0x47, 0x65, 0xc5, 0xd0, 0xb7, 0xeb, 0x26,
+	0x2b, 0x86, 0x7a, 0x1f, 0x4c, 0x12, 0xbb, 0xd1, 0x23, 0xfd, 0x96, 0xab, 0x4c, 0xfa, 0x11, 0x80,
+	0x97, 0x20, 0x93, 0xe8, 0xcf, 0x98, 0xb4, 0x9b, 0x3d, 0xd2, 0xb7, 0xdc, 0x56, 0x8a, 0x1c, 0x4b,
+	0xfa, 0x21, 0xb4, 0xae, 0x70, 0x23, 0x66, 0x51, 0xb8, 0xda, 0xd8, 0x2d, 0xbd, 0x67, 0x53, 0x01,
+	0xe7, 0xe1, 0x6a, 0xe3, 0xb4, 0xa0, 0x71, 0x16, 0xc9, 0x25, 0x0f, 0x17, 0xce, 0x2f, 0x04, 0xaa,
+	0xd3, 0x1b, 0x16, 0x67, 0x95, 0x90, 0x6d, 0x25, 0xfb, 0xd0, 0xc4, 0xdb, 0x18, 0x3d, 0x89, 0x7e,
+	0x5a, 0x60, 0xee, 0xd3, 0x67, 0xd0, 0x31, 0xf6, 0x2c, 0xe0, 0x42, 0xf0, 0x70, 0xa1, 0xcb, 0x6d,
+	0xba, 0x8f, 0x0c, 0xfa, 0x83, 0x01, 0xb7, 0x62, 0x54, 0xef, 0x89, 0xe1, 0xe3, 0x0a, 0x25, 0xea,
+	0xaa, 0x9b, 0x6e, 0xea, 0x39, 0x43, 0xa8, 0x8f, 0xa3, 0x80, 0xf1, 0x70, 0x2b, 0x0b, 0x29, 0x90,
+	0xa5, 0x92, 0xcb, 0xe2, 0xfc, 0x0c, 0xcd, 0x53, 0xa9, 0x1a, 0x1c, 0x25, 0x6a, 0x54, 0x7c, 0xcd,
+	0x7e, 0x30, 0x2a, 0x26, 0xa9, 0x5b, 0xf7, 0xf3, 0xe4, 0xd7, 0x6c, 0xc5, 0x4d, 0xa2, 0xa6, 0x6b,
+	0x9c, 0xac, 0x7e, 0xab, 0xa0, 0x93, 0x77, 0x0f, 0xef, 0xfc, 0x5d, 0x81, 0xda, 0x54, 0x32, 0x29,
+	0xe8, 0x67, 0x50, 0xf5, 0x99, 0x64, 0xe9, 0x40, 0xdb, 0xf9, 0x76, 0x7a, 0x75, 0x30, 0x66, 0x92,
+	0x4d, 0x42, 0x99, 0x6c, 0x5c, 0x1d, 0x45, 0xf7, 0xa0, 0x21, 0x79, 0x80, 0xaa, 0x59, 0x15, 0xdd,
+	0xac, 0xba, 0x72, 0x8f, 0x25, 0xb5, 0xa1, 0x31, 0x67, 0xde, 0x95, 0xaa, 0xcc, 0xd2, 0xed, 0xcd,
+	0xdc, 0xb4, 0x87, 0x33, 0x2f, 0x5a, 0x87, 0x52, 0x1f, 0xc2, 0xd2, 0x3d, 0xfc, 0x56, 0xf9, 0x6a,
+	0xd1, 0xe7, 0xe2, 0x6a, 0x26, 0xf8, 0x1b, 0xa3, 0xa3, 0xe5, 0x36, 0x15, 0x30, 0xe5, 0x6f, 0x90,
+	0x3e, 0x85, 0x4e, 0x80, 0xc1, 0x4c, 0xb2, 0xf9, 0x0a, 0x4d, 0x44, 0x5d, 0x47, 0xec, 0x06, 0x18,
+	0x5c, 0x28, 0x50, 0x47, 0xa9, 0x11, 0x62, 0xde, 0x32, 0x8d, 0x68, 0xa4, 0x23, 0xa4, 0x10, 0xbd,
+	0xfc, 0x0c, 0x3a, 0x51, 0x8c, 0xe1, 0x8c, 0xa7, 0x0a, 0x8b, 0x74, 0xca, 0x1e, 0x29, 0x34, 0x93,
+	0x5d, 0xd0, 0x1e, 0xb4, 0xbd, 0x28, 0x88, 0x99, 0xa7, 0xbe, 0x32, 0xa1, 0x67, 0xcd, 0x72, 0xef,
+	0x42, 0xfb, 0x5f, 0x42, 0x2b, 0x57, 0xe3, 0xee, 0x9c, 0xb5, 0xee, 0xe9, 0x5c, 0xd1, 0x98, 0x71,
+	0x5e, 0x55, 0xbe, 0x22, 0x8e, 0x80, 0xf6, 0x78, 0x1d, 0xc4, 0xe7, 0xb1, 0xce, 0x53, 0x76, 0x2a,
+	0xd4, 0x7c, 0x5d, 0x46, 0x49, 0xc0, 0xa4, 0x16, 0xb4, 0xe6, 0xa6, 0x9e, 0x56, 0x85, 0xdd, 0xce,
+	0x74, 0x7e, 0x53, 0x73, 0x35, 0x55, 0x85, 0xdd, 0xfe, 0xa8, 0x40, 0x55, 0xb6, 0xf3, 0x0d, 0x54,
+	0x4f, 0x43, 0x2e, 0x29, 0x35, 0x97, 0x44, 0x7a, 0x52, 0x6d, 0x2b, 0xec, 0x8c, 0x05, 0xd9, 0x49,
+	0xb5, 0xad, 0xf6, 0x1f, 0xf3, 0x24, 0xed, 0x9d, 0x32, 0x47, 0xbf, 0xb5, 0xa0, 0x32, 0x3e, 0xa1,
+	0x7d, 0xa8, 0x72, 0x95, 0xe8, 0x51, 0x3e, 0x19, 0x2a, 0xef, 0xfe, 0xfd, 0x2b, 0xcc, 0xd9, 0xa1,
+	0x9f, 0x80, 0xb5, 0x40, 0x49, 0xef, 0xaf, 0x14, 0x85, 0xbe, 0x84, 0xd6, 0x02, 0xe5, 0x54, 0x26,
+	0xc8, 0x82, 0x32, 0x84, 0x3e, 0x19, 0x12, 0x95, 0x7f, 0xc9, 0x44, 0xa9, 0xfc, 0x9f, 0x82, 0x25,
+	0x8a, 0x8e, 0xd2, 0xcd, 0x81, 0xec, 0xe6, 0xd8, 0xa1, 0x03, 0x68, 0x08, 0x94, 0xd3, 0x4d, 0xe8,
+	0x95, 0x8b, 0x3f, 0xcc, 0xbe, 0xfb, 0x72, 0xe1, 0x2f, 0x00, 0x4c, 0x78, 0xf9, 0x1d, 0x46, 0xd0,
+	0x36, 0x14, 0x97, 0x85, 0x0b, 0x2c, 0xcb, 0xe9, 0xe8, 0x61, 0x4d, 0xf0, 0x38, 0xf4, 0xf5, 0x55,
+	0xb8, 0x6d, 0x98, 0x72, 0x8b, 0x54, 0x1a, 0x41, 0x33, 0xfb, 0x2a, 0x1e, 0x6e, 0xf2, 0xde, 0xb6,
+	0xdf, 0x69, 0x8c, 0xb3, 0x33, 0x24, 0xf4, 0x6b, 0x78, 0x9c, 0xe0, 0x35, 0x26, 0x02, 0x4f, 0xdf,
+	0x95, 0x3a, 0x84, 0xda, 0x8d, 0x7e, 0x42, 0x1f, 0x10, 0x0a, 0x9e, 0x51, 0xcd, 0x18, 0x41, 0x3b,
+	0xc4, 0x9b, 0x69, 0xc8, 0x62, 0xb1, 0x8c, 0x24, 0x7d, 0x50, 0x77, 0x51, 0x51, 0x2f, 0xa0, 0x2d,
+	0x52, 0xc2, 0xeb, 0x92, 0xd3, 0x78, 0x87, 0xf2, 0x5d, 0xc9, 0x01, 0x7b, 0x05, 0xdd, 0x8c, 0xf2,
+	0xce, 0x3a, 0x1c, 0xc3, 0x5e, 0xc6, 0x75, 0xff, 0xa7, 0x94, 0x5f, 0xa8, 0x2e, 0xac, 0x90, 0x09,
+	0xcc, 0xc5, 0x29, 0x39, 0x8c, 0x55, 0x7f, 0x1d, 0xc4, 0xf4, 0xc9, 0xf6, 0x55, 0xd9, 0xde, 0x4c,
+	0x05, 0x75, 0x0e, 0x09, 0x7d, 0xae, 0xaf, 0x2b, 0x29, 0x0a, 0xd4, 0xef, 0xfc, 0xf7, 0xb1, 0x70,
+	0x76, 0xe8, 0x10, 0x60, 0xae, 0x5a, 0xfc, 0x53, 0xc2, 0x25, 0xd2, 0xed, 0xba, 0xfe, 0xeb, 0x54,
+	0x78, 0xa2, 0xcf, 0xa1, 0xb3, 0x65, 0xe8, 0x4f, 0xa4, 0x04, 0xeb, 0x64, 0xf7, 0x9f, 0xbf, 0x0e,
+	0xc8, 0xef, 0x6f, 0x0f, 0xc8, 0x1f, 0x6f, 0x0f, 0xc8, 0xbc, 0xae, 0x03, 0x5e, 0xfe, 0x3b, 0x00,
+	0x33, 0xd3, 0xe9, 0x69, 0xf7, 0x09, 0x00, 0x00,
 }
 
 func (this *Batch) Equal(that interface{}) bool {
@@ -633,6 +860,9 @@ func (this *Entity) Equal(that interface{}) bool {
 	if this.CreatedAt != that1.CreatedAt {
 		return false
 	}
+	if this.KeysOnly != that1.KeysOnly {
+		return false
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return false
 	}
@@ -662,6 +892,45 @@ func (this *Nothing) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *Swap) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Swap)
+	if !ok {
+		that2, ok := that.(Swap)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.Key, that1.Key) {
+		return false
+	}
+	if !bytes.Equal(this.Expected, that1.Expected) {
+		return false
+	}
+	if this.ExpectMissing != that1.ExpectMissing {
+		return false
+	}
+	if !bytes.Equal(this.Value, that1.Value) {
+		return false
+	}
+	if this.Delete != that1.Delete {
+		return false
+	}
+	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
+		return false
+	}
+	return true
+}
 func (this *Domain) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -758,6 +1027,63 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.TimeAt != that1.TimeAt {
 		return false
 	}
+	if this.Backend != that1.Backend {
+		return false
+	}
+	if this.KeyCount != that1.KeyCount {
+		return false
+	}
+	if this.DiskSize != that1.DiskSize {
+		return false
+	}
+	if this.MemTableSize != that1.MemTableSize {
+		return false
+	}
+	if this.CacheSize != that1.CacheSize {
+		return false
+	}
+	if this.OpenIterators != that1.OpenIterators {
+		return false
+	}
+	if this.Compactions != that1.Compactions {
+		return false
+	}
+	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
+		return false
+	}
+	return true
+}
+func (this *DumpOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DumpOptions)
+	if !ok {
+		that2, ok := that.(DumpOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.Start, that1.Start) {
+		return false
+	}
+	if !bytes.Equal(this.End, that1.End) {
+		return false
+	}
+	if this.Format != that1.Format {
+		return false
+	}
+	if this.MaxValueSize != that1.MaxValueSize {
+		return false
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return false
 	}
@@ -817,9 +1143,23 @@ type DBClient interface {
 	SetSync(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error)
 	Delete(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error)
 	DeleteSync(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error)
+	DeleteRange(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error)
+	// compareAndSwap returns whether the key was swapped as the exists field of the Entity.
+	CompareAndSwap(ctx context.Context, in *Swap, opts ...grpc.CallOption) (*Entity, error)
 	Iterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_IteratorClient, error)
 	ReverseIterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_ReverseIteratorClient, error)
-	// rpc print(Nothing) returns (Entity) {}
+	// watch streams changes to keys with the prefix given as the key of the Entity, as SET and
+	// DELETE operations. Response headers are sent once the watch is in place.
+	Watch(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_WatchClient, error)
+	// Snapshots are identified by the id of the Entity returned from newSnapshot.
+	NewSnapshot(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Entity, error)
+	SnapshotGet(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Entity, error)
+	SnapshotHas(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Entity, error)
+	SnapshotIterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_SnapshotIteratorClient, error)
+	SnapshotReverseIterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_SnapshotReverseIteratorClient, error)
+	ReleaseSnapshot(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error)
+	// dump streams the output of DB.Dump as the values of the returned Entities.
+	Dump(ctx context.Context, in *DumpOptions, opts ...grpc.CallOption) (DB_DumpClient, error)
 	Stats(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Stats, error)
 	BatchWrite(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Nothing, error)
 	BatchWriteSync(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Nothing, error)
@@ -927,6 +1267,24 @@ func (c *dBClient) DeleteSync(ctx context.Context, in *Entity, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *dBClient) DeleteRange(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/protodb.DB/deleteRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBClient) CompareAndSwap(ctx context.Context, in *Swap, opts ...grpc.CallOption) (*Entity, error) {
+	out := new(Entity)
+	err := c.cc.Invoke(ctx, "/protodb.DB/compareAndSwap", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *dBClient) Iterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_IteratorClient, error) {
 	stream, err := c.cc.NewStream(ctx, &_DB_serviceDesc.Streams[1], "/protodb.DB/iterator", opts...)
 	if err != nil {
@@ -991,27 +1349,191 @@ func (x *dBReverseIteratorClient) Recv() (*Iterator, error) {
 	return m, nil
 }
 
-func (c *dBClient) Stats(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Stats, error) {
-	out := new(Stats)
-	err := c.cc.Invoke(ctx, "/protodb.DB/stats", in, out, opts...)
+func (c *dBClient) Watch(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DB_serviceDesc.Streams[3], "/protodb.DB/watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dBWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DB_WatchClient interface {
+	Recv() (*Operation, error)
+	grpc.ClientStream
+}
+
+type dBWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *dBWatchClient) Recv() (*Operation, error) {
+	m := new(Operation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dBClient) NewSnapshot(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Entity, error) {
+	out := new(Entity)
+	err := c.cc.Invoke(ctx, "/protodb.DB/newSnapshot", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *dBClient) BatchWrite(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Nothing, error) {
-	out := new(Nothing)
-	err := c.cc.Invoke(ctx, "/protodb.DB/batchWrite", in, out, opts...)
+func (c *dBClient) SnapshotGet(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Entity, error) {
+	out := new(Entity)
+	err := c.cc.Invoke(ctx, "/protodb.DB/snapshotGet", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *dBClient) BatchWriteSync(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Nothing, error) {
-	out := new(Nothing)
-	err := c.cc.Invoke(ctx, "/protodb.DB/batchWriteSync", in, out, opts...)
+func (c *dBClient) SnapshotHas(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Entity, error) {
+	out := new(Entity)
+	err := c.cc.Invoke(ctx, "/protodb.DB/snapshotHas", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBClient) SnapshotIterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_SnapshotIteratorClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DB_serviceDesc.Streams[4], "/protodb.DB/snapshotIterator", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dBSnapshotIteratorClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DB_SnapshotIteratorClient interface {
+	Recv() (*Iterator, error)
+	grpc.ClientStream
+}
+
+type dBSnapshotIteratorClient struct {
+	grpc.ClientStream
+}
+
+func (x *dBSnapshotIteratorClient) Recv() (*Iterator, error) {
+	m := new(Iterator)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dBClient) SnapshotReverseIterator(ctx context.Context, in *Entity, opts ...grpc.CallOption) (DB_SnapshotReverseIteratorClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DB_serviceDesc.Streams[5], "/protodb.DB/snapshotReverseIterator", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dBSnapshotReverseIteratorClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DB_SnapshotReverseIteratorClient interface {
+	Recv() (*Iterator, error)
+	grpc.ClientStream
+}
+
+type dBSnapshotReverseIteratorClient struct {
+	grpc.ClientStream
+}
+
+func (x *dBSnapshotReverseIteratorClient) Recv() (*Iterator, error) {
+	m := new(Iterator)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dBClient) ReleaseSnapshot(ctx context.Context, in *Entity, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/protodb.DB/releaseSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBClient) Dump(ctx context.Context, in *DumpOptions, opts ...grpc.CallOption) (DB_DumpClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DB_serviceDesc.Streams[6], "/protodb.DB/dump", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dBDumpClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DB_DumpClient interface {
+	Recv() (*Entity, error)
+	grpc.ClientStream
+}
+
+type dBDumpClient struct {
+	grpc.ClientStream
+}
+
+func (x *dBDumpClient) Recv() (*Entity, error) {
+	m := new(Entity)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dBClient) Stats(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Stats, error) {
+	out := new(Stats)
+	err := c.cc.Invoke(ctx, "/protodb.DB/stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBClient) BatchWrite(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/protodb.DB/batchWrite", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBClient) BatchWriteSync(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/protodb.DB/batchWriteSync", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1028,9 +1550,23 @@ type DBServer interface {
 	SetSync(context.Context, *Entity) (*Nothing, error)
 	Delete(context.Context, *Entity) (*Nothing, error)
 	DeleteSync(context.Context, *Entity) (*Nothing, error)
+	DeleteRange(context.Context, *Entity) (*Nothing, error)
+	// compareAndSwap returns whether the key was swapped as the exists field of the Entity.
+	CompareAndSwap(context.Context, *Swap) (*Entity, error)
 	Iterator(*Entity, DB_IteratorServer) error
 	ReverseIterator(*Entity, DB_ReverseIteratorServer) error
-	// rpc print(Nothing) returns (Entity) {}
+	// watch streams changes to keys with the prefix given as the key of the Entity, as SET and
+	// DELETE operations. Response headers are sent once the watch is in place.
+	Watch(*Entity, DB_WatchServer) error
+	// Snapshots are identified by the id of the Entity returned from newSnapshot.
+	NewSnapshot(context.Context, *Nothing) (*Entity, error)
+	SnapshotGet(context.Context, *Entity) (*Entity, error)
+	SnapshotHas(context.Context, *Entity) (*Entity, error)
+	SnapshotIterator(*Entity, DB_SnapshotIteratorServer) error
+	SnapshotReverseIterator(*Entity, DB_SnapshotReverseIteratorServer) error
+	ReleaseSnapshot(context.Context, *Entity) (*Nothing, error)
+	// dump streams the output of DB.Dump as the values of the returned Entities.
+	Dump(*DumpOptions, DB_DumpServer) error
 	Stats(context.Context, *Nothing) (*Stats, error)
 	BatchWrite(context.Context, *Batch) (*Nothing, error)
 	BatchWriteSync(context.Context, *Batch) (*Nothing, error)
@@ -1064,12 +1600,42 @@ func (*UnimplementedDBServer) Delete(ctx context.Context, req *Entity) (*Nothing
 func (*UnimplementedDBServer) DeleteSync(ctx context.Context, req *Entity) (*Nothing, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteSync not implemented")
 }
+func (*UnimplementedDBServer) DeleteRange(ctx context.Context, req *Entity) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRange not implemented")
+}
+func (*UnimplementedDBServer) CompareAndSwap(ctx context.Context, req *Swap) (*Entity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompareAndSwap not implemented")
+}
 func (*UnimplementedDBServer) Iterator(req *Entity, srv DB_IteratorServer) error {
 	return status.Errorf(codes.Unimplemented, "method Iterator not implemented")
 }
 func (*UnimplementedDBServer) ReverseIterator(req *Entity, srv DB_ReverseIteratorServer) error {
 	return status.Errorf(codes.Unimplemented, "method ReverseIterator not implemented")
 }
+func (*UnimplementedDBServer) Watch(req *Entity, srv DB_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (*UnimplementedDBServer) NewSnapshot(ctx context.Context, req *Nothing) (*Entity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewSnapshot not implemented")
+}
+func (*UnimplementedDBServer) SnapshotGet(ctx context.Context, req *Entity) (*Entity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SnapshotGet not implemented")
+}
+func (*UnimplementedDBServer) SnapshotHas(ctx context.Context, req *Entity) (*Entity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SnapshotHas not implemented")
+}
+func (*UnimplementedDBServer) SnapshotIterator(req *Entity, srv DB_SnapshotIteratorServer) error {
+	return status.Errorf(codes.Unimplemented, "method SnapshotIterator not implemented")
+}
+func (*UnimplementedDBServer) SnapshotReverseIterator(req *Entity, srv DB_SnapshotReverseIteratorServer) error {
+	return status.Errorf(codes.Unimplemented, "method SnapshotReverseIterator not implemented")
+}
+func (*UnimplementedDBServer) ReleaseSnapshot(ctx context.Context, req *Entity) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseSnapshot not implemented")
+}
+func (*UnimplementedDBServer) Dump(req *DumpOptions, srv DB_DumpServer) error {
+	return status.Errorf(codes.Unimplemented, "method Dump not implemented")
+}
 func (*UnimplementedDBServer) Stats(ctx context.Context, req *Nothing) (*Stats, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
 }
@@ -1236,6 +1802,42 @@ func _DB_DeleteSync_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DB_DeleteRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Entity)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServer).DeleteRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protodb.DB/DeleteRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServer).DeleteRange(ctx, req.(*Entity))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DB_CompareAndSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Swap)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServer).CompareAndSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protodb.DB/CompareAndSwap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServer).CompareAndSwap(ctx, req.(*Swap))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _DB_Iterator_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(Entity)
 	if err := stream.RecvMsg(m); err != nil {
@@ -1278,6 +1880,162 @@ func (x *dBReverseIteratorServer) Send(m *Iterator) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _DB_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Entity)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DBServer).Watch(m, &dBWatchServer{stream})
+}
+
+type DB_WatchServer interface {
+	Send(*Operation) error
+	grpc.ServerStream
+}
+
+type dBWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *dBWatchServer) Send(m *Operation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DB_NewSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Nothing)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServer).NewSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protodb.DB/NewSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServer).NewSnapshot(ctx, req.(*Nothing))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DB_SnapshotGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Entity)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServer).SnapshotGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protodb.DB/SnapshotGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServer).SnapshotGet(ctx, req.(*Entity))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DB_SnapshotHas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Entity)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServer).SnapshotHas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protodb.DB/SnapshotHas",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServer).SnapshotHas(ctx, req.(*Entity))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DB_SnapshotIterator_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Entity)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DBServer).SnapshotIterator(m, &dBSnapshotIteratorServer{stream})
+}
+
+type DB_SnapshotIteratorServer interface {
+	Send(*Iterator) error
+	grpc.ServerStream
+}
+
+type dBSnapshotIteratorServer struct {
+	grpc.ServerStream
+}
+
+func (x *dBSnapshotIteratorServer) Send(m *Iterator) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DB_SnapshotReverseIterator_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Entity)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DBServer).SnapshotReverseIterator(m, &dBSnapshotReverseIteratorServer{stream})
+}
+
+type DB_SnapshotReverseIteratorServer interface {
+	Send(*Iterator) error
+	grpc.ServerStream
+}
+
+type dBSnapshotReverseIteratorServer struct {
+	grpc.ServerStream
+}
+
+func (x *dBSnapshotReverseIteratorServer) Send(m *Iterator) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DB_ReleaseSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Entity)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServer).ReleaseSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protodb.DB/ReleaseSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServer).ReleaseSnapshot(ctx, req.(*Entity))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DB_Dump_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DumpOptions)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DBServer).Dump(m, &dBDumpServer{stream})
+}
+
+type DB_DumpServer interface {
+	Send(*Entity) error
+	grpc.ServerStream
+}
+
+type dBDumpServer struct {
+	grpc.ServerStream
+}
+
+func (x *dBDumpServer) Send(m *Entity) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _DB_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Nothing)
 	if err := dec(in); err != nil {
@@ -1364,6 +2122,30 @@ var _DB_serviceDesc = grpc.ServiceDesc{
 			MethodName: "deleteSync",
 			Handler:    _DB_DeleteSync_Handler,
 		},
+		{
+			MethodName: "deleteRange",
+			Handler:    _DB_DeleteRange_Handler,
+		},
+		{
+			MethodName: "compareAndSwap",
+			Handler:    _DB_CompareAndSwap_Handler,
+		},
+		{
+			MethodName: "newSnapshot",
+			Handler:    _DB_NewSnapshot_Handler,
+		},
+		{
+			MethodName: "snapshotGet",
+			Handler:    _DB_SnapshotGet_Handler,
+		},
+		{
+			MethodName: "snapshotHas",
+			Handler:    _DB_SnapshotHas_Handler,
+		},
+		{
+			MethodName: "releaseSnapshot",
+			Handler:    _DB_ReleaseSnapshot_Handler,
+		},
 		{
 			MethodName: "stats",
 			Handler:    _DB_Stats_Handler,
@@ -1394,6 +2176,26 @@ var _DB_serviceDesc = grpc.ServiceDesc{
 			Handler:       _DB_ReverseIterator_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "watch",
+			Handler:       _DB_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "snapshotIterator",
+			Handler:       _DB_SnapshotIterator_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "snapshotReverseIterator",
+			Handler:       _DB_SnapshotReverseIterator_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "dump",
+			Handler:       _DB_Dump_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "remotedb/proto/defs.proto",
 }
@@ -1418,7 +2220,7 @@ func NewPopulatedOperation(r randyDefs, easy bool) *Operation {
 	if r.Intn(5) != 0 {
 		this.Entity = NewPopulatedEntity(r, easy)
 	}
-	this.Type = Operation_Type([]int32{0, 1}[r.Intn(2)])
+	this.Type = Operation_Type([]int32{0, 1, 2}[r.Intn(3)])
 	if !easy && r.Intn(10) != 0 {
 		this.XXX_unrecognized = randUnrecognizedDefs(r, 3)
 	}
@@ -1457,8 +2259,9 @@ func NewPopulatedEntity(r randyDefs, easy bool) *Entity {
 	if r.Intn(2) == 0 {
 		this.CreatedAt *= -1
 	}
+	this.KeysOnly = bool(bool(r.Intn(2) == 0))
 	if !easy && r.Intn(10) != 0 {
-		this.XXX_unrecognized = randUnrecognizedDefs(r, 9)
+		this.XXX_unrecognized = randUnrecognizedDefs(r, 10)
 	}
 	return this
 }
@@ -1471,16 +2274,41 @@ func NewPopulatedNothing(r randyDefs, easy bool) *Nothing {
 	return this
 }
 
-func NewPopulatedDomain(r randyDefs, easy bool) *Domain {
-	this := &Domain{}
+func NewPopulatedSwap(r randyDefs, easy bool) *Swap {
+	this := &Swap{}
 	v6 := r.Intn(100)
-	this.Start = make([]byte, v6)
+	this.Key = make([]byte, v6)
 	for i := 0; i < v6; i++ {
-		this.Start[i] = byte(r.Intn(256))
+		this.Key[i] = byte(r.Intn(256))
 	}
 	v7 := r.Intn(100)
-	this.End = make([]byte, v7)
+	this.Expected = make([]byte, v7)
 	for i := 0; i < v7; i++ {
+		this.Expected[i] = byte(r.Intn(256))
+	}
+	this.ExpectMissing = bool(bool(r.Intn(2) == 0))
+	v8 := r.Intn(100)
+	this.Value = make([]byte, v8)
+	for i := 0; i < v8; i++ {
+		this.Value[i] = byte(r.Intn(256))
+	}
+	this.Delete = bool(bool(r.Intn(2) == 0))
+	if !easy && r.Intn(10) != 0 {
+		this.XXX_unrecognized = randUnrecognizedDefs(r, 6)
+	}
+	return this
+}
+
+func NewPopulatedDomain(r randyDefs, easy bool) *Domain {
+	this := &Domain{}
+	v9 := r.Intn(100)
+	this.Start = make([]byte, v9)
+	for i := 0; i < v9; i++ {
+		this.Start[i] = byte(r.Intn(256))
+	}
+	v10 := r.Intn(100)
+	this.End = make([]byte, v10)
+	for i := 0; i < v10; i++ {
 		this.End[i] = byte(r.Intn(256))
 	}
 	if !easy && r.Intn(10) != 0 {
@@ -1495,14 +2323,14 @@ func NewPopulatedIterator(r randyDefs, easy bool) *Iterator {
 		this.Domain = NewPopulatedDomain(r, easy)
 	}
 	this.Valid = bool(bool(r.Intn(2) == 0))
-	v8 := r.Intn(100)
-	this.Key = make([]byte, v8)
-	for i := 0; i < v8; i++ {
+	v11 := r.Intn(100)
+	this.Key = make([]byte, v11)
+	for i := 0; i < v11; i++ {
 		this.Key[i] = byte(r.Intn(256))
 	}
-	v9 := r.Intn(100)
-	this.Value = make([]byte, v9)
-	for i := 0; i < v9; i++ {
+	v12 := r.Intn(100)
+	this.Value = make([]byte, v12)
+	for i := 0; i < v12; i++ {
 		this.Value[i] = byte(r.Intn(256))
 	}
 	if !easy && r.Intn(10) != 0 {
@@ -1514,9 +2342,9 @@ func NewPopulatedIterator(r randyDefs, easy bool) *Iterator {
 func NewPopulatedStats(r randyDefs, easy bool) *Stats {
 	this := &Stats{}
 	if r.Intn(5) != 0 {
-		v10 := r.Intn(10)
+		v13 := r.Intn(10)
 		this.Data = make(map[string]string)
-		for i := 0; i < v10; i++ {
+		for i := 0; i < v13; i++ {
 			this.Data[randStringDefs(r)] = randStringDefs(r)
 		}
 	}
@@ -1524,8 +2352,59 @@ func NewPopulatedStats(r randyDefs, easy bool) *Stats {
 	if r.Intn(2) == 0 {
 		this.TimeAt *= -1
 	}
+	this.Backend = string(randStringDefs(r))
+	this.KeyCount = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.KeyCount *= -1
+	}
+	this.DiskSize = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.DiskSize *= -1
+	}
+	this.MemTableSize = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.MemTableSize *= -1
+	}
+	this.CacheSize = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.CacheSize *= -1
+	}
+	this.OpenIterators = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.OpenIterators *= -1
+	}
+	this.Compactions = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.Compactions *= -1
+	}
 	if !easy && r.Intn(10) != 0 {
-		this.XXX_unrecognized = randUnrecognizedDefs(r, 3)
+		this.XXX_unrecognized = randUnrecognizedDefs(r, 10)
+	}
+	return this
+}
+
+func NewPopulatedDumpOptions(r randyDefs, easy bool) *DumpOptions {
+	this := &DumpOptions{}
+	v14 := r.Intn(100)
+	this.Start = make([]byte, v14)
+	for i := 0; i < v14; i++ {
+		this.Start[i] = byte(r.Intn(256))
+	}
+	v15 := r.Intn(100)
+	this.End = make([]byte, v15)
+	for i := 0; i < v15; i++ {
+		this.End[i] = byte(r.Intn(256))
+	}
+	this.Format = int32(r.Int31())
+	if r.Intn(2) == 0 {
+		this.Format *= -1
+	}
+	this.MaxValueSize = int64(r.Int63())
+	if r.Intn(2) == 0 {
+		this.MaxValueSize *= -1
+	}
+	if !easy && r.Intn(10) != 0 {
+		this.XXX_unrecognized = randUnrecognizedDefs(r, 5)
 	}
 	return this
 }
@@ -1560,9 +2439,9 @@ func randUTF8RuneDefs(r randyDefs) rune {
 	return rune(ru + 61)
 }
 func randStringDefs(r randyDefs) string {
-	v11 := r.Intn(100)
-	tmps := make([]rune, v11)
-	for i := 0; i < v11; i++ {
+	v16 := r.Intn(100)
+	tmps := make([]rune, v16)
+	for i := 0; i < v16; i++ {
 		tmps[i] = randUTF8RuneDefs(r)
 	}
 	return string(tmps)
@@ -1584,11 +2463,11 @@ func randFieldDefs(dAtA []byte, r randyDefs, fieldNumber int, wire int) []byte {
 	switch wire {
 	case 0:
 		dAtA = encodeVarintPopulateDefs(dAtA, uint64(key))
-		v12 := r.Int63()
+		v17 := r.Int63()
 		if r.Intn(2) == 0 {
-			v12 *= -1
+			v17 *= -1
 		}
-		dAtA = encodeVarintPopulateDefs(dAtA, uint64(v12))
+		dAtA = encodeVarintPopulateDefs(dAtA, uint64(v17))
 	case 1:
 		dAtA = encodeVarintPopulateDefs(dAtA, uint64(key))
 		dAtA = append(dAtA, byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)))
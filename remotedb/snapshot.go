@@ -0,0 +1,71 @@
+package remotedb
+
+import (
+	"fmt"
+
+	tmdb "github.com/tendermint/tm-db"
+	protodb "github.com/tendermint/tm-db/remotedb/proto"
+)
+
+// snapshot is a handle to a snapshot held open by the remote server, until it is released by
+// Close.
+type snapshot struct {
+	db *RemoteDB
+	id int32
+}
+
+var _ tmdb.Snapshot = (*snapshot)(nil)
+
+func newSnapshot(rdb *RemoteDB, id int32) *snapshot {
+	return &snapshot{
+		db: rdb,
+		id: id,
+	}
+}
+
+// Get implements Snapshot.
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	res, err := s.db.dc.SnapshotGet(s.db.ctx, &protodb.Entity{Id: s.id, Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("remoteDB.SnapshotGet error: %w", err)
+	}
+	if res.Exists && res.Value == nil {
+		return []byte{}, nil
+	}
+	return res.Value, nil
+}
+
+// Has implements Snapshot.
+func (s *snapshot) Has(key []byte) (bool, error) {
+	res, err := s.db.dc.SnapshotHas(s.db.ctx, &protodb.Entity{Id: s.id, Key: key})
+	if err != nil {
+		return false, err
+	}
+	return res.Exists, nil
+}
+
+// Iterator implements Snapshot.
+func (s *snapshot) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	dic, err := s.db.dc.SnapshotIterator(s.db.ctx, &protodb.Entity{Id: s.id, Start: start, End: end})
+	if err != nil {
+		return nil, fmt.Errorf("RemoteDB.SnapshotIterator error: %w", err)
+	}
+	return makeIterator(dic), nil
+}
+
+// ReverseIterator implements Snapshot.
+func (s *snapshot) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	dic, err := s.db.dc.SnapshotReverseIterator(s.db.ctx, &protodb.Entity{Id: s.id, Start: start, End: end})
+	if err != nil {
+		return nil, fmt.Errorf("RemoteDB.SnapshotReverseIterator error: %w", err)
+	}
+	return makeReverseIterator(dic), nil
+}
+
+// Close implements Snapshot.
+func (s *snapshot) Close() error {
+	if _, err := s.db.dc.ReleaseSnapshot(s.db.ctx, &protodb.Entity{Id: s.id}); err != nil {
+		return fmt.Errorf("remoteDB.ReleaseSnapshot: %w", err)
+	}
+	return nil
+}
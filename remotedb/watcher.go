@@ -0,0 +1,102 @@
+package remotedb
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	tmdb "github.com/tendermint/tm-db"
+	protodb "github.com/tendermint/tm-db/remotedb/proto"
+)
+
+// watcher receives events from a watch call, until it is closed by cancelling the call.
+type watcher struct {
+	cancel context.CancelFunc
+	events chan tmdb.Event
+	err    error // set before done is closed
+	done   chan struct{}
+}
+
+var _ tmdb.Watcher = (*watcher)(nil)
+
+// Watch implements WatchableDB, using the watch RPC. It returns once the server is watching.
+func (rd *RemoteDB) Watch(prefix []byte) (tmdb.Watcher, error) {
+	if rd.closed() {
+		return nil, tmdb.ErrClosed
+	}
+	ctx, cancel := context.WithCancel(rd.ctx)
+	stream, err := rd.dc.Watch(ctx, &protodb.Entity{Key: prefix})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("remoteDB.Watch: %w", err)
+	}
+	// The server sends headers once the watch is in place.
+	if _, err := stream.Header(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("remoteDB.Watch: %w", err)
+	}
+	w := &watcher{
+		cancel: cancel,
+		events: make(chan tmdb.Event),
+		done:   make(chan struct{}),
+	}
+	go w.receive(ctx, stream)
+	return w, nil
+}
+
+// receive delivers events from the stream until it ends.
+func (w *watcher) receive(ctx context.Context, stream protodb.DB_WatchClient) {
+	defer close(w.events)
+	defer close(w.done)
+	for {
+		op, err := stream.Recv()
+		switch {
+		case err == io.EOF || status.Code(err) == codes.Canceled:
+			return
+		case status.Convert(err).Message() == tmdb.ErrWatcherOverflow.Error():
+			w.err = tmdb.ErrWatcherOverflow
+			return
+		case err != nil:
+			w.err = fmt.Errorf("remoteDB.Watch: %w", err)
+			return
+		}
+
+		event := tmdb.Event{Type: tmdb.EventSet, Key: op.Entity.Key, Value: op.Entity.Value}
+		if op.Type == protodb.Operation_DELETE {
+			event = tmdb.Event{Type: tmdb.EventDelete, Key: op.Entity.Key}
+		} else if event.Value == nil {
+			// gRPC does not distinguish empty values from nil values.
+			event.Value = []byte{}
+		}
+		select {
+		case w.events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Events implements Watcher.
+func (w *watcher) Events() <-chan tmdb.Event {
+	return w.events
+}
+
+// Err implements Watcher.
+func (w *watcher) Err() error {
+	select {
+	case <-w.done:
+		return w.err
+	default:
+		return nil
+	}
+}
+
+// Close implements Watcher.
+func (w *watcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 type rocksDBBatch struct {
 	db    *RocksDB
 	batch *gorocksdb.WriteBatch
+	// maxKey is the greatest key set in the batch, needed by DeleteRange with a nil end.
+	maxKey []byte
 }
 
 var _ tmdb.Batch = (*rocksDBBatch)(nil)
@@ -31,6 +33,9 @@ func (b *rocksDBBatch) Set(key, value []byte) error {
 		return tmdb.ErrBatchClosed
 	}
 	b.batch.Put(key, value)
+	if b.maxKey == nil || b.db.cmp.Compare(key, b.maxKey) > 0 {
+		b.maxKey = key
+	}
 	return nil
 }
 
@@ -46,12 +51,49 @@ func (b *rocksDBBatch) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange implements Batch.
+func (b *rocksDBBatch) DeleteRange(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	if b.batch == nil {
+		return tmdb.ErrBatchClosed
+	}
+	if start == nil {
+		start = []byte{}
+	}
+	if end != nil {
+		if b.db.cmp.Compare(start, end) < 0 {
+			b.batch.DeleteRange(start, end)
+		}
+		return nil
+	}
+
+	// Range deletes require an end key, so we delete up to the last key (which is exclusive), either
+	// in the database or set earlier in the batch, and then delete the last key itself.
+	last, err := b.db.lastKey()
+	if err != nil {
+		return err
+	}
+	if last == nil || (b.maxKey != nil && b.db.cmp.Compare(b.maxKey, last) > 0) {
+		last = b.maxKey
+	}
+	if last == nil || b.db.cmp.Compare(last, start) < 0 {
+		return nil
+	}
+	b.batch.DeleteRange(start, last)
+	b.batch.Delete(last)
+	return nil
+}
+
 // Write implements Batch.
 func (b *rocksDBBatch) Write() error {
 	if b.batch == nil {
 		return tmdb.ErrBatchClosed
 	}
-	err := b.db.db.Write(b.db.wo, b.batch)
+	b.db.commitMtx.RLock()
+	defer b.db.commitMtx.RUnlock()
+	err := b.write(b.db.wo)
 	if err != nil {
 		return err
 	}
@@ -65,7 +107,9 @@ func (b *rocksDBBatch) WriteSync() error {
 	if b.batch == nil {
 		return tmdb.ErrBatchClosed
 	}
-	err := b.db.db.Write(b.db.woSync, b.batch)
+	b.db.commitMtx.RLock()
+	defer b.db.commitMtx.RUnlock()
+	err := b.write(b.db.woSync)
 	if err != nil {
 		return err
 	}
@@ -73,6 +117,21 @@ func (b *rocksDBBatch) WriteSync() error {
 	return b.Close()
 }
 
+// write writes the batch with the given write options, unless the database is closed or
+// read-only.
+func (b *rocksDBBatch) write(wo *gorocksdb.WriteOptions) error {
+	b.db.closeMtx.RLock()
+	defer b.db.closeMtx.RUnlock()
+
+	if b.db.closed {
+		return tmdb.ErrClosed
+	}
+	if b.db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	return b.db.db.Write(wo, b.batch)
+}
+
 // Close implements Batch.
 func (b *rocksDBBatch) Close() error {
 	if b.batch != nil {
@@ -1,25 +1,62 @@
 package rocksdb
 
 import (
-	"fmt"
+	"bytes"
+	"io"
+	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"sync"
 
 	"github.com/tecbot/gorocksdb"
 	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/estimate"
+	"github.com/tendermint/tm-db/internal/keylock"
 )
 
 // RocksDB is a RocksDB backend.
 type RocksDB struct {
+	// closeMtx is held for reading while the database is used, and for writing by Close, such that
+	// the database is not used after it is freed.
+	closeMtx sync.RWMutex
+	closed   bool
+
 	db     *gorocksdb.DB
+	path   string
+	cmp    tmdb.Comparer
 	ro     *gorocksdb.ReadOptions
 	wo     *gorocksdb.WriteOptions
 	woSync *gorocksdb.WriteOptions
+
+	// readOnly is true if the database was opened with NewReadOnlyDB, in which case all writes
+	// fail with ErrReadOnly.
+	readOnly bool
+
+	// commitMtx is held for reading by writes, and for writing by transaction commits, such that
+	// commits can validate and write without interleaved writes.
+	commitMtx sync.RWMutex
+
+	// keyLocks serializes updates of each key.
+	keyLocks keylock.Locks
 }
 
-var _ tmdb.DB = (*RocksDB)(nil)
+var (
+	_ tmdb.TransactionDB     = (*RocksDB)(nil)
+	_ tmdb.CompactionDB      = (*RocksDB)(nil)
+	_ tmdb.CheckpointDB      = (*RocksDB)(nil)
+	_ tmdb.MultiGetDB        = (*RocksDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*RocksDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*RocksDB)(nil)
+	_ tmdb.ComparerDB        = (*RocksDB)(nil)
+)
 
 func NewDB(name string, dir string) (*RocksDB, error) {
+	return NewDBWithOptions(name, dir, defaultOptions())
+}
+
+// defaultOptions returns the options used by NewDB.
+func defaultOptions() *gorocksdb.Options {
 	// default rocksdb option, good enough for most cases, including heavy workloads.
 	// 1GB table cache, 512MB write buffer(may use 50% more on heavy workloads).
 	// compression: snappy as default, need to -lsnappy to enable.
@@ -33,12 +70,55 @@ func NewDB(name string, dir string) (*RocksDB, error) {
 	opts.IncreaseParallelism(runtime.NumCPU())
 	// 1.5GB maximum memory use for writebuffer.
 	opts.OptimizeLevelStyleCompaction(512 * 1024 * 1024)
-	return NewDBWithOptions(name, dir, opts)
+	return opts
 }
 
+// NewDBWithOptions creates a RocksDB with the given options. Options with a custom comparator must
+// be given to NewDBWithComparer instead, since the comparator can not be read from them.
 func NewDBWithOptions(name string, dir string, opts *gorocksdb.Options) (*RocksDB, error) {
+	return newDB(name, dir, opts, tmdb.BytesComparer, false)
+}
+
+// NewDBWithComparer creates a RocksDB which orders keys with the given comparer, setting it on
+// the given options, or on the options used by NewDB if nil. The database must always be opened
+// with a comparer of the same name.
+func NewDBWithComparer(name string, dir string, opts *gorocksdb.Options, cmp tmdb.Comparer) (*RocksDB, error) {
+	if opts == nil {
+		opts = defaultOptions()
+	}
+	if !tmdb.IsBytesComparer(cmp) {
+		opts.SetComparator(cmp)
+	}
+	return newDB(name, dir, opts, cmp, false)
+}
+
+// NewReadOnlyDB opens an existing RocksDB without modifying its files, like NewDBWithComparer.
+// All writes fail with ErrReadOnly.
+func NewReadOnlyDB(name string, dir string, opts *gorocksdb.Options, cmp tmdb.Comparer) (*RocksDB, error) {
+	if opts == nil {
+		opts = defaultOptions()
+	}
+	if !tmdb.IsBytesComparer(cmp) {
+		opts.SetComparator(cmp)
+	}
+	return newDB(name, dir, opts, cmp, true)
+}
+
+// newDB opens a RocksDB whose keys are ordered by the given comparer, optionally read-only.
+func newDB(name string, dir string, opts *gorocksdb.Options, cmp tmdb.Comparer, readOnly bool) (*RocksDB, error) {
+	if cmp == nil {
+		cmp = tmdb.BytesComparer
+	}
 	dbPath := filepath.Join(dir, name+".db")
-	db, err := gorocksdb.OpenDb(opts, dbPath)
+	var (
+		db  *gorocksdb.DB
+		err error
+	)
+	if readOnly {
+		db, err = gorocksdb.OpenDbForReadOnly(opts, dbPath, false)
+	} else {
+		db, err = gorocksdb.OpenDb(opts, dbPath)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -47,10 +127,13 @@ func NewDBWithOptions(name string, dir string, opts *gorocksdb.Options) (*RocksD
 	woSync := gorocksdb.NewDefaultWriteOptions()
 	woSync.SetSync(true)
 	database := &RocksDB{
-		db:     db,
-		ro:     ro,
-		wo:     wo,
-		woSync: woSync,
+		db:       db,
+		path:     dbPath,
+		cmp:      cmp,
+		ro:       ro,
+		wo:       wo,
+		woSync:   woSync,
+		readOnly: readOnly,
 	}
 	return database, nil
 }
@@ -60,6 +143,12 @@ func (db *RocksDB) Get(key []byte) ([]byte, error) {
 	if len(key) == 0 {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
 	res, err := db.db.Get(db.ro, key)
 	if err != nil {
 		return nil, err
@@ -67,6 +156,30 @@ func (db *RocksDB) Get(key []byte) ([]byte, error) {
 	return moveSliceToBytes(res), nil
 }
 
+// GetMany implements MultiGetDB, using RocksDB's MultiGet.
+func (db *RocksDB) GetMany(keys [][]byte) ([][]byte, error) {
+	for _, key := range keys {
+		if len(key) == 0 {
+			return nil, tmdb.ErrKeyEmpty
+		}
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	slices, err := db.db.MultiGet(db.ro, keys...)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(slices))
+	for i, slice := range slices {
+		values[i] = moveSliceToBytes(slice)
+	}
+	return values, nil
+}
+
 // Has implements DB.
 func (db *RocksDB) Has(key []byte) (bool, error) {
 	bytes, err := db.Get(key)
@@ -84,6 +197,17 @@ func (db *RocksDB) Set(key []byte, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := db.db.Put(db.wo, key, value)
 	if err != nil {
 		return err
@@ -99,6 +223,17 @@ func (db *RocksDB) SetSync(key []byte, value []byte) error {
 	if value == nil {
 		return tmdb.ErrValueNil
 	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := db.db.Put(db.woSync, key, value)
 	if err != nil {
 		return err
@@ -111,6 +246,17 @@ func (db *RocksDB) Delete(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := db.db.Delete(db.wo, key)
 	if err != nil {
 		return err
@@ -123,6 +269,17 @@ func (db *RocksDB) DeleteSync(key []byte) error {
 	if len(key) == 0 {
 		return tmdb.ErrKeyEmpty
 	}
+	db.commitMtx.RLock()
+	defer db.commitMtx.RUnlock()
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
 	err := db.db.Delete(db.woSync, key)
 	if err != nil {
 		return nil
@@ -130,12 +287,184 @@ func (db *RocksDB) DeleteSync(key []byte) error {
 	return nil
 }
 
+// DeleteRange implements DB.
+func (db *RocksDB) DeleteRange(start, end []byte) error {
+	batch := newRocksDBBatch(db)
+	defer batch.Close()
+	if err := batch.DeleteRange(start, end); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// lastKey returns the last key in the database, or nil if it is empty.
+func (db *RocksDB) lastKey() ([]byte, error) {
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	itr := db.db.NewIterator(db.ro)
+	defer itr.Close()
+	itr.SeekToLast()
+	if !itr.Valid() {
+		return nil, itr.Err()
+	}
+	return moveSliceToBytes(itr.Key()), nil
+}
+
+// Update implements DB, serializing updates of each key with key locks.
+func (db *RocksDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+
+	value, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+	value, del, err := fn(value)
+	switch {
+	case err != nil:
+		return err
+	case del:
+		return db.Delete(key)
+	default:
+		return db.Set(key, value)
+	}
+}
+
+// CompareAndSwap implements DB, serializing it with updates of the key using key locks.
+func (db *RocksDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, tmdb.ErrKeyEmpty
+	}
+	if db.readOnly {
+		return false, tmdb.ErrReadOnly
+	}
+	db.keyLocks.Lock(key)
+	defer db.keyLocks.Unlock(key)
+
+	current, err := db.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if (current == nil) != (expected == nil) || !bytes.Equal(current, expected) {
+		return false, nil
+	}
+	if value == nil {
+		err = db.Delete(key)
+	} else {
+		err = db.Set(key, value)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (db *RocksDB) DB() *gorocksdb.DB {
 	return db.db
 }
 
+// Comparer implements ComparerDB.
+func (db *RocksDB) Comparer() tmdb.Comparer {
+	return db.cmp
+}
+
+// Compact implements CompactionDB.
+func (db *RocksDB) Compact(start, end []byte) error {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return tmdb.ErrKeyEmpty
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	if db.readOnly {
+		return tmdb.ErrReadOnly
+	}
+	db.db.CompactRange(gorocksdb.Range{Start: start, Limit: end})
+	return nil
+}
+
+// ApproximateSize implements ApproximateSizeDB, using the size of the table files overlapping the
+// range. Writes which have not been flushed to table files are not included.
+func (db *RocksDB) ApproximateSize(start, end []byte) (int64, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return 0, tmdb.ErrKeyEmpty
+	}
+	if end == nil {
+		last, err := db.lastKey()
+		if err != nil || last == nil {
+			return 0, err
+		}
+		end = append(last, 0)
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return 0, tmdb.ErrClosed
+	}
+	sizes := db.db.GetApproximateSizes([]gorocksdb.Range{{Start: start, Limit: end}})
+	return int64(sizes[0]), nil
+}
+
+// ApproximateCount implements ApproximateSizeDB, extrapolating from a sample of the keys in the
+// range and their approximate size.
+func (db *RocksDB) ApproximateCount(start, end []byte) (int64, error) {
+	size, err := db.ApproximateSize(start, end)
+	if err != nil {
+		return 0, err
+	}
+	itr, err := db.IteratorWithOptions(start, end, tmdb.IteratorOptions{KeysOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Close()
+	return estimate.Count(itr, size, db.ApproximateSize)
+}
+
+// Checkpoint implements CheckpointDB, using a native RocksDB checkpoint. Table files are hard
+// linked into the checkpoint when dir is on the same filesystem as the database.
+func (db *RocksDB) Checkpoint(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	checkpoint, err := db.db.NewCheckpoint()
+	if err != nil {
+		return err
+	}
+	defer checkpoint.Destroy()
+	// A log size of 0 flushes the memtable first, so the checkpoint does not need the WAL.
+	return checkpoint.CreateCheckpoint(filepath.Join(dir, filepath.Base(db.path)), 0)
+}
+
 // Close implements DB.
 func (db *RocksDB) Close() error {
+	db.closeMtx.Lock()
+	defer db.closeMtx.Unlock()
+
+	if db.closed {
+		return tmdb.ErrClosed
+	}
+	db.closed = true
+
 	db.ro.Destroy()
 	db.wo.Destroy()
 	db.woSync.Destroy()
@@ -143,29 +472,45 @@ func (db *RocksDB) Close() error {
 	return nil
 }
 
-// Print implements DB.
-func (db *RocksDB) Print() error {
-	itr, err := db.Iterator(nil, nil)
-	if err != nil {
-		return err
-	}
-	defer itr.Close()
-	for ; itr.Valid(); itr.Next() {
-		key := itr.Key()
-		value := itr.Value()
-		fmt.Printf("[%X]:\t[%X]\n", key, value)
-	}
-	return nil
+// Dump implements DB.
+func (db *RocksDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, db, opts)
 }
 
 // Stats implements DB.
-func (db *RocksDB) Stats() map[string]string {
-	keys := []string{"rocksdb.stats"}
-	stats := make(map[string]string, len(keys))
+func (db *RocksDB) Stats() (*tmdb.Stats, error) {
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	keys := []string{
+		"rocksdb.block-cache-usage",
+		"rocksdb.compaction-pending",
+		"rocksdb.cur-size-all-mem-tables",
+		"rocksdb.estimate-num-keys",
+		"rocksdb.num-running-compactions",
+		"rocksdb.stats",
+		"rocksdb.total-sst-files-size",
+	}
+	properties := make(map[string]string, len(keys))
 	for _, key := range keys {
-		stats[key] = db.db.GetProperty(key)
+		properties[key] = db.db.GetProperty(key)
 	}
-	return stats
+	// Properties which are unavailable are empty, and reported as 0.
+	intProperty := func(key string) int64 {
+		value, _ := strconv.ParseInt(properties[key], 10, 64)
+		return value
+	}
+	return &tmdb.Stats{
+		Backend:      "rocksdb",
+		KeyCount:     intProperty("rocksdb.estimate-num-keys"),
+		DiskSize:     intProperty("rocksdb.total-sst-files-size"),
+		MemTableSize: intProperty("rocksdb.cur-size-all-mem-tables"),
+		CacheSize:    intProperty("rocksdb.block-cache-usage"),
+		Properties:   properties,
+	}, nil
 }
 
 // NewBatch implements DB.
@@ -178,8 +523,14 @@ func (db *RocksDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
 	itr := db.db.NewIterator(db.ro)
-	return newRocksDBIterator(itr, start, end, false), nil
+	return newRocksDBIterator(itr, db.cmp, start, end, false), nil
 }
 
 // ReverseIterator implements DB.
@@ -187,6 +538,75 @@ func (db *RocksDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, tmdb.ErrKeyEmpty
 	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
 	itr := db.db.NewIterator(db.ro)
-	return newRocksDBIterator(itr, start, end, true), nil
+	return newRocksDBIterator(itr, db.cmp, start, end, true), nil
+}
+
+// IteratorWithOptions implements IteratorOptionsDB. RocksDB does not prefetch values, so
+// PrefetchSize is ignored.
+func (db *RocksDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, tmdb.ErrKeyEmpty
+	}
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(opts.FillCache)
+	if opts.ReadAheadSize > 0 {
+		ro.SetReadaheadSize(uint64(opts.ReadAheadSize))
+	}
+	itr := newRocksDBIterator(db.db.NewIterator(ro), db.cmp, start, end, opts.Reverse)
+	itr.keysOnly = opts.KeysOnly
+	itr.ro = ro
+	return itr, nil
+}
+
+// NewSnapshot implements DB.
+func (db *RocksDB) NewSnapshot() (tmdb.Snapshot, error) {
+	db.closeMtx.RLock()
+	defer db.closeMtx.RUnlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	return newRocksDBSnapshot(db), nil
+}
+
+// NewTransaction implements TransactionDB. gorocksdb does not expose RocksDB's optimistic
+// transactions, so this uses read-set tracking, and commits while blocking other writes through
+// this RocksDB. Writes made directly to the underlying gorocksdb.DB are not detected. It fails
+// with ErrReadOnly for read-only databases.
+func (db *RocksDB) NewTransaction() (tmdb.Transaction, error) {
+	if db.readOnly {
+		return nil, tmdb.ErrReadOnly
+	}
+	return tmdb.NewOptimisticTransaction(db, db.commitTransaction)
+}
+
+// commitTransaction validates and writes a transaction while blocking other writes.
+func (db *RocksDB) commitTransaction(batch tmdb.Batch, validate func(tmdb.Reader) error) error {
+	b := batch.(*rocksDBBatch)
+	if b.batch == nil {
+		return tmdb.ErrBatchClosed
+	}
+	db.commitMtx.Lock()
+	defer db.commitMtx.Unlock()
+
+	if err := validate(db); err != nil {
+		return err
+	}
+	if err := b.write(db.wo); err != nil {
+		return err
+	}
+	return b.Close()
 }
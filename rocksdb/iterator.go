@@ -1,50 +1,68 @@
 package rocksdb
 
 import (
-	"bytes"
-
 	"github.com/tecbot/gorocksdb"
 	tmdb "github.com/tendermint/tm-db"
 )
 
 type rocksDBIterator struct {
 	source     *gorocksdb.Iterator
+	cmp        tmdb.Comparer
 	start, end []byte
 	isReverse  bool
 	isInvalid  bool
+	keysOnly   bool // Value returns nil
+
+	// ro are read options owned by the iterator, if any, which are destroyed on Close.
+	ro *gorocksdb.ReadOptions
 }
 
-var _ tmdb.Iterator = (*rocksDBIterator)(nil)
+var _ tmdb.SeekableIterator = (*rocksDBIterator)(nil)
 
-func newRocksDBIterator(source *gorocksdb.Iterator, start, end []byte, isReverse bool) *rocksDBIterator {
-	if isReverse {
-		if end == nil {
-			source.SeekToLast()
-		} else {
-			source.Seek(end)
-			if source.Valid() {
-				eoakey := moveSliceToBytes(source.Key()) // end or after key
-				if bytes.Compare(end, eoakey) <= 0 {
-					source.Prev()
-				}
-			} else {
-				source.SeekToLast()
-			}
-		}
-	} else {
-		if start == nil {
-			source.SeekToFirst()
-		} else {
-			source.Seek(start)
-		}
-	}
-	return &rocksDBIterator{
+func newRocksDBIterator(source *gorocksdb.Iterator, cmp tmdb.Comparer, start, end []byte,
+	isReverse bool) *rocksDBIterator {
+	itr := &rocksDBIterator{
 		source:    source,
+		cmp:       cmp,
 		start:     start,
 		end:       end,
 		isReverse: isReverse,
 		isInvalid: false,
 	}
+	if isReverse {
+		itr.seekReverse(end, false)
+	} else {
+		itr.seekForward(start)
+	}
+	return itr
+}
+
+// seekForward moves the source to the first key at or after the given key, or to the first key
+// if nil.
+func (itr *rocksDBIterator) seekForward(key []byte) {
+	if key == nil {
+		itr.source.SeekToFirst()
+	} else {
+		itr.source.Seek(key)
+	}
+}
+
+// seekReverse moves the source to the last key before the given key (or at it, if inclusive), or
+// to the last key if nil.
+func (itr *rocksDBIterator) seekReverse(key []byte, inclusive bool) {
+	if key == nil {
+		itr.source.SeekToLast()
+		return
+	}
+	itr.source.Seek(key)
+	if !itr.source.Valid() {
+		itr.source.SeekToLast()
+		return
+	}
+	eoakey := moveSliceToBytes(itr.source.Key()) // key or after key
+	if c := itr.cmp.Compare(eoakey, key); c > 0 || (c == 0 && !inclusive) {
+		itr.source.Prev()
+	}
 }
 
 // Domain implements Iterator.
@@ -77,12 +95,12 @@ func (itr *rocksDBIterator) Valid() bool {
 	var end = itr.end
 	var key = moveSliceToBytes(itr.source.Key())
 	if itr.isReverse {
-		if start != nil && bytes.Compare(key, start) < 0 {
+		if start != nil && itr.cmp.Compare(key, start) < 0 {
 			itr.isInvalid = true
 			return false
 		}
 	} else {
-		if end != nil && bytes.Compare(end, key) <= 0 {
+		if end != nil && itr.cmp.Compare(end, key) <= 0 {
 			itr.isInvalid = true
 			return false
 		}
@@ -101,6 +119,9 @@ func (itr *rocksDBIterator) Key() []byte {
 // Value implements Iterator.
 func (itr *rocksDBIterator) Value() []byte {
 	itr.assertIsValid()
+	if itr.keysOnly {
+		return nil
+	}
 	return moveSliceToBytes(itr.source.Value())
 }
 
@@ -114,6 +135,21 @@ func (itr rocksDBIterator) Next() {
 	}
 }
 
+// Seek implements SeekableIterator.
+func (itr *rocksDBIterator) Seek(key []byte) {
+	itr.isInvalid = false
+	switch {
+	case itr.isReverse && itr.end != nil && itr.cmp.Compare(key, itr.end) >= 0:
+		itr.seekReverse(itr.end, false)
+	case itr.isReverse:
+		itr.seekReverse(key, true)
+	case itr.start != nil && itr.cmp.Compare(key, itr.start) < 0:
+		itr.seekForward(itr.start)
+	default:
+		itr.seekForward(key)
+	}
+}
+
 // Error implements Iterator.
 func (itr *rocksDBIterator) Error() error {
 	return itr.source.Err()
@@ -122,6 +158,10 @@ func (itr *rocksDBIterator) Error() error {
 // Close implements Iterator.
 func (itr *rocksDBIterator) Close() error {
 	itr.source.Close()
+	if itr.ro != nil {
+		itr.ro.Destroy()
+		itr.ro = nil
+	}
 	return nil
 }
 
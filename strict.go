@@ -0,0 +1,14 @@
+package db
+
+// GetStrict fetches the value of the given key from a reader like Get, but returns ErrNotFound
+// rather than a nil value if the key does not exist.
+func GetStrict(r Reader, key []byte) ([]byte, error) {
+	value, err := r.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
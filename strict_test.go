@@ -0,0 +1,31 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+)
+
+func TestGetStrict(t *testing.T) {
+	db := mockDBWithStuff(t)
+	require.NoError(t, db.Set([]byte("empty"), []byte{}))
+
+	value, err := tmdb.GetStrict(db, []byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), value)
+
+	value, err = tmdb.GetStrict(db, []byte("empty"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, value)
+
+	_, err = tmdb.GetStrict(db, []byte("missing"))
+	require.Equal(t, tmdb.ErrNotFound, err)
+
+	_, err = tmdb.GetStrict(db, []byte{})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+
+	require.NoError(t, db.Close())
+	_, err = tmdb.GetStrict(db, []byte("key1"))
+	require.Equal(t, tmdb.ErrClosed, err)
+}
@@ -0,0 +1,308 @@
+package db
+
+import (
+	"bytes"
+
+	"github.com/google/btree"
+)
+
+// txnBTreeDegree is the B-tree degree used for a transaction's pending writes.
+const txnBTreeDegree = 32
+
+// TxnCommitFunc atomically validates and writes a transaction created by NewOptimisticTransaction.
+// It must call validate with a reader of the latest state of the database, and if validate
+// succeeds write the batch, without allowing any other writes to the database in between. Errors
+// returned by validate (such as ErrConflict) must be returned as-is.
+type TxnCommitFunc func(batch Batch, validate func(Reader) error) error
+
+// optimisticTransaction is a generic Transaction for databases which do not support transactions
+// natively. Reads are served from a snapshot taken when the transaction begins, merged with the
+// transaction's pending writes, and the keys and key ranges read from the snapshot are recorded
+// in a read set. On commit, the read set is validated against the latest state of the database
+// and the writes are applied atomically, both by the backend's commit function.
+type optimisticTransaction struct {
+	snapshot Snapshot
+	cmp      Comparer
+	batch    Batch
+	commit   TxnCommitFunc
+
+	// writes contains pending writes as txnEntry items, where a nil value is a delete.
+	writes *btree.BTree
+	// reads contains the values of keys read from the snapshot, where a nil value is a missing key.
+	reads map[string][]byte
+	// ranges contains the key ranges read from the snapshot by iterators.
+	ranges []*txnRangeRead
+}
+
+var _ Transaction = (*optimisticTransaction)(nil)
+
+// NewOptimisticTransaction creates a Transaction with read-set tracking for a database which does
+// not support transactions natively. The given commit function must atomically validate the
+// transaction's read set and write its batch, which was created by the database's NewBatch. Keys
+// are ordered by the database's KeyComparer.
+func NewOptimisticTransaction(db DB, commit TxnCommitFunc) (Transaction, error) {
+	snapshot, err := db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &optimisticTransaction{
+		snapshot: snapshot,
+		cmp:      KeyComparer(db),
+		batch:    db.NewBatch(),
+		commit:   commit,
+		writes:   btree.New(txnBTreeDegree),
+		reads:    make(map[string][]byte),
+	}, nil
+}
+
+// Get implements Transaction.
+func (txn *optimisticTransaction) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrKeyEmpty
+	}
+	if txn.snapshot == nil {
+		return nil, ErrTransactionClosed
+	}
+	if i := txn.writes.Get(txn.entry(key, nil)); i != nil {
+		return i.(txnEntry).value, nil
+	}
+	value, err := txn.snapshot.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	txn.reads[string(key)] = value
+	return value, nil
+}
+
+// Has implements Transaction.
+func (txn *optimisticTransaction) Has(key []byte) (bool, error) {
+	value, err := txn.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+// Iterator implements Transaction.
+func (txn *optimisticTransaction) Iterator(start, end []byte) (Iterator, error) {
+	return txn.newIterator(start, end, false)
+}
+
+// ReverseIterator implements Transaction.
+func (txn *optimisticTransaction) ReverseIterator(start, end []byte) (Iterator, error) {
+	return txn.newIterator(start, end, true)
+}
+
+func (txn *optimisticTransaction) newIterator(start, end []byte, reverse bool) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, ErrKeyEmpty
+	}
+	if txn.snapshot == nil {
+		return nil, ErrTransactionClosed
+	}
+	var (
+		source Iterator
+		err    error
+	)
+	if reverse {
+		source, err = txn.snapshot.ReverseIterator(start, end)
+	} else {
+		source, err = txn.snapshot.Iterator(start, end)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newTxnIterator(txn, source, start, end, reverse), nil
+}
+
+// pendingWrites returns the pending writes within the given range, in iteration order.
+func (txn *optimisticTransaction) pendingWrites(start, end []byte, reverse bool) []txnEntry {
+	var writes []txnEntry
+	visitor := func(i btree.Item) bool {
+		entry := i.(txnEntry)
+		if end != nil && txn.cmp.Compare(entry.key, end) >= 0 {
+			return false
+		}
+		writes = append(writes, entry)
+		return true
+	}
+	if start != nil {
+		txn.writes.AscendGreaterOrEqual(txn.entry(start, nil), visitor)
+	} else {
+		txn.writes.Ascend(visitor)
+	}
+	if reverse {
+		for i, j := 0, len(writes)-1; i < j; i, j = i+1, j-1 {
+			writes[i], writes[j] = writes[j], writes[i]
+		}
+	}
+	return writes
+}
+
+// newRangeRead records a new range read by an iterator.
+func (txn *optimisticTransaction) newRangeRead(start, end []byte, reverse bool) *txnRangeRead {
+	read := &txnRangeRead{start: start, end: end, reverse: reverse, cmp: txn.cmp}
+	// An empty range, e.g. from seeking past the end of the domain, cannot read anything.
+	if start == nil || end == nil || txn.cmp.Compare(start, end) < 0 {
+		txn.ranges = append(txn.ranges, read)
+	}
+	return read
+}
+
+// Set implements Transaction.
+func (txn *optimisticTransaction) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrKeyEmpty
+	}
+	if value == nil {
+		return ErrValueNil
+	}
+	if txn.snapshot == nil {
+		return ErrTransactionClosed
+	}
+	if err := txn.batch.Set(key, value); err != nil {
+		return err
+	}
+	txn.writes.ReplaceOrInsert(txn.entry(key, value))
+	return nil
+}
+
+// Delete implements Transaction.
+func (txn *optimisticTransaction) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrKeyEmpty
+	}
+	if txn.snapshot == nil {
+		return ErrTransactionClosed
+	}
+	if err := txn.batch.Delete(key); err != nil {
+		return err
+	}
+	txn.writes.ReplaceOrInsert(txn.entry(key, nil))
+	return nil
+}
+
+// Commit implements Transaction.
+func (txn *optimisticTransaction) Commit() error {
+	if txn.snapshot == nil {
+		return ErrTransactionClosed
+	}
+	defer txn.Close()
+
+	// A transaction without writes has nothing to commit, and its reads were consistent anyway.
+	if txn.writes.Len() == 0 {
+		return nil
+	}
+	return txn.commit(txn.batch, txn.validate)
+}
+
+// validate checks that the keys and ranges read by the transaction are unchanged in the reader.
+func (txn *optimisticTransaction) validate(r Reader) error {
+	for key, value := range txn.reads {
+		current, err := r.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		if (current == nil) != (value == nil) || !bytes.Equal(current, value) {
+			return ErrConflict
+		}
+	}
+	for _, read := range txn.ranges {
+		if err := read.validate(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements Transaction.
+func (txn *optimisticTransaction) Close() error {
+	if txn.snapshot == nil {
+		return nil
+	}
+	err := txn.batch.Close()
+	if serr := txn.snapshot.Close(); err == nil {
+		err = serr
+	}
+	txn.snapshot = nil
+	txn.batch = nil
+	txn.writes = nil
+	txn.reads = nil
+	txn.ranges = nil
+	return err
+}
+
+// entry creates a txnEntry for the transaction's B-tree of pending writes.
+func (txn *optimisticTransaction) entry(key, value []byte) txnEntry {
+	return txnEntry{key: key, value: value, cmp: txn.cmp}
+}
+
+// txnEntry is a key/value pair, used both as a B-tree item for pending writes and to record
+// entries seen by iterators.
+type txnEntry struct {
+	key   []byte
+	value []byte
+	cmp   Comparer // orders B-tree items
+}
+
+// Less implements btree.Item.
+func (e txnEntry) Less(i btree.Item) bool {
+	return e.cmp.Compare(e.key, i.(txnEntry).key) < 0
+}
+
+// txnRangeRead records the entries read from the snapshot by an iterator. The iterator has read
+// every entry in its domain up to and including last, or the whole domain if exhausted is set.
+type txnRangeRead struct {
+	start     []byte
+	end       []byte
+	reverse   bool
+	cmp       Comparer
+	seen      []txnEntry
+	last      []byte
+	exhausted bool
+}
+
+// validate checks that the part of the range read by the iterator is unchanged in the reader.
+func (read *txnRangeRead) validate(r Reader) error {
+	var (
+		itr Iterator
+		err error
+	)
+	if read.reverse {
+		itr, err = r.ReverseIterator(read.start, read.end)
+	} else {
+		itr, err = r.Iterator(read.start, read.end)
+	}
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	i := 0
+	for ; itr.Valid(); itr.Next() {
+		key := itr.Key()
+		if !read.exhausted && (read.last == nil || read.beyond(key)) {
+			break
+		}
+		if i >= len(read.seen) || !bytes.Equal(key, read.seen[i].key) ||
+			!bytes.Equal(itr.Value(), read.seen[i].value) {
+			return ErrConflict
+		}
+		i++
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	if i != len(read.seen) {
+		return ErrConflict
+	}
+	return nil
+}
+
+// beyond returns whether the key comes after the last key read, in iteration order.
+func (read *txnRangeRead) beyond(key []byte) bool {
+	if read.reverse {
+		return read.cmp.Compare(key, read.last) < 0
+	}
+	return read.cmp.Compare(key, read.last) > 0
+}
@@ -0,0 +1,169 @@
+package db
+
+import "sort"
+
+// txnIterator is an iterator over an optimisticTransaction, which merges an iterator over the
+// transaction's snapshot with its pending writes, and records the snapshot entries it reads.
+type txnIterator struct {
+	txn     *optimisticTransaction
+	source  Iterator
+	start   []byte
+	end     []byte
+	reverse bool
+
+	domainWrites []txnEntry // pending writes in the domain, in iteration order
+	writes       []txnEntry // remaining pending writes, in iteration order
+	read         *txnRangeRead
+	key          []byte
+	value        []byte
+	valid        bool
+	err          error
+}
+
+var _ SeekableIterator = (*txnIterator)(nil)
+
+func newTxnIterator(txn *optimisticTransaction, source Iterator, start, end []byte, reverse bool) *txnIterator {
+	writes := txn.pendingWrites(start, end, reverse)
+	itr := &txnIterator{
+		txn:          txn,
+		source:       source,
+		start:        start,
+		end:          end,
+		reverse:      reverse,
+		domainWrites: writes,
+		writes:       writes,
+		read:         txn.newRangeRead(start, end, reverse),
+	}
+	itr.settle()
+	return itr
+}
+
+// settle moves the iterator to the next visible entry, which is either the next pending write
+// (skipping deletes) or the next snapshot entry, whichever comes first in iteration order. Pending
+// writes shadow snapshot entries with the same key.
+func (itr *txnIterator) settle() {
+	for {
+		sourceValid := itr.source.Valid()
+		if !sourceValid {
+			if err := itr.source.Error(); err != nil {
+				itr.err = err
+				itr.valid = false
+				return
+			}
+			if len(itr.writes) == 0 {
+				itr.read.exhausted = true
+				itr.valid = false
+				return
+			}
+		}
+
+		cmp := 0
+		if sourceValid && len(itr.writes) > 0 {
+			cmp = itr.txn.cmp.Compare(itr.source.Key(), itr.writes[0].key)
+			if itr.reverse {
+				cmp = -cmp
+			}
+		}
+
+		if len(itr.writes) > 0 && (!sourceValid || cmp >= 0) {
+			write := itr.writes[0]
+			itr.writes = itr.writes[1:]
+			if sourceValid && cmp == 0 {
+				itr.observe()
+				itr.source.Next()
+			}
+			if write.value == nil {
+				continue
+			}
+			itr.key, itr.value = write.key, write.value
+		} else {
+			itr.key, itr.value = itr.observe()
+			itr.source.Next()
+		}
+		itr.read.last = itr.key
+		itr.valid = true
+		return
+	}
+}
+
+// observe records the current snapshot entry in the read set, and returns a copy of it.
+func (itr *txnIterator) observe() ([]byte, []byte) {
+	key, value := cp(itr.source.Key()), cp(itr.source.Value())
+	itr.read.seen = append(itr.read.seen, txnEntry{key: key, value: value})
+	return key, value
+}
+
+// Domain implements Iterator.
+func (itr *txnIterator) Domain() (start []byte, end []byte) {
+	return itr.start, itr.end
+}
+
+// Valid implements Iterator.
+func (itr *txnIterator) Valid() bool {
+	return itr.valid
+}
+
+// Next implements Iterator.
+func (itr *txnIterator) Next() {
+	itr.assertIsValid()
+	itr.settle()
+}
+
+// Seek implements SeekableIterator. It fails with ErrNotSupported if the snapshot iterator does
+// not implement SeekableIterator.
+func (itr *txnIterator) Seek(key []byte) {
+	source, ok := itr.source.(SeekableIterator)
+	if !ok {
+		itr.err = ErrNotSupported
+		itr.valid = false
+		return
+	}
+
+	// Entries read after seeking are recorded as a new range read, which begins at the key
+	// (inclusive) and is clamped to the iterator's domain.
+	start, end := itr.start, itr.end
+	if itr.reverse && (end == nil || itr.txn.cmp.Compare(key, end) < 0) {
+		end = append(cp(key), 0)
+	} else if !itr.reverse && (start == nil || itr.txn.cmp.Compare(key, start) > 0) {
+		start = cp(key)
+	}
+	itr.read = itr.txn.newRangeRead(start, end, itr.reverse)
+	itr.writes = itr.domainWrites[sort.Search(len(itr.domainWrites), func(i int) bool {
+		if itr.reverse {
+			return end == nil || itr.txn.cmp.Compare(itr.domainWrites[i].key, end) < 0
+		}
+		return start == nil || itr.txn.cmp.Compare(itr.domainWrites[i].key, start) >= 0
+	}):]
+
+	source.Seek(key)
+	itr.err = nil
+	itr.settle()
+}
+
+// Key implements Iterator.
+func (itr *txnIterator) Key() []byte {
+	itr.assertIsValid()
+	return itr.key
+}
+
+// Value implements Iterator.
+func (itr *txnIterator) Value() []byte {
+	itr.assertIsValid()
+	return itr.value
+}
+
+// Error implements Iterator.
+func (itr *txnIterator) Error() error {
+	return itr.err
+}
+
+// Close implements Iterator.
+func (itr *txnIterator) Close() error {
+	return itr.source.Close()
+}
+
+func (itr *txnIterator) assertIsValid() {
+	if !itr.valid {
+		panic("iterator is invalid")
+	}
+}
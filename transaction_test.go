@@ -0,0 +1,230 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+func TestOptimisticTransactionIterator(t *testing.T) {
+	db := memdb.NewDB()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+	txn, err := db.NewTransaction()
+	require.NoError(t, err)
+	defer txn.Close()
+
+	require.NoError(t, txn.Set([]byte("a"), []byte("A")))
+	require.NoError(t, txn.Delete([]byte("b")))
+	require.NoError(t, txn.Set([]byte("bb"), []byte("BB")))
+	require.NoError(t, txn.Delete([]byte("d")))
+	require.NoError(t, txn.Set([]byte("e"), []byte("E")))
+
+	testCases := map[string]struct {
+		start, end []byte
+		reverse    bool
+		expect     []string
+	}{
+		"all":            {nil, nil, false, []string{"a=A", "bb=BB", "c=c", "e=E"}},
+		"all reverse":    {nil, nil, true, []string{"e=E", "c=c", "bb=BB", "a=A"}},
+		"range":          {[]byte("b"), []byte("d"), false, []string{"bb=BB", "c=c"}},
+		"range reverse":  {[]byte("a"), []byte("c"), true, []string{"bb=BB", "a=A"}},
+		"deleted only":   {[]byte("d"), []byte("e"), false, nil},
+		"pending writes": {[]byte("e"), nil, true, []string{"e=E"}},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var (
+				itr tmdb.Iterator
+				err error
+			)
+			if tc.reverse {
+				itr, err = txn.ReverseIterator(tc.start, tc.end)
+			} else {
+				itr, err = txn.Iterator(tc.start, tc.end)
+			}
+			require.NoError(t, err)
+			defer itr.Close()
+
+			var actual []string
+			for ; itr.Valid(); itr.Next() {
+				actual = append(actual, string(itr.Key())+"="+string(itr.Value()))
+			}
+			require.NoError(t, itr.Error())
+			assert.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestOptimisticTransactionIteratorSeek(t *testing.T) {
+	db := memdb.NewDB()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, db.Set([]byte(key), []byte(key)))
+	}
+	txn, err := db.NewTransaction()
+	require.NoError(t, err)
+	defer txn.Close()
+
+	require.NoError(t, txn.Delete([]byte("b")))
+	require.NoError(t, txn.Set([]byte("bb"), []byte("bb")))
+	require.NoError(t, txn.Delete([]byte("d")))
+
+	collect := func(itr tmdb.Iterator) []string {
+		var keys []string
+		for ; itr.Valid(); itr.Next() {
+			keys = append(keys, string(itr.Key()))
+		}
+		return keys
+	}
+
+	itr, err := txn.Iterator([]byte("b"), nil)
+	require.NoError(t, err)
+	itr.(tmdb.SeekableIterator).Seek([]byte("c"))
+	assert.Equal(t, []string{"c", "e"}, collect(itr))
+	itr.(tmdb.SeekableIterator).Seek([]byte("a"))
+	assert.Equal(t, []string{"bb", "c", "e"}, collect(itr))
+	require.NoError(t, itr.Close())
+
+	ritr, err := txn.ReverseIterator(nil, []byte("e"))
+	require.NoError(t, err)
+	ritr.(tmdb.SeekableIterator).Seek([]byte("bc"))
+	assert.Equal(t, []string{"bb", "a"}, collect(ritr))
+	ritr.(tmdb.SeekableIterator).Seek([]byte("z"))
+	assert.Equal(t, []string{"c", "bb", "a"}, collect(ritr))
+	require.NoError(t, ritr.Close())
+}
+
+func TestOptimisticTransactionConflicts(t *testing.T) {
+	testCases := map[string]struct {
+		read   func(tmdb.Transaction)
+		write  func(tmdb.DB)
+		expect error
+	}{
+		"get changed": {
+			func(txn tmdb.Transaction) { _, _ = txn.Get([]byte("b")) },
+			func(db tmdb.DB) { _ = db.Set([]byte("b"), []byte{9}) },
+			tmdb.ErrConflict,
+		},
+		"get changed to same value": {
+			func(txn tmdb.Transaction) { _, _ = txn.Get([]byte("b")) },
+			func(db tmdb.DB) { _ = db.Set([]byte("b"), []byte("b")) },
+			nil,
+		},
+		"has created": {
+			func(txn tmdb.Transaction) { _, _ = txn.Has([]byte("x")) },
+			func(db tmdb.DB) { _ = db.Set([]byte("x"), []byte{}) },
+			tmdb.ErrConflict,
+		},
+		"get after own write": {
+			func(txn tmdb.Transaction) {
+				_ = txn.Set([]byte("b"), []byte{1})
+				_, _ = txn.Get([]byte("b"))
+			},
+			func(db tmdb.DB) { _ = db.Set([]byte("b"), []byte{9}) },
+			nil,
+		},
+		"iterated key changed": {
+			func(txn tmdb.Transaction) { iterate(txn, nil, nil, false, 2) },
+			func(db tmdb.DB) { _ = db.Set([]byte("b"), []byte{9}) },
+			tmdb.ErrConflict,
+		},
+		"key inserted into iterated range": {
+			func(txn tmdb.Transaction) { iterate(txn, nil, nil, false, 2) },
+			func(db tmdb.DB) { _ = db.Set([]byte("aa"), []byte{9}) },
+			tmdb.ErrConflict,
+		},
+		"key inserted into exhausted range": {
+			func(txn tmdb.Transaction) { iterate(txn, []byte("c"), []byte("f"), false, -1) },
+			func(db tmdb.DB) { _ = db.Set([]byte("e"), []byte{9}) },
+			tmdb.ErrConflict,
+		},
+		"key deleted from reverse range": {
+			func(txn tmdb.Transaction) { iterate(txn, nil, nil, true, 2) },
+			func(db tmdb.DB) { _ = db.Delete([]byte("c")) },
+			tmdb.ErrConflict,
+		},
+		"key inserted beyond iterated keys": {
+			func(txn tmdb.Transaction) { iterate(txn, nil, nil, false, 2) },
+			func(db tmdb.DB) { _ = db.Set([]byte("cc"), []byte{9}) },
+			nil,
+		},
+		"key inserted beyond reverse iterated keys": {
+			func(txn tmdb.Transaction) { iterate(txn, nil, nil, true, 2) },
+			func(db tmdb.DB) { _ = db.Set([]byte("aa"), []byte{9}) },
+			nil,
+		},
+		"key changed after seek": {
+			func(txn tmdb.Transaction) {
+				itr, _ := txn.Iterator(nil, nil)
+				itr.(tmdb.SeekableIterator).Seek([]byte("bb"))
+				itr.Close()
+			},
+			func(db tmdb.DB) { _ = db.Set([]byte("c"), []byte{9}) },
+			tmdb.ErrConflict,
+		},
+		"key inserted before seek": {
+			func(txn tmdb.Transaction) {
+				itr, _ := txn.Iterator(nil, nil)
+				itr.(tmdb.SeekableIterator).Seek([]byte("d"))
+				itr.Close()
+			},
+			func(db tmdb.DB) { _ = db.Set([]byte("bb"), []byte{9}) },
+			nil,
+		},
+		"key inserted outside range": {
+			func(txn tmdb.Transaction) { iterate(txn, []byte("a"), []byte("c"), false, -1) },
+			func(db tmdb.DB) { _ = db.Set([]byte("cc"), []byte{9}) },
+			nil,
+		},
+	}
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			db := memdb.NewDB()
+			for _, key := range []string{"a", "b", "c", "d"} {
+				require.NoError(t, db.Set([]byte(key), []byte(key)))
+			}
+			txn, err := db.NewTransaction()
+			require.NoError(t, err)
+			defer txn.Close()
+
+			tc.read(txn)
+			require.NoError(t, txn.Set([]byte("z"), []byte{1}))
+			tc.write(db)
+			require.Equal(t, tc.expect, txn.Commit())
+
+			value, err := db.Get([]byte("z"))
+			require.NoError(t, err)
+			if tc.expect == nil {
+				assert.Equal(t, []byte{1}, value)
+			} else {
+				assert.Nil(t, value)
+			}
+		})
+	}
+}
+
+// iterate reads up to n items (or all items if n is negative) from a transaction iterator.
+func iterate(txn tmdb.Transaction, start, end []byte, reverse bool, n int) {
+	var (
+		itr tmdb.Iterator
+		err error
+	)
+	if reverse {
+		itr, err = txn.ReverseIterator(start, end)
+	} else {
+		itr, err = txn.Iterator(start, end)
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer itr.Close()
+	for ; itr.Valid() && n != 0; itr.Next() {
+		n--
+	}
+}
@@ -0,0 +1,52 @@
+package ttldb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// ttlDBBatch encodes values written to a batch of the data namespace.
+type ttlDBBatch struct {
+	source tmdb.Batch
+}
+
+var _ tmdb.Batch = (*ttlDBBatch)(nil)
+
+func newTTLDBBatch(source tmdb.Batch) *ttlDBBatch {
+	return &ttlDBBatch{source: source}
+}
+
+// Set implements Batch.
+func (b *ttlDBBatch) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return b.source.Set(key, encode(value, 0))
+}
+
+// Delete implements Batch.
+func (b *ttlDBBatch) Delete(key []byte) error {
+	return b.source.Delete(key)
+}
+
+// DeleteRange implements Batch.
+func (b *ttlDBBatch) DeleteRange(start, end []byte) error {
+	return b.source.DeleteRange(start, end)
+}
+
+// Write implements Batch.
+func (b *ttlDBBatch) Write() error {
+	return b.source.Write()
+}
+
+// WriteSync implements Batch.
+func (b *ttlDBBatch) WriteSync() error {
+	return b.source.WriteSync()
+}
+
+// Close implements Batch.
+func (b *ttlDBBatch) Close() error {
+	return b.source.Close()
+}
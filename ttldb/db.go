@@ -0,0 +1,292 @@
+// Package ttldb provides a database wrapper which supports keys that expire, for backends without
+// native support for them.
+package ttldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+const (
+	// DefaultSweepInterval is the default interval between background deletions of expired keys.
+	DefaultSweepInterval = time.Minute
+
+	// expiryLen is the length of the expiry time prefixed to values and index keys, as a big-endian
+	// Unix timestamp in nanoseconds, or 0 for keys which do not expire.
+	expiryLen = 8
+
+	// sweepBatchSize is the maximum number of index entries read at a time while sweeping.
+	sweepBatchSize = 1000
+)
+
+var (
+	// dataPrefix namespaces keys, whose values are prefixed by their expiry time.
+	dataPrefix = []byte{'d'}
+	// indexPrefix namespaces the expiry index, whose keys are prefixed by their expiry time such
+	// that they are ordered by it. Index entries may be stale, e.g. if the key was set again.
+	indexPrefix = []byte{'x'}
+)
+
+// Options configures a TTLDB.
+type Options struct {
+	// SweepInterval is the interval between background deletions of expired keys, which are
+	// hidden from reads as soon as they expire. Defaults to DefaultSweepInterval if 0, and
+	// disables background deletions if negative.
+	SweepInterval time.Duration
+}
+
+// TTLDB wraps a database to support keys that expire, by storing values along with their expiry
+// time, and an index of keys by expiry time. Expired keys are hidden from reads, and deleted by a
+// background sweep. The wrapped database must only be used through the TTLDB, since its keys and
+// values are encoded, and it is closed along with the TTLDB.
+//
+// Set, Update and CompareAndSwap write keys which do not expire. Expired keys are deleted using
+// CompareAndSwap, with the same caveats as DB.Update for the wrapped database.
+type TTLDB struct {
+	reader
+	db    tmdb.DB
+	data  *tmdb.PrefixDB
+	index *tmdb.PrefixDB
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+var _ tmdb.TTLDB = (*TTLDB)(nil)
+
+// NewDB wraps a database with default options.
+func NewDB(db tmdb.DB) *TTLDB {
+	return NewDBWithOpts(db, Options{})
+}
+
+// NewDBWithOpts wraps a database with the given options.
+func NewDBWithOpts(db tmdb.DB, opts Options) *TTLDB {
+	data := tmdb.NewPrefixDB(db, dataPrefix)
+	tdb := &TTLDB{
+		reader: reader{source: data, now: time.Now},
+		db:     db,
+		data:   data,
+		index:  tmdb.NewPrefixDB(db, indexPrefix),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	interval := opts.SweepInterval
+	if interval == 0 {
+		interval = DefaultSweepInterval
+	}
+	if interval > 0 {
+		go tdb.sweepLoop(interval)
+	} else {
+		close(tdb.done)
+	}
+	return tdb
+}
+
+// encode prefixes a value with its expiry time.
+func encode(value []byte, expiry int64) []byte {
+	encoded := make([]byte, expiryLen+len(value))
+	binary.BigEndian.PutUint64(encoded, uint64(expiry))
+	copy(encoded[expiryLen:], value)
+	return encoded
+}
+
+// decode returns the value of an encoded value, or nil if it is nil or has expired at the given
+// Unix time in nanoseconds.
+func decode(encoded []byte, now int64) ([]byte, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	if len(encoded) < expiryLen {
+		return nil, fmt.Errorf("invalid encoded value %X", encoded)
+	}
+	expiry := int64(binary.BigEndian.Uint64(encoded))
+	if expiry != 0 && expiry <= now {
+		return nil, nil
+	}
+	return encoded[expiryLen:], nil
+}
+
+// indexKey returns the expiry index key of a key.
+func indexKey(key []byte, expiry int64) []byte {
+	return encode(key, expiry)
+}
+
+// Set implements DB.
+func (tdb *TTLDB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return tdb.data.Set(key, encode(value, 0))
+}
+
+// SetSync implements DB.
+func (tdb *TTLDB) SetSync(key, value []byte) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	return tdb.data.SetSync(key, encode(value, 0))
+}
+
+// SetWithTTL implements TTLDB.
+func (tdb *TTLDB) SetWithTTL(key, value []byte, ttl time.Duration) error {
+	if len(key) == 0 {
+		return tmdb.ErrKeyEmpty
+	}
+	if value == nil {
+		return tmdb.ErrValueNil
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("invalid TTL %v", ttl)
+	}
+	// The index entry is written first, so that expiring keys always have one.
+	expiry := tdb.now().Add(ttl).UnixNano()
+	if err := tdb.index.Set(indexKey(key, expiry), []byte{}); err != nil {
+		return err
+	}
+	return tdb.data.Set(key, encode(value, expiry))
+}
+
+// Delete implements DB.
+func (tdb *TTLDB) Delete(key []byte) error {
+	return tdb.data.Delete(key)
+}
+
+// DeleteSync implements DB.
+func (tdb *TTLDB) DeleteSync(key []byte) error {
+	return tdb.data.DeleteSync(key)
+}
+
+// DeleteRange implements DB. Index entries of the deleted keys are removed by the next sweep.
+func (tdb *TTLDB) DeleteRange(start, end []byte) error {
+	return tdb.data.DeleteRange(start, end)
+}
+
+// Update implements DB.
+func (tdb *TTLDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	return tdb.data.Update(key, func(encoded []byte) ([]byte, bool, error) {
+		value, err := decode(encoded, tdb.now().UnixNano())
+		if err != nil {
+			return nil, false, err
+		}
+		value, del, err := fn(value)
+		if err != nil || del || value == nil {
+			return nil, del, err
+		}
+		return encode(value, 0), false, nil
+	})
+}
+
+// CompareAndSwap implements DB. Expired keys are considered to not exist.
+func (tdb *TTLDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	return tmdb.CompareAndSwapWithUpdate(tdb, key, expected, value)
+}
+
+// NewSnapshot implements DB. Keys expire in the snapshot as they would in the database.
+func (tdb *TTLDB) NewSnapshot() (tmdb.Snapshot, error) {
+	snapshot, err := tdb.data.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return newTTLDBSnapshot(snapshot, tdb.now), nil
+}
+
+// NewBatch implements DB.
+func (tdb *TTLDB) NewBatch() tmdb.Batch {
+	return newTTLDBBatch(tdb.data.NewBatch())
+}
+
+// Close implements DB. It stops the background sweep, and closes the wrapped database.
+func (tdb *TTLDB) Close() error {
+	tdb.closeOnce.Do(func() {
+		close(tdb.stop)
+	})
+	<-tdb.done
+	return tdb.db.Close()
+}
+
+// Dump implements DB.
+func (tdb *TTLDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return tmdb.Dump(w, tdb, opts)
+}
+
+// Stats implements DB. The statistics are those of the wrapped database, so the key count
+// includes the expiry index.
+func (tdb *TTLDB) Stats() (*tmdb.Stats, error) {
+	return tdb.db.Stats()
+}
+
+// sweepLoop periodically deletes expired keys, until the database is closed.
+func (tdb *TTLDB) sweepLoop(interval time.Duration) {
+	defer close(tdb.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tdb.stop:
+			return
+		case <-ticker.C:
+			// Errors are retried by the next sweep, as the index entries are left in place.
+			_ = tdb.sweep()
+		}
+	}
+}
+
+// sweep deletes expired keys and their index entries, along with stale index entries.
+func (tdb *TTLDB) sweep() error {
+	end := make([]byte, expiryLen)
+	binary.BigEndian.PutUint64(end, uint64(tdb.now().UnixNano()+1))
+	for {
+		// Keys cannot be deleted while iterating over them, so we collect a batch of them first.
+		itr, err := tdb.index.Iterator(nil, end)
+		if err != nil {
+			return err
+		}
+		var entries [][]byte
+		for ; itr.Valid() && len(entries) < sweepBatchSize; itr.Next() {
+			entries = append(entries, itr.Key())
+		}
+		err = itr.Error()
+		itr.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := tdb.expire(entry); err != nil {
+				return err
+			}
+		}
+		if len(entries) < sweepBatchSize {
+			return nil
+		}
+	}
+}
+
+// expire deletes an expired key given its index entry, unless it was set again since, and deletes
+// the index entry.
+func (tdb *TTLDB) expire(entry []byte) error {
+	key := entry[expiryLen:]
+	encoded, err := tdb.data.Get(key)
+	if err != nil {
+		return err
+	}
+	if len(encoded) >= expiryLen && bytes.Equal(encoded[:expiryLen], entry[:expiryLen]) {
+		if _, err := tdb.data.CompareAndSwap(key, encoded, nil); err != nil {
+			return err
+		}
+	}
+	return tdb.index.Delete(entry)
+}
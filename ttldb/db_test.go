@@ -0,0 +1,148 @@
+package ttldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/internal/dbtest"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+// testClock is a manually advanced clock.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestDB(t *testing.T) (*TTLDB, tmdb.DB, *testClock) {
+	db := memdb.NewDB()
+	clock := &testClock{now: time.Unix(1600000000, 0)}
+	tdb := NewDBWithOpts(db, Options{SweepInterval: -1})
+	tdb.now = clock.Now
+	return tdb, db, clock
+}
+
+func iterate(t *testing.T, r tmdb.Reader, reverse bool) []string {
+	var (
+		itr tmdb.Iterator
+		err error
+	)
+	if reverse {
+		itr, err = r.ReverseIterator(nil, nil)
+	} else {
+		itr, err = r.Iterator(nil, nil)
+	}
+	require.NoError(t, err)
+	defer itr.Close()
+	keys := []string{}
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, string(itr.Key())+"="+string(itr.Value()))
+	}
+	require.NoError(t, itr.Error())
+	return keys
+}
+
+func TestTTLDBExpiry(t *testing.T) {
+	tdb, db, clock := newTestDB(t)
+	defer tdb.Close()
+
+	require.NoError(t, tdb.Set([]byte("a"), []byte("1")))
+	require.NoError(t, tdb.SetWithTTL([]byte("b"), []byte("2"), 10*time.Second))
+	require.NoError(t, tdb.SetWithTTL([]byte("c"), []byte("3"), 20*time.Second))
+	require.NoError(t, tdb.SetWithTTL([]byte("d"), []byte("4"), 10*time.Second))
+	require.Equal(t, []string{"a=1", "b=2", "c=3", "d=4"}, iterate(t, tdb, false))
+
+	snapshot, err := tdb.NewSnapshot()
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	// setting a key again should replace its TTL
+	require.NoError(t, tdb.Set([]byte("d"), []byte("5")))
+
+	clock.now = clock.now.Add(15 * time.Second)
+	dbtest.Value(t, tdb, []byte("a"), []byte("1"))
+	dbtest.Value(t, tdb, []byte("b"), nil)
+	dbtest.Value(t, tdb, []byte("c"), []byte("3"))
+	dbtest.Value(t, tdb, []byte("d"), []byte("5"))
+	has, err := tdb.Has([]byte("b"))
+	require.NoError(t, err)
+	require.False(t, has)
+	require.Equal(t, []string{"a=1", "c=3", "d=5"}, iterate(t, tdb, false))
+	require.Equal(t, []string{"d=5", "c=3", "a=1"}, iterate(t, tdb, true))
+	require.Equal(t, []string{"a=1", "c=3"}, iterate(t, snapshot, false))
+
+	// expired keys should not exist for updates and compare-and-swap
+	swapped, err := tdb.CompareAndSwap([]byte("b"), nil, []byte("6"))
+	require.NoError(t, err)
+	require.True(t, swapped)
+	swapped, err = tdb.CompareAndSwap([]byte("b"), []byte("2"), nil)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.NoError(t, tdb.Update([]byte("b"), func(value []byte) ([]byte, bool, error) {
+		require.Equal(t, []byte("6"), value)
+		return []byte("7"), false, nil
+	}))
+
+	// sweeping should delete expired keys and index entries, but keep keys which were set again
+	clock.now = clock.now.Add(10 * time.Second)
+	require.NoError(t, tdb.sweep())
+	require.Equal(t, []string{"a=1", "b=7", "d=5"}, iterate(t, tdb, false))
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	rawKeys := []string{}
+	for ; itr.Valid(); itr.Next() {
+		rawKeys = append(rawKeys, string(itr.Key()))
+	}
+	require.NoError(t, itr.Close())
+	require.Equal(t, []string{"da", "db", "dd"}, rawKeys)
+}
+
+func TestTTLDBBatch(t *testing.T) {
+	tdb, _, _ := newTestDB(t)
+	defer tdb.Close()
+
+	require.NoError(t, tdb.SetWithTTL([]byte("a"), []byte("1"), time.Second))
+	batch := tdb.NewBatch()
+	require.NoError(t, batch.Set([]byte("b"), []byte("2")))
+	require.NoError(t, batch.Set([]byte("c"), []byte("3")))
+	require.NoError(t, batch.Delete([]byte("a")))
+	require.Equal(t, tmdb.ErrValueNil, batch.Set([]byte("d"), nil))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	require.Equal(t, []string{"b=2", "c=3"}, iterate(t, tdb, false))
+}
+
+func TestTTLDBBackgroundSweep(t *testing.T) {
+	db := memdb.NewDB()
+	tdb := NewDBWithOpts(db, Options{SweepInterval: 10 * time.Millisecond})
+	defer tdb.Close()
+
+	require.NoError(t, tdb.SetWithTTL([]byte("a"), []byte("1"), time.Millisecond))
+	require.Eventually(t, func() bool {
+		itr, err := db.Iterator(nil, nil)
+		require.NoError(t, err)
+		defer itr.Close()
+		return !itr.Valid()
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTTLDBErrors(t *testing.T) {
+	tdb, _, _ := newTestDB(t)
+	defer tdb.Close()
+
+	require.Equal(t, tmdb.ErrKeyEmpty, tdb.SetWithTTL([]byte{}, []byte("1"), time.Second))
+	require.Equal(t, tmdb.ErrValueNil, tdb.SetWithTTL([]byte("a"), nil, time.Second))
+	require.Error(t, tdb.SetWithTTL([]byte("a"), []byte("1"), 0))
+	require.Equal(t, tmdb.ErrKeyEmpty, tdb.Set(nil, []byte("1")))
+	require.Equal(t, tmdb.ErrValueNil, tdb.Set([]byte("a"), nil))
+	_, err := tdb.Get([]byte{})
+	require.Equal(t, tmdb.ErrKeyEmpty, err)
+	require.Equal(t, tmdb.ErrValueNil, tdb.Update([]byte("a"), func([]byte) ([]byte, bool, error) {
+		return nil, false, nil
+	}))
+}
@@ -0,0 +1,92 @@
+package ttldb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// ttlDBIterator skips expired keys of an iterator over the data namespace, and decodes values.
+// Keys are considered expired as of the time the iterator was created.
+type ttlDBIterator struct {
+	source tmdb.Iterator
+	now    int64
+	value  []byte
+	err    error
+}
+
+var _ tmdb.SeekableIterator = (*ttlDBIterator)(nil)
+
+func newTTLDBIterator(source tmdb.Iterator, now int64) *ttlDBIterator {
+	itr := &ttlDBIterator{source: source, now: now}
+	itr.skipExpired()
+	return itr
+}
+
+// skipExpired moves the source iterator to the next key which has not expired, if necessary.
+func (itr *ttlDBIterator) skipExpired() {
+	for ; itr.err == nil && itr.source.Valid(); itr.source.Next() {
+		itr.value, itr.err = decode(itr.source.Value(), itr.now)
+		if itr.value != nil {
+			return
+		}
+	}
+}
+
+// Domain implements Iterator.
+func (itr *ttlDBIterator) Domain() ([]byte, []byte) {
+	return itr.source.Domain()
+}
+
+// Valid implements Iterator.
+func (itr *ttlDBIterator) Valid() bool {
+	return itr.err == nil && itr.source.Valid()
+}
+
+// Next implements Iterator.
+func (itr *ttlDBIterator) Next() {
+	itr.assertIsValid()
+	itr.source.Next()
+	itr.skipExpired()
+}
+
+// Seek implements SeekableIterator.
+func (itr *ttlDBIterator) Seek(key []byte) {
+	source, ok := itr.source.(tmdb.SeekableIterator)
+	if !ok {
+		itr.err = tmdb.ErrNotSupported
+		return
+	}
+	source.Seek(key)
+	itr.err = nil
+	itr.skipExpired()
+}
+
+// Key implements Iterator.
+func (itr *ttlDBIterator) Key() []byte {
+	itr.assertIsValid()
+	return itr.source.Key()
+}
+
+// Value implements Iterator.
+func (itr *ttlDBIterator) Value() []byte {
+	itr.assertIsValid()
+	return itr.value
+}
+
+// Error implements Iterator.
+func (itr *ttlDBIterator) Error() error {
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.source.Error()
+}
+
+// Close implements Iterator.
+func (itr *ttlDBIterator) Close() error {
+	return itr.source.Close()
+}
+
+func (itr *ttlDBIterator) assertIsValid() {
+	if !itr.Valid() {
+		panic("iterator is invalid")
+	}
+}
@@ -0,0 +1,72 @@
+package ttldb
+
+import (
+	"time"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// reader hides expired keys from, and decodes values of, a Reader of the data namespace. It is
+// shared by TTLDB and its snapshots.
+type reader struct {
+	source tmdb.Reader
+	now    func() time.Time
+}
+
+var _ tmdb.Reader = reader{}
+
+// Get implements Reader.
+func (r reader) Get(key []byte) ([]byte, error) {
+	encoded, err := r.source.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return decode(encoded, r.now().UnixNano())
+}
+
+// Has implements Reader.
+func (r reader) Has(key []byte) (bool, error) {
+	value, err := r.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+// Iterator implements Reader.
+func (r reader) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	itr, err := r.source.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newTTLDBIterator(itr, r.now().UnixNano()), nil
+}
+
+// ReverseIterator implements Reader.
+func (r reader) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	itr, err := r.source.ReverseIterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return newTTLDBIterator(itr, r.now().UnixNano()), nil
+}
+
+// ttlDBSnapshot is a snapshot of a TTLDB.
+type ttlDBSnapshot struct {
+	reader
+	source tmdb.Snapshot
+}
+
+var _ tmdb.Snapshot = (*ttlDBSnapshot)(nil)
+
+func newTTLDBSnapshot(source tmdb.Snapshot, now func() time.Time) *ttlDBSnapshot {
+	return &ttlDBSnapshot{
+		reader: reader{source: source, now: now},
+		source: source,
+	}
+}
+
+// Close implements Snapshot.
+func (s *ttlDBSnapshot) Close() error {
+	return s.source.Close()
+}
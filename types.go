@@ -1,20 +1,47 @@
 package db
 
-import "errors"
+import (
+	"errors"
+	"io"
+	"time"
+)
 
 var (
 	// ErrBatchClosed is returned when a closed or written batch is used.
 	ErrBatchClosed = errors.New("batch has been written or closed")
 
+	// ErrClosed is returned when a closed database is used.
+	ErrClosed = errors.New("database is closed")
+
+	// ErrConflict is returned when committing a transaction which read keys that were modified by
+	// a concurrent write.
+	ErrConflict = errors.New("transaction conflicts with a concurrent write")
+
 	// ErrKeyEmpty is returned when attempting to use an empty or nil key.
 	ErrKeyEmpty = errors.New("key cannot be empty")
 
+	// ErrNotFound is returned by GetStrict when a key does not exist.
+	ErrNotFound = errors.New("key not found")
+
+	// ErrNotSupported is returned when an optional capability is not supported by the database.
+	ErrNotSupported = errors.New("operation is not supported by the database")
+
+	// ErrReadOnly is returned when writing to a database which was opened read-only.
+	ErrReadOnly = errors.New("database is read-only")
+
+	// ErrTransactionClosed is returned when a committed or closed transaction is used.
+	ErrTransactionClosed = errors.New("transaction has been committed or closed")
+
 	// ErrValueNil is returned when attempting to set a nil value.
 	ErrValueNil = errors.New("value cannot be nil")
+
+	// ErrWatcherOverflow is returned by a Watcher which was closed because it fell too far behind
+	// the changes made to the database.
+	ErrWatcherOverflow = errors.New("watcher fell behind database changes")
 )
 
 // DB is the main interface for all database backends. DBs are concurrency-safe. Callers must call
-// Close on the database when done.
+// Close on the database when done, after which its methods return ErrClosed.
 //
 // Keys cannot be nil or empty, while values cannot be nil. Keys and values should be considered
 // read-only, both when returned and when given, and must be copied before they are modified.
@@ -41,6 +68,29 @@ type DB interface {
 	// DeleteSync deletes the key, and flushes the delete to storage before returning.
 	DeleteSync([]byte) error
 
+	// DeleteRange deletes all keys in the domain [start, end), with the same semantics as
+	// Iterator: a nil start deletes from the first key, and a nil end deletes to the last key
+	// (inclusive). Empty keys are not valid. RocksDB uses native range deletes, while other
+	// backends emulate it by iterating over the range.
+	// CONTRACT: start, end readonly []byte
+	DeleteRange(start, end []byte) error
+
+	// Update atomically updates a key, by calling fn with its current value (or nil if it does not
+	// exist) and writing the value returned by fn, or deleting the key if fn returns delete. If fn
+	// returns an error, nothing is written and the error is returned. Updates are atomic with
+	// respect to other updates of the same key. GoLevelDB, CLevelDB and RocksDB serialize updates
+	// with key locks, so writes of the key by other methods during an update may be overwritten.
+	// fn may be called more than once, and must not use the database. Empty keys are not valid.
+	// CONTRACT: key readonly []byte, and fn must not modify the value passed to it
+	Update(key []byte, fn UpdateFunc) error
+
+	// CompareAndSwap atomically sets a key to value if its current value equals expected, and
+	// returns whether it did. A nil expected value requires the key not to exist, and a nil value
+	// deletes the key. It is atomic with respect to updates of the key, with the same caveats as
+	// Update. Empty keys are not valid.
+	// CONTRACT: key, expected, value readonly []byte
+	CompareAndSwap(key, expected, value []byte) (bool, error)
+
 	// Iterator returns an iterator over a domain of keys, in ascending order. The caller must call
 	// Close when done. End is exclusive, and start must be less than end. A nil start iterates
 	// from the first key, and a nil end iterates to the last key (inclusive). Empty keys are not
@@ -57,17 +107,231 @@ type DB interface {
 	// CONTRACT: start, end readonly []byte
 	ReverseIterator(start, end []byte) (Iterator, error)
 
-	// Close closes the database connection.
+	// NewSnapshot returns a read-only view of the database, frozen at the time of the call. Writes
+	// made after the snapshot was taken are not visible through it, and unlike iterators on the
+	// database itself, iterators over a snapshot allow concurrent writes to their domain. The
+	// caller must call Close on the snapshot when done.
+	NewSnapshot() (Snapshot, error)
+
+	// Close closes the database connection. Iterators, snapshots and batches must be closed or
+	// written first. Further use of the database, including closing it again, returns ErrClosed.
 	Close() error
 
 	// NewBatch creates a batch for atomic updates. The caller must call Batch.Close.
 	NewBatch() Batch
 
-	// Print is used for debugging.
-	Print() error
+	// Dump writes the key/value pairs in a range to w, for debugging.
+	Dump(w io.Writer, opts DumpOptions) error
+
+	// Stats returns statistics about the database.
+	Stats() (*Stats, error)
+}
+
+// Stats contains statistics about a database. Fields which a backend does not provide are zero,
+// and sizes are in bytes.
+type Stats struct {
+	// Backend is the type of the database backend, e.g. "goleveldb".
+	Backend string
+	// KeyCount is the approximate number of keys.
+	KeyCount int64
+	// DiskSize is the approximate size of the database on disk.
+	DiskSize int64
+	// MemTableSize is the size of the in-memory write buffers, or all data for in-memory databases.
+	MemTableSize int64
+	// CacheSize is the size of the block cache.
+	CacheSize int64
+	// OpenIterators is the number of open iterators.
+	OpenIterators int64
+	// Compactions is the number of compactions since the database was opened.
+	Compactions int64
+	// Properties contains backend-specific statistics.
+	Properties map[string]string
+}
+
+// UpdateFunc computes the new value of a key for DB.Update, given its current value or nil if it
+// does not exist. It returns either the new value, or delete as true to delete the key.
+type UpdateFunc func(value []byte) (newValue []byte, delete bool, err error)
+
+// Reader is the read-only subset of the DB interface, implemented by DB, Snapshot and Transaction.
+// Methods follow the same contracts as the corresponding DB methods.
+type Reader interface {
+	// Get fetches the value of the given key, or nil if it does not exist.
+	// CONTRACT: key, value readonly []byte
+	Get([]byte) ([]byte, error)
+
+	// Has checks if a key exists.
+	// CONTRACT: key, value readonly []byte
+	Has(key []byte) (bool, error)
+
+	// Iterator returns an iterator over a domain of keys, in ascending order. The caller must call
+	// Close when done. See DB.Iterator for details.
+	// CONTRACT: start, end readonly []byte
+	Iterator(start, end []byte) (Iterator, error)
+
+	// ReverseIterator returns an iterator over a domain of keys, in descending order. The caller
+	// must call Close when done. See DB.ReverseIterator for details.
+	// CONTRACT: start, end readonly []byte
+	ReverseIterator(start, end []byte) (Iterator, error)
+}
+
+// Snapshot is a consistent, read-only view of a database at a point in time. Snapshots are
+// concurrency-safe, and callers must call Close when done, which may release resources held by
+// the backend (e.g. preventing compaction of old data).
+type Snapshot interface {
+	Reader
+
+	// Close releases the snapshot. Iterators created from the snapshot must be closed first.
+	Close() error
+}
+
+// Transaction is an optimistic read-write transaction. Reads see a consistent view of the
+// database as of the start of the transaction, along with the transaction's own writes, and
+// writes are buffered until Commit. Commit writes atomically, and fails with ErrConflict if any
+// key read by the transaction (including keys seen or skipped over by iterators) was modified
+// since the transaction began. Transactions are not concurrency-safe, and callers must call Close
+// when done.
+//
+// As with DB, given keys and values should be considered read-only, and must not be modified after
+// passing them to the transaction.
+type Transaction interface {
+	Reader
+
+	// Set sets the value for the given key, replacing it if it already exists.
+	// CONTRACT: key, value readonly []byte
+	Set(key, value []byte) error
+
+	// Delete deletes the key, or does nothing if the key does not exist.
+	// CONTRACT: key readonly []byte
+	Delete(key []byte) error
+
+	// Commit atomically writes the transaction to the database, or returns ErrConflict if it
+	// conflicts with a concurrent write, in which case nothing is written and the caller may retry
+	// with a new transaction. Iterators must be closed first. Only Close() can be called after,
+	// other methods will error.
+	Commit() error
+
+	// Close discards the transaction if it has not been committed. It is idempotent, but calls to
+	// other methods afterwards will error.
+	Close() error
+}
+
+// TransactionDB is implemented by databases which support optimistic read-write transactions.
+type TransactionDB interface {
+	DB
+
+	// NewTransaction begins a new read-write transaction. The caller must call Close on the
+	// transaction when done.
+	NewTransaction() (Transaction, error)
+}
+
+// TTLDB is implemented by databases which support keys that expire, such as BadgerDB. Other
+// databases can be wrapped with the ttldb package to support it.
+type TTLDB interface {
+	DB
+
+	// SetWithTTL sets the value for the given key, which expires after the given time-to-live.
+	// Expired keys are not visible to reads, and are deleted in the background. Setting the key
+	// again, with or without a TTL, replaces the TTL. The TTL must be positive.
+	// CONTRACT: key, value readonly []byte
+	SetWithTTL(key, value []byte, ttl time.Duration) error
+}
+
+// MultiGetDB is implemented by databases which can get many keys at once more efficiently than
+// by calling Get for each key. GetMany can be used to get many keys from any database.
+type MultiGetDB interface {
+	DB
+
+	// GetMany fetches the values of the given keys, returning them in the same order as the keys.
+	// As with Get, the value of a missing key is nil. The keys are read from a consistent view of
+	// the database, unless the backend documents otherwise. Empty keys are not valid.
+	// CONTRACT: keys and the returned values readonly []byte
+	GetMany(keys [][]byte) ([][]byte, error)
+}
+
+// KeysIteratorDB is implemented by databases which can iterate over keys without reading their
+// values, e.g. to count or prune keys. KeysIterator can be used to iterate over the keys of any
+// database.
+type KeysIteratorDB interface {
+	DB
+
+	// KeysIterator returns an iterator over a domain of keys like Iterator, except that Value
+	// returns nil. Values are not read, where the backend can avoid it.
+	KeysIterator(start, end []byte) (Iterator, error)
+
+	// ReverseKeysIterator returns an iterator over a domain of keys like ReverseIterator, except
+	// that Value returns nil.
+	ReverseKeysIterator(start, end []byte) (Iterator, error)
+}
+
+// IteratorOptionsDB is implemented by databases which can tune iterators with IteratorOptions.
+// IteratorWithOptions can be used to create iterators with options for any database.
+type IteratorOptionsDB interface {
+	DB
+
+	// IteratorWithOptions returns an iterator over a domain of keys like Iterator, configured by
+	// the given options. Options which the backend does not support are ignored, except for
+	// Reverse and KeysOnly.
+	IteratorWithOptions(start, end []byte, opts IteratorOptions) (Iterator, error)
+}
+
+// ApproximateSizeDB is implemented by databases which can estimate the size of a range of keys
+// and the number of keys in it without scanning the whole range, e.g. to choose batch sizes.
+type ApproximateSizeDB interface {
+	DB
+
+	// ApproximateSize returns the approximate size in bytes of the keys in the range
+	// [start, end), where nil bounds are the ends of the database. On-disk backends measure the
+	// storage space used, which may not include recent writes. Empty keys are not valid.
+	ApproximateSize(start, end []byte) (int64, error)
+
+	// ApproximateCount returns the approximate number of keys in the range [start, end), where
+	// nil bounds are the ends of the database. Empty keys are not valid.
+	ApproximateCount(start, end []byte) (int64, error)
+}
+
+// ComparerDB is implemented by databases which can order keys with a custom Comparer, given when
+// opening them. KeyComparer returns the order of any database.
+type ComparerDB interface {
+	DB
+
+	// Comparer returns the comparer which orders the database's keys.
+	Comparer() Comparer
+}
+
+// CompactionDB is implemented by on-disk databases which support manual compaction, e.g. to
+// reclaim disk space after deleting many keys instead of waiting for background compaction.
+type CompactionDB interface {
+	DB
+
+	// Compact compacts the underlying storage for the key range [start, end), where nil start
+	// and end are the first and last keys in the database. Backends which can only compact the
+	// whole database ignore the range. It may block for a long time, and backends may block
+	// other operations during compaction. Empty keys are not valid.
+	// CONTRACT: start, end readonly []byte
+	Compact(start, end []byte) error
+}
 
-	// Stats returns a map of property values for all keys and the size of the cache.
-	Stats() map[string]string
+// CheckpointDB is implemented by on-disk databases which can create an online backup of
+// themselves. In-memory databases can be copied into another database using Copy and a Snapshot.
+type CheckpointDB interface {
+	DB
+
+	// Checkpoint writes a consistent, point-in-time copy of the database into the directory dir,
+	// which is created if it does not exist, without blocking writes. The copy can be opened by
+	// passing dir, along with the name the database was opened with, to the backend's NewDB. It
+	// errors if the copy's database files already exist in dir.
+	Checkpoint(dir string) error
+}
+
+// WatchableDB is a database which delivers changes to keys to subscribers, e.g. for indexing them
+// without polling. Databases can be wrapped with watchdb to implement it.
+type WatchableDB interface {
+	DB
+
+	// Watch subscribes to changes made to keys with the given prefix, or to all keys if the
+	// prefix is empty, after Watch returns. Events are delivered in the order the changes were
+	// applied, including changes written by batches. The watcher must be closed when done.
+	Watch(prefix []byte) (Watcher, error)
 }
 
 // Batch represents a group of writes. They may or may not be written atomically depending on the
@@ -84,6 +348,13 @@ type Batch interface {
 	// CONTRACT: key readonly []byte
 	Delete(key []byte) error
 
+	// DeleteRange deletes all keys in the domain [start, end), including keys set earlier in the
+	// batch. See DB.DeleteRange for details. Backends without native range deletes may find the
+	// keys to delete when DeleteRange is called, in which case keys written to the database after
+	// the call but before the batch is written are not deleted.
+	// CONTRACT: start, end readonly []byte
+	DeleteRange(start, end []byte) error
+
 	// Write writes the batch, possibly without flushing to disk. Only Close() can be called after,
 	// other methods will error.
 	Write() error
@@ -124,7 +395,7 @@ type Iterator interface {
 	Domain() (start []byte, end []byte)
 
 	// Valid returns whether the current iterator is valid. Once invalid, the Iterator remains
-	// invalid forever.
+	// invalid forever, unless repositioned with SeekableIterator.Seek.
 	Valid() bool
 
 	// Next moves the iterator to the next key in the database, as defined by order of iteration.
@@ -145,3 +416,81 @@ type Iterator interface {
 	// Close closes the iterator, relasing any allocated resources.
 	Close() error
 }
+
+// SeekableIterator is an Iterator which can be repositioned within its domain without having to
+// close it and create a new one. Iterators of most backends implement it, which can be checked
+// with a type assertion.
+type SeekableIterator interface {
+	Iterator
+
+	// Seek moves the iterator to the given key if it exists, or otherwise to the next key in
+	// iteration order: the first key after it for ascending iterators, and the last key before it
+	// for descending iterators. Keys outside of the iterator's domain are clamped to it. Seek may
+	// be called on an invalid iterator, and makes it valid again if a key is found. Empty keys are
+	// not valid.
+	// CONTRACT: key readonly []byte
+	Seek(key []byte)
+}
+
+// EventType is the type of change to a key delivered by a Watcher.
+type EventType int
+
+const (
+	// EventSet is a key being set.
+	EventSet EventType = iota
+	// EventDelete is a key being deleted, which may not have existed.
+	EventDelete
+)
+
+// Event is a change to a key delivered by a Watcher.
+type Event struct {
+	Type EventType
+	Key  []byte
+	// Value is the new value of the key, or nil for EventDelete.
+	Value []byte
+}
+
+// Watcher delivers changes made to keys in a WatchableDB.
+type Watcher interface {
+	// Events returns the channel that events are delivered on, which is closed when the watcher
+	// is closed, either by Close or because it failed.
+	Events() <-chan Event
+
+	// Err returns the error which closed the watcher once the events channel is closed, if any.
+	// It returns ErrWatcherOverflow if events were not received fast enough.
+	Err() error
+
+	// Close stops delivering events and closes the events channel.
+	Close() error
+}
+
+// IteratorOptions configures an iterator created with IteratorWithOptions. The zero value gives
+// a forward iterator which does not fill the block cache, suitable for scans.
+type IteratorOptions struct {
+	// Reverse iterates in reverse, like ReverseIterator.
+	Reverse bool
+	// KeysOnly iterates without values, like KeysIterator.
+	KeysOnly bool
+	// FillCache adds blocks read by the iterator to the block cache. Large scans should leave it
+	// unset, to avoid evicting frequently read data.
+	FillCache bool
+	// PrefetchSize is the number of values to fetch ahead of the iterator, or 0 for the backend
+	// default.
+	PrefetchSize int
+	// ReadAheadSize is the number of bytes to read ahead from disk, or 0 for the backend default.
+	ReadAheadSize int
+}
+
+// Comparer defines a total order of keys, for databases which order keys other than by their
+// bytes. Key ranges such as iterator domains are interpreted in this order. Backends rely on the
+// empty key being ordered before all other keys, and on a key followed by a 0 byte being the next
+// key after it, as in byte order. PrefixDB also requires keys with a common prefix to be ordered
+// contiguously.
+type Comparer interface {
+	// Compare returns -1, 0 or +1 if a is less than, equal to or greater than b.
+	Compare(a, b []byte) int
+
+	// Name identifies the order. On-disk backends record it, and fail to open a database with a
+	// comparer of a different name, since the keys would be out of order.
+	Name() string
+}
@@ -45,6 +45,17 @@ func IsKeyInDomain(key, start, end []byte) bool {
 	return true
 }
 
+// IsKeyInDomainWithComparer is like IsKeyInDomain, but orders keys with the given comparer.
+func IsKeyInDomainWithComparer(cmp Comparer, key, start, end []byte) bool {
+	if start != nil && cmp.Compare(key, start) < 0 {
+		return false
+	}
+	if end != nil && cmp.Compare(end, key) <= 0 {
+		return false
+	}
+	return true
+}
+
 func FileExists(filePath string) bool {
 	_, err := os.Stat(filePath)
 	return !os.IsNotExist(err)
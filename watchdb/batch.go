@@ -0,0 +1,100 @@
+package watchdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// operation is a write in a batch, either an event or a range deletion.
+type operation struct {
+	event       tmdb.Event
+	deleteRange bool
+	start, end  []byte
+}
+
+// watchDBBatch records the writes to a batch, to publish them once it is written.
+type watchDBBatch struct {
+	db     *WatchDB
+	source tmdb.Batch
+	ops    []operation
+}
+
+var _ tmdb.Batch = (*watchDBBatch)(nil)
+
+func newWatchDBBatch(db *WatchDB, source tmdb.Batch) *watchDBBatch {
+	return &watchDBBatch{db: db, source: source}
+}
+
+// Set implements Batch.
+func (b *watchDBBatch) Set(key, value []byte) error {
+	if err := b.source.Set(key, value); err != nil {
+		return err
+	}
+	b.ops = append(b.ops, operation{event: tmdb.Event{Type: tmdb.EventSet, Key: key, Value: value}})
+	return nil
+}
+
+// Delete implements Batch.
+func (b *watchDBBatch) Delete(key []byte) error {
+	if err := b.source.Delete(key); err != nil {
+		return err
+	}
+	b.ops = append(b.ops, operation{event: tmdb.Event{Type: tmdb.EventDelete, Key: key}})
+	return nil
+}
+
+// DeleteRange implements Batch.
+func (b *watchDBBatch) DeleteRange(start, end []byte) error {
+	if err := b.source.DeleteRange(start, end); err != nil {
+		return err
+	}
+	b.ops = append(b.ops, operation{deleteRange: true, start: start, end: end})
+	return nil
+}
+
+// Write implements Batch.
+func (b *watchDBBatch) Write() error {
+	return b.write(b.source.Write)
+}
+
+// WriteSync implements Batch.
+func (b *watchDBBatch) WriteSync() error {
+	return b.write(b.source.WriteSync)
+}
+
+// Close implements Batch.
+func (b *watchDBBatch) Close() error {
+	b.ops = nil
+	return b.source.Close()
+}
+
+// write writes the batch and publishes its events, while holding the database write lock.
+func (b *watchDBBatch) write(apply func() error) error {
+	b.db.mtx.Lock()
+	defer b.db.mtx.Unlock()
+
+	// Range deletions are expanded into events before the batch is written, including keys set
+	// earlier in the batch.
+	events := make([]tmdb.Event, 0, len(b.ops))
+	written := [][]byte{}
+	for _, op := range b.ops {
+		if !op.deleteRange {
+			events = append(events, op.event)
+			if op.event.Type == tmdb.EventSet {
+				written = append(written, op.event.Key)
+			}
+			continue
+		}
+		rangeEvents, err := b.db.deleteRangeEvents(op.start, op.end, written)
+		if err != nil {
+			return err
+		}
+		events = append(events, rangeEvents...)
+	}
+
+	if err := apply(); err != nil {
+		return err
+	}
+	b.ops = nil
+	b.db.publish(events...)
+	return nil
+}
@@ -0,0 +1,332 @@
+// Package watchdb provides a database wrapper which delivers changes to keys to subscribers, for
+// any database backend.
+package watchdb
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// DefaultBufferSize is the default number of events buffered for each watcher.
+const DefaultBufferSize = 1024
+
+// Options configures a WatchDB.
+type Options struct {
+	// BufferSize is the number of events buffered for each watcher. Watchers which fall further
+	// behind are closed with ErrWatcherOverflow, rather than blocking writes. Defaults to
+	// DefaultBufferSize if 0.
+	BufferSize int
+}
+
+// WatchDB wraps a database to implement WatchableDB. Writes are serialized, such that events are
+// delivered in the order the writes were applied, and must all go through the WatchDB to be
+// delivered. Writes made via transactions of the wrapped database are not delivered, and
+// WatchDB does not implement TransactionDB.
+type WatchDB struct {
+	mtx      sync.Mutex // serializes writes, and guards watchers and closed
+	source   tmdb.DB
+	opts     Options
+	watchers map[*watcher]struct{}
+	closed   bool
+}
+
+var (
+	_ tmdb.WatchableDB       = (*WatchDB)(nil)
+	_ tmdb.KeysIteratorDB    = (*WatchDB)(nil)
+	_ tmdb.IteratorOptionsDB = (*WatchDB)(nil)
+	_ tmdb.ApproximateSizeDB = (*WatchDB)(nil)
+	_ tmdb.ComparerDB        = (*WatchDB)(nil)
+)
+
+// NewDB wraps a database with default options.
+func NewDB(db tmdb.DB) *WatchDB {
+	return NewDBWithOpts(db, Options{})
+}
+
+// NewDBWithOpts wraps a database with the given options.
+func NewDBWithOpts(db tmdb.DB, opts Options) *WatchDB {
+	if opts.BufferSize == 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	return &WatchDB{
+		source:   db,
+		opts:     opts,
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+// Watch implements WatchableDB. It fails with ErrClosed once the database is closed.
+func (db *WatchDB) Watch(prefix []byte) (tmdb.Watcher, error) {
+	w := &watcher{
+		db:     db,
+		prefix: append([]byte{}, prefix...),
+		events: make(chan tmdb.Event, db.opts.BufferSize),
+	}
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if db.closed {
+		return nil, tmdb.ErrClosed
+	}
+	db.watchers[w] = struct{}{}
+	return w, nil
+}
+
+// Get implements DB.
+func (db *WatchDB) Get(key []byte) ([]byte, error) {
+	return db.source.Get(key)
+}
+
+// Has implements DB.
+func (db *WatchDB) Has(key []byte) (bool, error) {
+	return db.source.Has(key)
+}
+
+// Iterator implements DB.
+func (db *WatchDB) Iterator(start, end []byte) (tmdb.Iterator, error) {
+	return db.source.Iterator(start, end)
+}
+
+// ReverseIterator implements DB.
+func (db *WatchDB) ReverseIterator(start, end []byte) (tmdb.Iterator, error) {
+	return db.source.ReverseIterator(start, end)
+}
+
+// KeysIterator implements KeysIteratorDB.
+func (db *WatchDB) KeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	return tmdb.KeysIterator(db.source, start, end)
+}
+
+// ReverseKeysIterator implements KeysIteratorDB.
+func (db *WatchDB) ReverseKeysIterator(start, end []byte) (tmdb.Iterator, error) {
+	return tmdb.ReverseKeysIterator(db.source, start, end)
+}
+
+// IteratorWithOptions implements IteratorOptionsDB.
+func (db *WatchDB) IteratorWithOptions(start, end []byte, opts tmdb.IteratorOptions) (tmdb.Iterator, error) {
+	return tmdb.IteratorWithOptions(db.source, start, end, opts)
+}
+
+// Comparer implements ComparerDB.
+func (db *WatchDB) Comparer() tmdb.Comparer {
+	return tmdb.KeyComparer(db.source)
+}
+
+// ApproximateSize implements ApproximateSizeDB.
+func (db *WatchDB) ApproximateSize(start, end []byte) (int64, error) {
+	return tmdb.ApproximateSize(db.source, start, end)
+}
+
+// ApproximateCount implements ApproximateSizeDB.
+func (db *WatchDB) ApproximateCount(start, end []byte) (int64, error) {
+	return tmdb.ApproximateCount(db.source, start, end)
+}
+
+// Set implements DB.
+func (db *WatchDB) Set(key, value []byte) error {
+	return db.write(func() error {
+		return db.source.Set(key, value)
+	}, tmdb.Event{Type: tmdb.EventSet, Key: key, Value: value})
+}
+
+// SetSync implements DB.
+func (db *WatchDB) SetSync(key, value []byte) error {
+	return db.write(func() error {
+		return db.source.SetSync(key, value)
+	}, tmdb.Event{Type: tmdb.EventSet, Key: key, Value: value})
+}
+
+// Delete implements DB.
+func (db *WatchDB) Delete(key []byte) error {
+	return db.write(func() error {
+		return db.source.Delete(key)
+	}, tmdb.Event{Type: tmdb.EventDelete, Key: key})
+}
+
+// DeleteSync implements DB.
+func (db *WatchDB) DeleteSync(key []byte) error {
+	return db.write(func() error {
+		return db.source.DeleteSync(key)
+	}, tmdb.Event{Type: tmdb.EventDelete, Key: key})
+}
+
+// DeleteRange implements DB. If any watchers match keys in the range, the keys are read before
+// they are deleted, to deliver an event for each of them.
+func (db *WatchDB) DeleteRange(start, end []byte) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	events, err := db.deleteRangeEvents(start, end, nil)
+	if err != nil {
+		return err
+	}
+	if err := db.source.DeleteRange(start, end); err != nil {
+		return err
+	}
+	db.publish(events...)
+	return nil
+}
+
+// Update implements DB. The update function is called while holding the write lock.
+func (db *WatchDB) Update(key []byte, fn tmdb.UpdateFunc) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	// The update function may be called several times by the wrapped database, in which case
+	// the last call determines the change.
+	var event tmdb.Event
+	err := db.source.Update(key, func(value []byte) ([]byte, bool, error) {
+		newValue, del, err := fn(value)
+		if del {
+			event = tmdb.Event{Type: tmdb.EventDelete, Key: key}
+		} else {
+			event = tmdb.Event{Type: tmdb.EventSet, Key: key, Value: newValue}
+		}
+		return newValue, del, err
+	})
+	if err != nil {
+		return err
+	}
+	db.publish(event)
+	return nil
+}
+
+// CompareAndSwap implements DB.
+func (db *WatchDB) CompareAndSwap(key, expected, value []byte) (bool, error) {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	swapped, err := db.source.CompareAndSwap(key, expected, value)
+	if err != nil || !swapped {
+		return swapped, err
+	}
+	if value == nil {
+		db.publish(tmdb.Event{Type: tmdb.EventDelete, Key: key})
+	} else {
+		db.publish(tmdb.Event{Type: tmdb.EventSet, Key: key, Value: value})
+	}
+	return true, nil
+}
+
+// NewSnapshot implements DB.
+func (db *WatchDB) NewSnapshot() (tmdb.Snapshot, error) {
+	return db.source.NewSnapshot()
+}
+
+// NewBatch implements DB.
+func (db *WatchDB) NewBatch() tmdb.Batch {
+	return newWatchDBBatch(db, db.source.NewBatch())
+}
+
+// Close implements DB. It closes all watchers, and the wrapped database.
+func (db *WatchDB) Close() error {
+	db.mtx.Lock()
+	db.closed = true
+	for w := range db.watchers {
+		w.close(nil)
+	}
+	db.mtx.Unlock()
+	return db.source.Close()
+}
+
+// Dump implements DB.
+func (db *WatchDB) Dump(w io.Writer, opts tmdb.DumpOptions) error {
+	return db.source.Dump(w, opts)
+}
+
+// Stats implements DB.
+func (db *WatchDB) Stats() (*tmdb.Stats, error) {
+	return db.source.Stats()
+}
+
+// write applies a write and publishes its events, while holding the write lock.
+func (db *WatchDB) write(apply func() error, events ...tmdb.Event) error {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	if err := apply(); err != nil {
+		return err
+	}
+	db.publish(events...)
+	return nil
+}
+
+// watched returns whether any watchers match the key. The write lock must be held.
+func (db *WatchDB) watched(key []byte) bool {
+	for w := range db.watchers {
+		if bytes.HasPrefix(key, w.prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteRangeEvents returns delete events for the watched keys in the range, i.e. keys which
+// exist in the wrapped database and the given keys which are about to be written. The write lock
+// must be held.
+func (db *WatchDB) deleteRangeEvents(start, end []byte, written [][]byte) ([]tmdb.Event, error) {
+	if len(db.watchers) == 0 {
+		return nil, nil
+	}
+	itr, err := db.source.Iterator(start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	seen := make(map[string]bool)
+	events := []tmdb.Event{}
+	add := func(key []byte) {
+		if !seen[string(key)] && db.watched(key) {
+			seen[string(key)] = true
+			// Iterators may reuse the key slice, so it must be copied.
+			events = append(events, tmdb.Event{Type: tmdb.EventDelete, Key: append([]byte{}, key...)})
+		}
+	}
+	for ; itr.Valid(); itr.Next() {
+		add(itr.Key())
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	cmp := tmdb.KeyComparer(db.source)
+	for _, key := range written {
+		if tmdb.IsKeyInDomainWithComparer(cmp, key, start, end) {
+			add(key)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return cmp.Compare(events[i].Key, events[j].Key) < 0
+	})
+	return events, nil
+}
+
+// publish delivers events to matching watchers, closing watchers whose buffers are full. The
+// write lock must be held.
+func (db *WatchDB) publish(events ...tmdb.Event) {
+	for _, event := range events {
+		// The event is copied once any watcher matches it, since callers may reuse the slices.
+		copied := false
+		for w := range db.watchers {
+			if !bytes.HasPrefix(event.Key, w.prefix) {
+				continue
+			}
+			if !copied {
+				event.Key = append([]byte{}, event.Key...)
+				if event.Value != nil {
+					event.Value = append([]byte{}, event.Value...)
+				}
+				copied = true
+			}
+			select {
+			case w.events <- event:
+			default:
+				w.close(tmdb.ErrWatcherOverflow)
+			}
+		}
+	}
+}
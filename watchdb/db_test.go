@@ -0,0 +1,142 @@
+package watchdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmdb "github.com/tendermint/tm-db"
+	"github.com/tendermint/tm-db/memdb"
+)
+
+func set(key, value string) tmdb.Event {
+	return tmdb.Event{Type: tmdb.EventSet, Key: []byte(key), Value: []byte(value)}
+}
+
+func del(key string) tmdb.Event {
+	return tmdb.Event{Type: tmdb.EventDelete, Key: []byte(key)}
+}
+
+// receive returns the events buffered by a watcher.
+func receive(w tmdb.Watcher) []tmdb.Event {
+	events := []tmdb.Event{}
+	for {
+		select {
+		case event, ok := <-w.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestWatchDB(t *testing.T) {
+	db := NewDB(memdb.NewDB())
+	require.NoError(t, db.Set([]byte("a1"), []byte("0")))
+
+	all, err := db.Watch(nil)
+	require.NoError(t, err)
+	a, err := db.Watch([]byte("a"))
+	require.NoError(t, err)
+
+	require.NoError(t, db.Set([]byte("a1"), []byte("1")))
+	require.NoError(t, db.SetSync([]byte("b1"), []byte("2")))
+	require.NoError(t, db.Delete([]byte("a1")))
+	require.NoError(t, db.DeleteSync([]byte("b1")))
+	require.Equal(t, tmdb.ErrKeyEmpty, db.Set([]byte{}, []byte("3")))
+
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte("a2"), []byte("4")))
+	require.NoError(t, batch.Set([]byte("a3"), []byte("5")))
+	require.NoError(t, batch.Set([]byte("b2"), []byte("6")))
+	require.NoError(t, batch.Delete([]byte("a3")))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+
+	require.NoError(t, db.Update([]byte("a2"), func(value []byte) ([]byte, bool, error) {
+		return append(value, '7'), false, nil
+	}))
+	swapped, err := db.CompareAndSwap([]byte("a2"), []byte("0"), nil)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	swapped, err = db.CompareAndSwap([]byte("a2"), []byte("47"), nil)
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	require.Equal(t, []tmdb.Event{
+		set("a1", "1"), del("a1"),
+		set("a2", "4"), set("a3", "5"), del("a3"),
+		set("a2", "47"), del("a2"),
+	}, receive(a))
+	require.Equal(t, []tmdb.Event{
+		set("a1", "1"), set("b1", "2"), del("a1"), del("b1"),
+		set("a2", "4"), set("a3", "5"), set("b2", "6"), del("a3"),
+		set("a2", "47"), del("a2"),
+	}, receive(all))
+
+	// closed watchers should no longer receive events
+	require.NoError(t, all.Close())
+	require.NoError(t, db.Set([]byte("a4"), []byte("8")))
+	_, ok := <-all.Events()
+	require.False(t, ok)
+	require.NoError(t, all.Err())
+	require.Equal(t, []tmdb.Event{set("a4", "8")}, receive(a))
+
+	// closing the database should close its watchers
+	require.NoError(t, db.Close())
+	_, ok = <-a.Events()
+	require.False(t, ok)
+	require.NoError(t, a.Err())
+	_, err = db.Watch(nil)
+	require.Equal(t, tmdb.ErrClosed, err)
+}
+
+func TestWatchDBDeleteRange(t *testing.T) {
+	db := NewDB(memdb.NewDB())
+	defer db.Close()
+	for _, key := range []string{"a1", "a2", "b1", "b2"} {
+		require.NoError(t, db.Set([]byte(key), []byte("0")))
+	}
+	w, err := db.Watch([]byte("a"))
+	require.NoError(t, err)
+
+	require.NoError(t, db.DeleteRange([]byte("a2"), []byte("b2")))
+	require.Equal(t, []tmdb.Event{del("a2")}, receive(w))
+
+	// range deletions in batches should include keys set earlier in the batch
+	batch := db.NewBatch()
+	require.NoError(t, batch.Set([]byte("a3"), []byte("1")))
+	require.NoError(t, batch.Set([]byte("a0"), []byte("2")))
+	require.NoError(t, batch.DeleteRange(nil, []byte("b")))
+	require.NoError(t, batch.Set([]byte("a4"), []byte("3")))
+	require.NoError(t, batch.Write())
+	require.NoError(t, batch.Close())
+	require.Equal(t, []tmdb.Event{
+		set("a3", "1"), set("a0", "2"), del("a0"), del("a1"), del("a3"), set("a4", "3"),
+	}, receive(w))
+
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	keys := []string{}
+	for ; itr.Valid(); itr.Next() {
+		keys = append(keys, string(itr.Key()))
+	}
+	require.NoError(t, itr.Close())
+	require.Equal(t, []string{"a4", "b2"}, keys)
+}
+
+func TestWatchDBOverflow(t *testing.T) {
+	db := NewDBWithOpts(memdb.NewDB(), Options{BufferSize: 2})
+	defer db.Close()
+	w, err := db.Watch(nil)
+	require.NoError(t, err)
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, db.Set([]byte(key), []byte("0")))
+	}
+	require.Equal(t, []tmdb.Event{set("a", "0"), set("b", "0")}, receive(w))
+	require.Equal(t, tmdb.ErrWatcherOverflow, w.Err())
+	require.NoError(t, w.Close())
+}
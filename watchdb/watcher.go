@@ -0,0 +1,49 @@
+package watchdb
+
+import (
+	tmdb "github.com/tendermint/tm-db"
+)
+
+// watcher is a subscription to changes to keys with a prefix. Its fields other than the events
+// channel are guarded by the database write lock.
+type watcher struct {
+	db     *WatchDB
+	prefix []byte
+	events chan tmdb.Event
+	err    error
+	closed bool
+}
+
+var _ tmdb.Watcher = (*watcher)(nil)
+
+// Events implements Watcher.
+func (w *watcher) Events() <-chan tmdb.Event {
+	return w.events
+}
+
+// Err implements Watcher.
+func (w *watcher) Err() error {
+	w.db.mtx.Lock()
+	defer w.db.mtx.Unlock()
+	return w.err
+}
+
+// Close implements Watcher.
+func (w *watcher) Close() error {
+	w.db.mtx.Lock()
+	defer w.db.mtx.Unlock()
+	w.close(nil)
+	return nil
+}
+
+// close unsubscribes the watcher and closes its events channel, if not already closed. The
+// database write lock must be held.
+func (w *watcher) close(err error) {
+	if w.closed {
+		return
+	}
+	w.closed = true
+	w.err = err
+	delete(w.db.watchers, w)
+	close(w.events)
+}